@@ -1,16 +1,25 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"TXLOCK/internal/agent"
+	"TXLOCK/internal/archive"
 	"TXLOCK/internal/derive"
+	"TXLOCK/internal/errcode"
+	"TXLOCK/internal/ethsig"
+	"TXLOCK/internal/fieldlock"
 	"TXLOCK/internal/lockcore"
+	"TXLOCK/internal/secret"
+	"TXLOCK/internal/seqstate"
 )
 
 func main() {
@@ -27,90 +36,416 @@ func run(args []string, getenv func(string) string) int {
 	outPath := fs.String("out", "", "")
 	mnemonicEnv := fs.String("mnemonic-env", "", "")
 	decIndex := fs.String("index", "", "")
+	fieldsFormat := fs.String("fields", "", "")
+	extractDir := fs.String("extract", "", "")
+	listOnly := fs.Bool("list", false, "")
+	maxSize := fs.Int64("max-size", lockcore.DefaultMaxPlaintext, "")
+	ignoreMeta := fs.Bool("ignore-meta", false, "")
+	passwordEnv := fs.String("password-env", "", "")
+	passwordPrompt := fs.Bool("password-prompt", false, "")
+	argonMaxMemory := fs.Uint("argon2-max-memory", uint(lockcore.DefaultMaxArgon2ParamsV2.Memory), "")
+	argonMaxTime := fs.Uint("argon2-max-time", uint(lockcore.DefaultMaxArgon2ParamsV2.Time), "")
+	label := fs.String("label", "", "")
+	allowRollback := fs.Bool("allow-rollback", false, "")
+	statePath := fs.String("state", "", "")
+	asJSON := fs.Bool("json", false, "")
 
 	if err := fs.Parse(args); err != nil {
 		if err == flag.ErrHelp {
 			printDecUsage()
 			return 0
 		}
+		if errcode.WantsJSON(args) {
+			return (&errcode.Reporter{Tool: "txlock-dec", JSON: true, W: os.Stderr}).Usage(err.Error())
+		}
 		return 1
 	}
+	r := &errcode.Reporter{Tool: "txlock-dec", JSON: *asJSON, W: os.Stderr, File: *inPath, Index: *decIndex}
 	if fs.NArg() != 0 {
-		return 1
+		return r.Usage("unexpected argument: " + fs.Arg(0))
+	}
+	archiveMode := *extractDir != "" || *listOnly
+	if archiveMode && (*outPath != "" || *fieldsFormat != "" || (*extractDir != "" && *listOnly)) {
+		return r.Usage("-extract/-list cannot be combined with -out, -fields or each other")
+	}
+	explicitOut := *outPath != ""
+	passwordGiven := *passwordEnv != "" || *passwordPrompt
+	if *passwordEnv != "" && *passwordPrompt {
+		return r.Usage("-password-env and -password-prompt are mutually exclusive")
+	}
+	if passwordGiven && *fieldsFormat != "" {
+		return r.Usage("-password-env/-password-prompt cannot be combined with -fields")
+	}
+	if *argonMaxMemory == 0 || *argonMaxMemory > math.MaxUint32 || *argonMaxTime == 0 || *argonMaxTime > math.MaxUint32 {
+		return r.Usage("invalid -argon2-max-memory/-argon2-max-time")
+	}
+	maxKDF := lockcore.Argon2ParamsV2{Memory: uint32(*argonMaxMemory), Time: uint32(*argonMaxTime)}
+	if *label != "" && !lockcore.ValidLabel(*label) {
+		return r.Usage("invalid -label: " + *label + " (1-64 of A-Z a-z 0-9 . _ / -)")
+	}
+	if *label != "" && (passwordGiven || *fieldsFormat != "") {
+		return r.Usage("-label cannot be combined with -fields or a password")
 	}
-	if *outPath == "" {
-		path, err := defaultDecOutPath(*inPath)
+	if passwordGiven && *mnemonicEnv == "" {
+		if *decIndex != "" {
+			return r.Usage("-index requires -mnemonic-env")
+		}
+		raw, err := readInputBytes(*inPath)
 		if err != nil {
-			return 2
+			return r.FailErr(err, "read input failed")
 		}
-		*outPath = path
-	}
-	if *mnemonicEnv == "" {
-		return failDecUsage("-mnemonic-env is required")
+		env, ok := parseEnvelope(stripSignature(raw))
+		if !ok {
+			return failInvalidEnvelope(r, stripSignature(raw))
+		}
+		r.Version = env.reportedVersion()
+		if env.kdf() == lockcore.KDFHybridV2 {
+			return r.Usage("envelope requires both factors: add -mnemonic-env and -index")
+		}
+		password, msg := resolveDecPassword(*passwordEnv, getenv)
+		if msg != "" {
+			return r.Usage(msg)
+		}
+		plain, meta, err := env.openPassword(password, lockcore.OpenOptionsV2{MaxPlaintext: *maxSize, MaxKDF: maxKDF})
+		if code := enforceSequence(r, env.header, err, *statePath, *allowRollback); code != 0 {
+			return code
+		}
+		return finishDecOpen(r, plain, meta, err, *inPath, *outPath, explicitOut, *extractDir, *listOnly, *ignoreMeta)
 	}
-	rawMnemonic := getenv(*mnemonicEnv)
-	if rawMnemonic == "" {
-		return failDecUsage("mnemonic env is empty: " + *mnemonicEnv)
+	deriveSK, msg := resolveKeySource(*mnemonicEnv, getenv)
+	if msg != "" {
+		return r.Usage(msg)
 	}
-	mnemonicCanonical, ok := canonicalizeMnemonic(rawMnemonic)
-	if !ok {
-		return failDecProcess("invalid mnemonic")
+	if deriveSK == nil {
+		return r.Fail(errcode.MnemonicInvalid, "invalid mnemonic")
 	}
 	raw, err := readInputBytes(*inPath)
 	if err != nil {
-		return failDecProcess("read input failed")
+		return r.FailErr(err, "read input failed")
 	}
 	if *decIndex == "" {
-		return failDecUsage("-index is required")
+		return r.Usage("-index is required")
 	}
 	if !validateIndex(*decIndex) {
-		return failDecUsage("invalid -index: " + *decIndex)
+		return r.Usage("invalid -index: " + *decIndex)
 	}
 	path, ok := buildPathFromIndex(*decIndex)
 	if !ok {
-		return failDecUsage("failed to build path from -index: " + *decIndex)
+		return r.Usage("failed to build path from -index: " + *decIndex)
 	}
-	_, saltB64, nonceB64, ct, ok := lockcore.ParseEnvelopeV1(string(raw))
+	if *fieldsFormat != "" {
+		format, msg := resolveFieldsFormat(*fieldsFormat, *inPath)
+		if msg != "" {
+			return r.Usage(msg)
+		}
+		sk, err := deriveSK(*decIndex)
+		if err != nil {
+			return r.FailErr(err, "derive key failed: "+err.Error())
+		}
+		plain, err := fieldlock.Decrypt(format, raw, sk, path)
+		if err != nil {
+			if err == lockcore.ErrNonCanonical {
+				return r.FailErr(err, "field decrypt failed: non-canonical base64 encoding (possible tampering)")
+			}
+			return r.FailErr(err, "field decrypt failed (index/mnemonic mismatch or tampered document)")
+		}
+		if !explicitOut {
+			if *outPath, err = defaultDecOutPath(*inPath, ""); err != nil {
+				return r.FailErr(err, "cannot create the default output directory")
+			}
+		}
+		r.Output = *outPath
+		if err := writeOutputBytes(*outPath, plain); err != nil {
+			return r.FailErr(err, "write output failed")
+		}
+		return r.Done()
+	}
+	env, ok := parseEnvelope(stripSignature(raw))
 	if !ok {
-		return failDecProcess("invalid envelope")
+		return failInvalidEnvelope(r, stripSignature(raw))
 	}
-	sk, err := derive.DeriveSK(mnemonicCanonical, *decIndex)
+	r.Version = env.reportedVersion()
+	sk, err := deriveSK(*decIndex)
 	if err != nil {
-		return failDecProcess("derive key failed")
+		return r.FailErr(err, "derive key failed: "+err.Error())
 	}
-	plain, err := lockcore.OpenV1(sk, path, saltB64, nonceB64, ct)
-	if err != nil {
-		return failDecProcess("decrypt failed (index/mnemonic mismatch or tampered data)")
+	opts := lockcore.OpenOptionsV2{MaxPlaintext: *maxSize, Label: *label, MaxKDF: maxKDF}
+	if env.kdf() != lockcore.KDFHybridV2 {
+		if passwordGiven {
+			return r.Usage("envelope does not use a password; drop -password-env/-password-prompt")
+		}
+		plain, meta, err := env.open(sk, path, opts)
+		if code := enforceSequence(r, env.header, err, *statePath, *allowRollback); code != 0 {
+			return code
+		}
+		return finishDecOpen(r, plain, meta, err, *inPath, *outPath, explicitOut, *extractDir, *listOnly, *ignoreMeta)
 	}
-	if err := writeOutputBytes(*outPath, plain); err != nil {
-		return failDecProcess("write output failed")
+	password, msg := resolveDecPassword(*passwordEnv, getenv)
+	if msg != "" {
+		return r.Usage(msg)
 	}
-	return 0
+	plain, meta, err := lockcore.OpenHybridV2Meta(sk, path, password, env.header, env.ct, opts)
+	if code := enforceSequence(r, env.header, err, *statePath, *allowRollback); code != 0 {
+		return code
+	}
+	return finishDecOpen(r, plain, meta, err, *inPath, *outPath, explicitOut, *extractDir, *listOnly, *ignoreMeta)
 }
 
-// Why(中文): 参数类失败打印明确 stderr 诊断，避免用户只看到退出码却误以为命令未报错。
-// Why(English): Print explicit stderr diagnostics for usage failures so users don't mistake silent exit codes for success.
-func failDecUsage(msg string) int {
-	_, _ = io.WriteString(os.Stderr, "txlock-dec: "+msg+"\n")
-	return 1
+// Why(中文): 解析在派生 SK 或口令密钥之前完成；非规范 base64 单独报告，提示文件被改写过而不是助记词或口令错误。
+// Why(English): Parsing happens before the SK or password key is derived; non-canonical base64 is reported on its own, pointing at a rewritten file rather than a wrong mnemonic or password.
+func failInvalidEnvelope(r *errcode.Reporter, raw string) int {
+	if lockcore.CheckCanonicalB64([]byte(raw)) == lockcore.ErrNonCanonical {
+		return r.Fail(errcode.NonCanonical, "invalid envelope: non-canonical base64 encoding (possible tampering)")
+	}
+	return r.Fail(errcode.EnvelopeBoundary, "invalid envelope")
 }
 
-// Why(中文): 处理层失败也输出明确 stderr，避免解密失败只剩退出码导致排障成本上升。
-// Why(English): Emit explicit stderr on processing failures so decrypt errors are diagnosable without relying on exit code alone.
-func failDecProcess(msg string) int {
-	_, _ = io.WriteString(os.Stderr, "txlock-dec: "+msg+"\n")
-	return 2
+// Why(中文): 与加密侧相同，SK 来自 -mnemonic-env 或 TXLOCK_AGENT_SOCK 指向的 agent；给了口令参数时已先分流到口令模式。
+// Why(English): As on the encrypt side, the SK comes from -mnemonic-env or the agent at TXLOCK_AGENT_SOCK; password flags were routed to password mode first.
+func resolveKeySource(mnemonicEnv string, getenv func(string) string) (func(string) ([]byte, error), string) {
+	if mnemonicEnv == "" {
+		sock := getenv(agent.SockEnv)
+		if sock == "" {
+			return nil, "-mnemonic-env is required"
+		}
+		return func(index string) ([]byte, error) { return agent.Client{Path: sock}.Key("", index) }, ""
+	}
+	rawMnemonic := getenv(mnemonicEnv)
+	if rawMnemonic == "" {
+		return nil, "mnemonic env is empty: " + mnemonicEnv
+	}
+	mnemonicCanonical, ok := canonicalizeMnemonic(rawMnemonic)
+	if !ok {
+		return nil, ""
+	}
+	return func(index string) ([]byte, error) { return derive.DeriveSK(mnemonicCanonical, index) }, ""
+}
+
+// Why(中文): 钱包与口令两种密钥来源解密后的落盘、解包与元数据还原完全一致，集中处理避免两条路径行为漂移。
+// Why(English): Output, extraction and metadata restore are identical for wallet and password sources, so one tail keeps both paths from drifting.
+func finishDecOpen(r *errcode.Reporter, plain []byte, meta *lockcore.FileMetaV2, err error, inPath string, outPath string, explicitOut bool, extractDir string, listOnly bool, ignoreMeta bool) int {
+	if err == lockcore.ErrTooLarge {
+		return r.FailErr(err, "decompressed size exceeds -max-size")
+	}
+	if err == lockcore.ErrWeakKDF {
+		return r.FailErr(err, "kdf parameters below floor (possible downgrade)")
+	}
+	if err == lockcore.ErrKDFLimit {
+		return r.FailErr(err, "kdf parameters exceed the decrypt ceiling (raise -argon2-max-memory/-argon2-max-time only for trusted files)")
+	}
+	if err == lockcore.ErrNonCanonical {
+		return r.FailErr(err, "decrypt failed: non-canonical base64 encoding (possible tampering)")
+	}
+	if err == lockcore.ErrLabelMismatch {
+		return r.FailErr(err, "decrypt failed: -label does not match the envelope's label")
+	}
+	if err != nil {
+		return r.FailErr(err, "decrypt failed (index/mnemonic mismatch or tampered data)")
+	}
+	if listOnly {
+		return listArchive(r, plain)
+	}
+	if extractDir != "" {
+		r.Output = extractDir
+		if err := archive.Extract(bytes.NewReader(plain), extractDir); err != nil {
+			return r.FailErr(err, "extract failed: "+err.Error())
+		}
+		return r.Done()
+	}
+	if ignoreMeta {
+		meta = nil
+	}
+	if !explicitOut {
+		name := ""
+		if meta != nil {
+			name = meta.Name
+		}
+		if outPath, err = defaultDecOutPath(inPath, name); err != nil {
+			return r.FailErr(err, "cannot create the default output directory")
+		}
+	}
+	r.Output = outPath
+	if err := writeOutputBytes(outPath, plain); err != nil {
+		return r.FailErr(err, "write output failed")
+	}
+	if err := restoreFileMeta(outPath, meta); err != nil {
+		return r.FailErr(err, "restore metadata failed")
+	}
+	return r.Done()
+}
+
+// Why(中文): 只在认证成功后检查 id/seq，未认证的头字段不能推进状态；比已见最大值更旧的版本在写出明文前拒绝，除非显式 -allow-rollback，且放行旧版本也不会降低记录。
+// Why(English): Check id/seq only after authentication so unauthenticated headers never advance the state; a version older than the highest seen is refused before any plaintext is written unless -allow-rollback is given, and letting it through never lowers the record.
+func enforceSequence(r *errcode.Reporter, h []lockcore.HeaderField, openErr error, statePath string, allowRollback bool) int {
+	if openErr != nil {
+		return 0
+	}
+	id, seq, ok := lockcore.SequenceOf(h)
+	if !ok {
+		return 0
+	}
+	path, err := seqstate.Resolve(statePath)
+	if err != nil {
+		return r.Usage("cannot locate the sequence state file; pass -state")
+	}
+	state, err := seqstate.Load(path)
+	if err != nil {
+		return r.FailErr(err, "load sequence state failed: "+err.Error())
+	}
+	if state.Check(id, seq) != nil && !allowRollback {
+		return r.Fail(errcode.Rollback, "rollback refused: "+seqstate.Describe(id, seq, state.Highest(id))+" (pass -allow-rollback to open it anyway)")
+	}
+	if state.Record(id, seq) {
+		if err := state.Save(); err != nil {
+			return r.FailErr(err, "save sequence state failed: "+err.Error())
+		}
+	}
+	return 0
 }
 
 // Why(中文): dec 与 enc 保持一致的帮助输出策略，避免用户在禁用默认 flag 输出时无法发现参数约定。
 // Why(English): Keep dec help behavior aligned with enc so users can discover flags even when default flag output is suppressed.
 func printDecUsage() {
-	fmt.Fprintln(os.Stdout, "Usage: txlock-dec -mnemonic-env ENV -index N [-in PATH|-] [-out PATH|-]")
+	fmt.Fprintln(os.Stdout, "Usage: txlock-dec [-mnemonic-env ENV -index N] [-password-env ENV|-password-prompt [-argon2-max-memory KiB] [-argon2-max-time N]] [-in PATH|-] [-out PATH|-|-extract DIR|-list] [-fields FORMAT] [-max-size N] [-ignore-meta] [-label NAME] [-allow-rollback] [-state PATH] [-json]")
 	fmt.Fprintln(os.Stdout, "Flags:")
-	fmt.Fprintln(os.Stdout, "  -mnemonic-env string   环境变量名，变量值为助记词 (required)")
-	fmt.Fprintln(os.Stdout, "  -index string          派生索引 (required)")
-	fmt.Fprintln(os.Stdout, "  -in string             输入文件路径，默认 - (stdin)")
+	fmt.Fprintln(os.Stdout, "  -mnemonic-env string   环境变量名，变量值为助记词（钱包/双因子模式必填；未给时可由 TXLOCK_AGENT_SOCK 指向的 agent 代替）")
+	fmt.Fprintln(os.Stdout, "  -index string          派生索引（钱包/双因子模式必填）")
+	fmt.Fprintln(os.Stdout, "  -in string             输入文件路径，默认 - (stdin)；Markdown 信封与二进制紧凑容器自动识别")
 	fmt.Fprintln(os.Stdout, "  -out string            输出文件路径，默认 ./lockfile/unlock/<name-without-.lock>")
+	fmt.Fprintln(os.Stdout, "  -fields string         字段级解密：json|yaml|dotenv|auto")
+	fmt.Fprintln(os.Stdout, "  -max-size int          压缩 envelope 解压后的最大字节数，默认 1GiB")
+	fmt.Fprintln(os.Stdout, "  -password-env string   环境变量名，变量值为口令；单独使用为口令模式，与 -mnemonic-env 同用为双因子")
+	fmt.Fprintln(os.Stdout, "  -password-prompt        从终端无回显读取口令；双因子 envelope 未给 -password-env 时自动提示")
+	fmt.Fprintln(os.Stdout, "  -ignore-meta           忽略加密元数据（文件名/权限/mtime），按旧规则命名输出")
+	fmt.Fprintln(os.Stdout, "  -extract string        将 -archive 产生的归档解包到该目录（拒绝路径穿越与覆盖）")
+	fmt.Fprintln(os.Stdout, "  -list                  仅列出归档条目，不落盘")
+	fmt.Fprintln(os.Stdout, "  -label string          文件子密钥标签；头字段已带标签时可省略，加密时用了 -label-hidden 则必须提供")
+	fmt.Fprintln(os.Stdout, "  -allow-rollback        允许打开 seq 低于状态文件记录的旧版本（记录不会因此降低）")
+	fmt.Fprintln(os.Stdout, "  -state string          序号状态文件，默认 <用户配置目录>/txlock/seq-state.json")
+	fmt.Fprintln(os.Stdout, "  -json                  在 stderr 输出一行 JSON 结果（status/code/exit/file/index/version），并启用细分退出码 3-6")
+}
+
+type parsedEnvelope struct {
+	version  string
+	saltB64  string
+	nonceB64 string
+	header   []lockcore.HeaderField
+	ct       []byte
+}
+
+// Why(中文): 内嵌签名块只用于来源证明，解密前剥离；签名校验由 txlock verify 负责，解密仍以 AEAD 认证为准。
+// Why(English): An embedded signature block proves provenance only and is stripped before parsing; txlock verify checks it, while decryption still relies on AEAD auth.
+func stripSignature(raw []byte) string {
+	if lockcore.IsBinaryEnvelope(raw) {
+		return string(raw)
+	}
+	envelope, _, _ := ethsig.SplitEmbedded(string(raw))
+	return envelope
+}
+
+// Why(中文): 按 magic 行选择 v1 或 v2 严格解析器（v3 复用 v2 语法），旧文件保持原有解析路径不变；二进制容器解析出的头字段与文本形态相同，后续解密路径完全共用。
+// Why(English): Pick the strict v1 or v2 parser by magic line (v3 reuses the v2 grammar) so existing files keep their original parse path; a binary container yields the same header as its text form and shares the rest of the decrypt path.
+func parseEnvelope(raw string) (*parsedEnvelope, bool) {
+	if lockcore.IsBinaryEnvelope([]byte(raw)) {
+		version, h, ct, ok := lockcore.ParseBinaryEnvelope([]byte(raw))
+		if !ok {
+			return nil, false
+		}
+		if version == "v1" {
+			saltB64, _ := lockcore.HeaderValue(h, "salt_b64")
+			nonceB64, _ := lockcore.HeaderValue(h, "nonce_b64")
+			return &parsedEnvelope{version: "v1", saltB64: saltB64, nonceB64: nonceB64, ct: ct}, true
+		}
+		return &parsedEnvelope{version: "v2", header: h, ct: ct}, true
+	}
+	switch lockcore.DetectEnvelopeVersion(raw) {
+	case "v1":
+		_, saltB64, nonceB64, ct, ok := lockcore.ParseEnvelopeV1(raw)
+		return &parsedEnvelope{version: "v1", saltB64: saltB64, nonceB64: nonceB64, ct: ct}, ok
+	case "v2", "v3":
+		h, ct, ok := lockcore.ParseEnvelopeV2(raw)
+		return &parsedEnvelope{version: "v2", header: h, ct: ct}, ok
+	}
+	return nil, false
+}
+
+// Why(中文): 解密入口按版本分派，调用方只关心明文与错误，不需要了解各版本的字段差异；v1 不可能用标签子密钥，显式给出标签即视为不匹配。
+// Why(English): Dispatch decryption by version so callers see only plaintext and errors, not per-version field differences; v1 can never use a label subkey, so an explicit label is a mismatch.
+func (e *parsedEnvelope) open(sk []byte, path string, opts lockcore.OpenOptionsV2) ([]byte, *lockcore.FileMetaV2, error) {
+	if e.version == "v1" {
+		if opts.Label != "" {
+			return nil, nil, lockcore.ErrLabelMismatch
+		}
+		pt, err := lockcore.OpenV1(sk, path, e.saltB64, e.nonceB64, e.ct)
+		return pt, nil, err
+	}
+	return lockcore.OpenV2Meta(sk, path, e.header, e.ct, opts)
+}
+
+// Why(中文): 口令入口只接受 kdf 为 argon2id 的 v2/v3 envelope；参数低于下限时给出明确诊断而不是笼统的解密失败。
+// Why(English): The password entry accepts only argon2id v2/v3 envelopes, and parameters below the floors get a specific diagnostic instead of a generic decrypt failure.
+func (e *parsedEnvelope) openPassword(password []byte, opts lockcore.OpenOptionsV2) ([]byte, *lockcore.FileMetaV2, error) {
+	if e.version == "v1" {
+		return nil, nil, lockcore.ErrDecrypt
+	}
+	return lockcore.OpenPasswordV2Meta(password, e.header, e.ct, opts)
+}
+
+// Why(中文): 对外报告的版本区分 v3：内部按 v2 语法解析，带 commit_b64 的即为 v3。
+// Why(English): The reported version tells v3 apart: it is parsed with the v2 grammar internally, and one carrying commit_b64 is v3.
+func (e *parsedEnvelope) reportedVersion() string {
+	if _, ok := lockcore.HeaderValue(e.header, "commit_b64"); ok && e.version == "v2" {
+		return "v3"
+	}
+	return e.version
+}
+
+// Why(中文): v1 没有头字段列表，其 kdf 固定为 hkdf-sha256；统一取值让调用方按因子需求分流。
+// Why(English): v1 has no header list and its kdf is always hkdf-sha256; one accessor lets callers branch on the required factors.
+func (e *parsedEnvelope) kdf() string {
+	if e.version == "v1" {
+		return "hkdf-sha256"
+	}
+	kdf, _ := lockcore.HeaderValue(e.header, "kdf")
+	return kdf
+}
+
+// Why(中文): 测试替换该变量以模拟终端输入；正式运行时始终走无回显的终端读取。
+// Why(English): Tests swap this variable to simulate terminal input; real runs always use the no-echo terminal reader.
+var promptPassword = secret.Prompt
+
+// Why(中文): 未给 -password-env 时从终端无回显读取口令（解密只需输入一次），口令不进入命令行与环境。
+// Why(English): Without -password-env, read the password from the terminal with echo off (once for decryption) so it never enters argv or the environment.
+func resolveDecPassword(passwordEnv string, getenv func(string) string) ([]byte, string) {
+	if passwordEnv == "" {
+		password, err := promptPassword("Password", false)
+		if err != nil {
+			return nil, "password prompt failed: " + err.Error()
+		}
+		return password, ""
+	}
+	password := getenv(passwordEnv)
+	if password == "" {
+		return nil, "password env is empty: " + passwordEnv
+	}
+	return []byte(password), ""
+}
+
+// Why(中文): 列表输出固定为“权限 大小 mtime 名称”四列，便于人工审阅与脚本解析。
+// Why(English): Listing prints fixed "mode size mtime name" columns for both human review and script parsing.
+func listArchive(r *errcode.Reporter, plain []byte) int {
+	entries, err := archive.List(bytes.NewReader(plain))
+	if err != nil {
+		return r.FailErr(err, "list archive failed: "+err.Error())
+	}
+	for _, e := range entries {
+		name := e.Name
+		if e.IsDir {
+			name += "/"
+		}
+		fmt.Fprintf(os.Stdout, "%s %10d %s %s\n", e.Mode, e.Size, e.ModTime.UTC().Format("2006-01-02T15:04:05Z"), name)
+	}
+	return r.Done()
 }
 
 // Why(中文): 解密侧必须复用同一助记词归一化语义，保证 enc/dec 对同义输入派生结果一致。
@@ -170,9 +505,11 @@ func writeOutputBytes(path string, data []byte) error {
 
 // Why(中文): 默认把解密产物落到 unlock 子目录，与密文产物隔离，便于人工与脚本按目录分流。
 // Why(English): Put decrypted artifacts under unlock subdir so plaintext/ciphertext are separated for both humans and automation.
-func defaultDecOutPath(inPath string) (string, error) {
+func defaultDecOutPath(inPath string, metaName string) (string, error) {
 	name := "stdin"
-	if inPath != "-" {
+	if metaName != "" {
+		name = metaName
+	} else if inPath != "-" {
 		base := filepath.Base(inPath)
 		name = strings.TrimSuffix(base, ".lock")
 		if name == base {
@@ -188,3 +525,36 @@ func defaultDecOutPath(inPath string) (string, error) {
 	}
 	return filepath.Join(dir, name), nil
 }
+
+// Why(中文): 权限与 mtime 只在写入真实文件时还原，stdout 或无元数据时保持原有行为。
+// Why(English): Restore mode and mtime only when writing a real file; stdout or missing metadata keep the previous behavior.
+func restoreFileMeta(path string, meta *lockcore.FileMetaV2) error {
+	if path == "-" || meta == nil {
+		return nil
+	}
+	if mode, ok := meta.FileMode(); ok {
+		if err := os.Chmod(path, mode); err != nil {
+			return err
+		}
+	}
+	if mtime, ok := meta.ModTime(); ok {
+		return os.Chtimes(path, mtime, mtime)
+	}
+	return nil
+}
+
+// Why(中文): 解密侧格式识别与加密侧保持同一规则；auto 依据去掉 .lock 后的原始文件名推断。
+// Why(English): Decrypt-side format detection mirrors encrypt; auto inspects the original name with any .lock suffix removed.
+func resolveFieldsFormat(format string, inPath string) (string, string) {
+	if format == "auto" {
+		format = fieldlock.DetectFormat(strings.TrimSuffix(filepath.Base(inPath), ".lock"))
+		if format == "" {
+			return "", "cannot detect -fields format from input: " + inPath
+		}
+	}
+	switch format {
+	case "json", "yaml", "dotenv":
+		return format, ""
+	}
+	return "", "invalid -fields: " + format
+}
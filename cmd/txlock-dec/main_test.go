@@ -3,12 +3,19 @@ package main
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"TXLOCK/internal/agent"
+	"TXLOCK/internal/archive"
 	"TXLOCK/internal/derive"
+	"TXLOCK/internal/errcode"
+	"TXLOCK/internal/ethsig"
+	"TXLOCK/internal/fieldlock"
 	"TXLOCK/internal/lockcore"
 )
 
@@ -164,3 +171,711 @@ func TestRunDefaultOutToTmp(t *testing.T) {
 		t.Fatalf("expected default output in lockfile/unlock, err=%v", err)
 	}
 }
+
+// Why(中文): dec -fields 必须还原 enc 侧字段级加密的文档，且整文档 MAC 失败时归为处理错误 exit 2。
+// Why(English): dec -fields must restore field-encrypted documents and map whole-document MAC failures to exit 2.
+func TestRunFieldsDotenvRoundTripAndTamper(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "app.env")
+	outPath := filepath.Join(dir, "app.out.env")
+	sk, err := derive.DeriveSK(fixtureMnemonic(), "777")
+	if err != nil {
+		t.Fatalf("derive fixture sk: %v", err)
+	}
+	plain := "USER=admin\nPASSWORD=s3cret\n"
+	enc, err := fieldlock.Encrypt("dotenv", []byte(plain), sk, "m/44'/60'/0'/0/777", nil, bytes.NewReader(make([]byte, 128)))
+	if err != nil {
+		t.Fatalf("encrypt fixture: %v", err)
+	}
+	if err := os.WriteFile(inPath, enc, 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777", "-fields", "auto"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil || string(got) != plain {
+		t.Fatalf("unexpected plaintext: %q err=%v", string(got), err)
+	}
+	if err := os.WriteFile(inPath, append(enc, []byte("EXTRA=1\n")...), 0o644); err != nil {
+		t.Fatalf("write tampered input: %v", err)
+	}
+	code = run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777", "-fields", "dotenv"}, func(string) string { return fixtureMnemonic() })
+	if code != 2 {
+		t.Fatalf("expected 2, got %d", code)
+	}
+}
+
+// Why(中文): -extract 必须把归档 envelope 还原为目录树，-list 只读不写，两者共享同一解密路径。
+// Why(English): -extract must restore the archived tree and -list must only read; both share the same decrypt path.
+func TestRunExtractAndListArchive(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "nested"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "nested", "k.txt"), []byte("key material"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var tarBuf bytes.Buffer
+	if err := archive.Pack(src, &tarBuf); err != nil {
+		t.Fatalf("pack: %v", err)
+	}
+	inPath := filepath.Join(dir, "src.lock")
+	if err := os.WriteFile(inPath, []byte(buildFixtureEnvelope(t, tarBuf.Bytes())), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	code := run([]string{"-in", inPath, "-list", "-mnemonic-env", "MNEM", "-index", "777"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0 for -list, got %d", code)
+	}
+	dest := filepath.Join(dir, "restored")
+	code = run([]string{"-in", inPath, "-extract", dest, "-mnemonic-env", "MNEM", "-index", "777"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0 for -extract, got %d", code)
+	}
+	got, err := os.ReadFile(filepath.Join(dest, "nested", "k.txt"))
+	if err != nil || string(got) != "key material" {
+		t.Fatalf("unexpected extracted content: %q err=%v", got, err)
+	}
+	code = run([]string{"-in", inPath, "-extract", dest, "-mnemonic-env", "MNEM", "-index", "777"}, func(string) string { return fixtureMnemonic() })
+	if code != 2 {
+		t.Fatalf("expected 2 when extraction would overwrite, got %d", code)
+	}
+}
+
+// Why(中文): 压缩的 v2 envelope 必须透明解压还原，且 -max-size 生效时超限数据以 exit 2 拒绝。
+// Why(English): Compressed v2 envelopes must decompress transparently, and data over -max-size must be refused with exit 2.
+func TestRunCompressedV2RoundTripAndLimit(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.lock")
+	outPath := filepath.Join(dir, "out.md")
+	sk, err := derive.DeriveSK(fixtureMnemonic(), "777")
+	if err != nil {
+		t.Fatalf("derive fixture sk: %v", err)
+	}
+	plain := []byte(strings.Repeat("| col | col |\n", 500))
+	sealed, err := lockcore.SealV2(sk, "m/44'/60'/0'/0/777", plain, lockcore.SealOptionsV2{Compress: "gzip"}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal fixture: %v", err)
+	}
+	raw := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	if err := os.WriteFile(inPath, []byte(raw), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("unexpected plaintext, err=%v", err)
+	}
+	code = run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777", "-max-size", "100"}, func(string) string { return fixtureMnemonic() })
+	if code != 2 {
+		t.Fatalf("expected 2 for size limit, got %d", code)
+	}
+}
+
+// Why(中文): 解密侧按头字段 aead 选择套件，每个已登记套件的 v2 envelope 都必须原样还原。
+// Why(English): Decryption picks the suite from the aead header, so every registered suite's v2 envelope must open byte-exact.
+func TestRunOpensEveryAEADSuite(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.lock")
+	outPath := filepath.Join(dir, "out.md")
+	sk, err := derive.DeriveSK(fixtureMnemonic(), "777")
+	if err != nil {
+		t.Fatalf("derive fixture sk: %v", err)
+	}
+	plain := []byte("hello txlock suites\n")
+	for _, suite := range lockcore.AEADNamesV2() {
+		sealed, err := lockcore.SealV2(sk, "m/44'/60'/0'/0/777", plain, lockcore.SealOptionsV2{AEAD: suite}, bytes.NewReader(make([]byte, 64)))
+		if err != nil {
+			t.Fatalf("%s: seal fixture: %v", suite, err)
+		}
+		raw := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+		if err := os.WriteFile(inPath, []byte(raw), 0o644); err != nil {
+			t.Fatalf("write fixture input: %v", err)
+		}
+		code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777"}, func(string) string { return fixtureMnemonic() })
+		if code != 0 {
+			t.Fatalf("%s: expected 0, got %d", suite, code)
+		}
+		got, err := os.ReadFile(outPath)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Fatalf("%s: unexpected plaintext, err=%v", suite, err)
+		}
+	}
+}
+
+// Why(中文): v3 envelope 正确索引可还原；错误索引在承诺校验处失败并返回 exit 2。
+// Why(English): A v3 envelope opens with the right index; a wrong index fails at the commitment check with exit 2.
+func TestRunCommittedV3RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.lock")
+	outPath := filepath.Join(dir, "out.md")
+	sk, err := derive.DeriveSK(fixtureMnemonic(), "777")
+	if err != nil {
+		t.Fatalf("derive fixture sk: %v", err)
+	}
+	plain := []byte("hello committed txlock\n")
+	sealed, err := lockcore.SealV2(sk, "m/44'/60'/0'/0/777", plain, lockcore.SealOptionsV2{Commit: true}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal fixture: %v", err)
+	}
+	raw := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	if err := os.WriteFile(inPath, []byte(raw), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("unexpected plaintext, err=%v", err)
+	}
+	code = run([]string{"-in", inPath, "-out", filepath.Join(dir, "other.md"), "-mnemonic-env", "MNEM", "-index", "778"}, func(string) string { return fixtureMnemonic() })
+	if code != 2 {
+		t.Fatalf("expected 2 for wrong index, got %d", code)
+	}
+}
+
+// Why(中文): 口令模式无需助记词与索引即可还原；错误口令与降级参数都以 exit 2 拒绝，混用钱包参数属于用法错误。
+// Why(English): Password mode opens without mnemonic or index; a wrong password or downgraded parameters exit 2, and mixing wallet flags is a usage error.
+func TestRunPasswordModeRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "audit.lock")
+	outPath := filepath.Join(dir, "audit.md")
+	plain := []byte("for the auditor\n")
+	sealed, err := lockcore.SealPasswordV2([]byte("correct horse battery staple"), lockcore.MinArgon2ParamsV2, plain, lockcore.SealOptionsV2{}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal fixture: %v", err)
+	}
+	raw := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	if err := os.WriteFile(inPath, []byte(raw), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	getenv := func(k string) string {
+		switch k {
+		case "PASS":
+			return "correct horse battery staple"
+		case "BAD":
+			return "wrong horse"
+		}
+		return fixtureMnemonic()
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-password-env", "PASS"}, getenv)
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("unexpected plaintext, err=%v", err)
+	}
+	if code := run([]string{"-in", inPath, "-out", filepath.Join(dir, "bad.md"), "-password-env", "BAD"}, getenv); code != 2 {
+		t.Fatalf("expected 2 for wrong password, got %d", code)
+	}
+	weakPath := filepath.Join(dir, "weak.lock")
+	if err := os.WriteFile(weakPath, []byte(strings.Replace(raw, "kdf_params:m=19456,t=2,p=1", "kdf_params:m=8,t=1,p=1", 1)), 0o644); err != nil {
+		t.Fatalf("write weak input: %v", err)
+	}
+	if code := run([]string{"-in", weakPath, "-out", filepath.Join(dir, "weak.md"), "-password-env", "PASS"}, getenv); code != 2 {
+		t.Fatalf("expected 2 for downgraded params, got %d", code)
+	}
+	if code := run([]string{"-in", inPath, "-out", outPath, "-password-env", "PASS", "-index", "777"}, getenv); code != 1 {
+		t.Fatalf("expected 1 for -password-env with -index, got %d", code)
+	}
+}
+
+// Why(中文): 头字段超过默认解密上限时在运行 Argon2 之前报 KDF_LIMIT（exit 6）；-argon2-max-time 显式放开后才进入计算（此处改写头字段导致 AUTH_FAILED）；上限为 0 属于用法错误。
+// Why(English): A header above the default decrypt ceiling is KDF_LIMIT (exit 6) before Argon2 runs; only an explicit -argon2-max-time lets it compute (AUTH_FAILED here, as the header was rewritten); a zero ceiling is a usage error.
+func TestRunPasswordModeDecryptCeiling(t *testing.T) {
+	dir := t.TempDir()
+	sealed, err := lockcore.SealPasswordV2([]byte("correct horse battery staple"), lockcore.MinArgon2ParamsV2, []byte("x"), lockcore.SealOptionsV2{}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal fixture: %v", err)
+	}
+	raw := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	inPath := filepath.Join(dir, "costly.lock")
+	if err := os.WriteFile(inPath, []byte(strings.Replace(raw, "kdf_params:m=19456,t=2,p=1", "kdf_params:m=19456,t=17,p=1", 1)), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	getenv := func(string) string { return "correct horse battery staple" }
+	for _, tc := range []struct {
+		extra []string
+		code  errcode.Code
+		exit  int
+	}{
+		{nil, errcode.KDFLimit, errcode.ExitPolicy},
+		{[]string{"-argon2-max-time", "32"}, errcode.AuthFailed, errcode.ExitAuth},
+		{[]string{"-argon2-max-memory", "0"}, errcode.Usage, errcode.ExitUsage},
+	} {
+		args := append([]string{"-in", inPath, "-out", filepath.Join(dir, "out.md"), "-password-env", "PASS", "-json"}, tc.extra...)
+		var code int
+		stderr := captureStderr(t, func() { code = run(args, getenv) })
+		var got errcode.Result
+		if err := json.Unmarshal([]byte(stderr), &got); err != nil || code != tc.exit || got.Code != tc.code {
+			t.Fatalf("%v: expected %s exit %d, got %d %q (%v)", tc.extra, tc.code, tc.exit, code, stderr, err)
+		}
+	}
+}
+
+// Why(中文): 双因子 envelope 需要助记词与口令同时正确；缺口令时自动走终端提示，只给口令则提示补齐助记词。
+// Why(English): A two-factor envelope needs both mnemonic and password; a missing password triggers the terminal prompt, and a password alone asks for the mnemonic too.
+func TestRunHybridRequiresBothFactors(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "vault.lock")
+	outPath := filepath.Join(dir, "vault.md")
+	sk, err := derive.DeriveSK(fixtureMnemonic(), "777")
+	if err != nil {
+		t.Fatalf("derive fixture sk: %v", err)
+	}
+	plain := []byte("high value\n")
+	sealed, err := lockcore.SealHybridV2(sk, "m/44'/60'/0'/0/777", []byte("correct horse battery staple"), lockcore.MinArgon2ParamsV2, plain, lockcore.SealOptionsV2{}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal fixture: %v", err)
+	}
+	raw := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	if err := os.WriteFile(inPath, []byte(raw), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	getenv := func(k string) string {
+		switch k {
+		case "PASS":
+			return "correct horse battery staple"
+		case "BAD":
+			return "wrong horse"
+		}
+		return fixtureMnemonic()
+	}
+	orig := promptPassword
+	defer func() { promptPassword = orig }()
+	prompted := false
+	promptPassword = func(string, bool) ([]byte, error) {
+		prompted = true
+		return []byte("correct horse battery staple"), nil
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777"}, getenv)
+	if code != 0 || !prompted {
+		t.Fatalf("expected prompted success, got code=%d prompted=%v", code, prompted)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("unexpected plaintext, err=%v", err)
+	}
+	if code := run([]string{"-in", inPath, "-out", filepath.Join(dir, "a.md"), "-mnemonic-env", "MNEM", "-index", "777", "-password-env", "BAD"}, getenv); code != 2 {
+		t.Fatalf("expected 2 for wrong password, got %d", code)
+	}
+	if code := run([]string{"-in", inPath, "-out", filepath.Join(dir, "b.md"), "-password-env", "PASS"}, getenv); code != 1 {
+		t.Fatalf("expected 1 for password alone, got %d", code)
+	}
+	if code := run([]string{"-in", inPath, "-out", filepath.Join(dir, "c.md"), "-mnemonic-env", "MNEM", "-index", "778", "-password-env", "PASS"}, getenv); code != 2 {
+		t.Fatalf("expected 2 for wrong index, got %d", code)
+	}
+}
+
+// Why(中文): 重命名后的 .lock 仍应按加密元数据还原原文件名、权限与 mtime；-ignore-meta 回退到旧命名规则。
+// Why(English): A renamed .lock must still restore the original name, mode and mtime from sealed metadata; -ignore-meta falls back to the old naming.
+func TestRunRestoresSealedMetadata(t *testing.T) {
+	dir := t.TempDir()
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir temp dir: %v", err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	sk, err := derive.DeriveSK(fixtureMnemonic(), "777")
+	if err != nil {
+		t.Fatalf("derive fixture sk: %v", err)
+	}
+	mtime := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	meta := lockcore.NewFileMetaV2("wallet-notes.md", 0o600, mtime, "text/markdown")
+	sealed, err := lockcore.SealV2(sk, "m/44'/60'/0'/0/777", []byte("hello txlock\n"), lockcore.SealOptionsV2{Meta: &meta}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal fixture: %v", err)
+	}
+	inPath := filepath.Join(dir, "renamed.lock")
+	raw := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	if err := os.WriteFile(inPath, []byte(raw), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	code := run([]string{"-in", inPath, "-mnemonic-env", "MNEM", "-index", "777"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	fi, err := os.Stat(filepath.Join(dir, "lockfile", "unlock", "wallet-notes.md"))
+	if err != nil || fi.Mode().Perm() != 0o600 || !fi.ModTime().Equal(mtime) {
+		t.Fatalf("expected restored name/mode/mtime, fi=%v err=%v", fi, err)
+	}
+	code = run([]string{"-in", inPath, "-mnemonic-env", "MNEM", "-index", "777", "-ignore-meta"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0 with -ignore-meta, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "lockfile", "unlock", "renamed")); err != nil {
+		t.Fatalf("expected legacy output name with -ignore-meta, err=%v", err)
+	}
+}
+
+// Why(中文): 在短路径临时目录启动进程内 agent 并载入夹具账户，CLI 测试无需真实后台进程。
+// Why(English): Start an in-process agent under a short temp dir with the fixture account loaded so CLI tests need no background process.
+func startFixtureAgent(t *testing.T, load bool) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "txa")
+	if err != nil {
+		t.Fatalf("mkdir temp: %v", err)
+	}
+	sock := filepath.Join(dir, "agent.sock")
+	ln, err := agent.Listen(sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	a := agent.New(0, 0)
+	if load {
+		account, err := derive.DeriveAccountKey(fixtureMnemonic())
+		if err != nil {
+			t.Fatalf("derive account: %v", err)
+		}
+		if _, err := a.Add(account, 0); err != nil {
+			t.Fatalf("agent add: %v", err)
+		}
+	}
+	served := make(chan error, 1)
+	go func() { served <- a.Serve(ln) }()
+	t.Cleanup(func() {
+		a.Kill()
+		<-served
+		os.RemoveAll(dir)
+	})
+	return sock
+}
+
+// Why(中文): 未给 -mnemonic-env 时经 agent 取 SK 解密；仍要求 -index，口令参数不会被 agent 改成双因子。
+// Why(English): Without -mnemonic-env decryption fetches the SK from the agent; -index is still required and password flags are never turned into two-factor by it.
+func TestRunUsesAgentWithoutMnemonic(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.lock")
+	outPath := filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(inPath, []byte(buildFixtureEnvelope(t, []byte("hello agent\n"))), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	sock := startFixtureAgent(t, true)
+	getenv := func(k string) string {
+		switch k {
+		case agent.SockEnv:
+			return sock
+		case "PASS":
+			return "correct horse battery staple"
+		}
+		return ""
+	}
+	if code := run([]string{"-in", inPath, "-out", outPath, "-index", "777"}, getenv); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil || string(got) != "hello agent\n" {
+		t.Fatalf("unexpected plaintext %q err=%v", got, err)
+	}
+	if code := run([]string{"-in", inPath, "-out", outPath}, getenv); code != 1 {
+		t.Fatalf("expected 1 without -index, got %d", code)
+	}
+	if code := run([]string{"-in", inPath, "-out", outPath, "-index", "778"}, getenv); code != 2 {
+		t.Fatalf("expected 2 for wrong index, got %d", code)
+	}
+	if code := run([]string{"-in", inPath, "-out", outPath, "-password-env", "PASS"}, getenv); code != 2 {
+		t.Fatalf("expected 2 for password mode on a wallet envelope, got %d", code)
+	}
+}
+
+// Why(中文): 内嵌签名块在解密前被剥离，签名文件与未签名文件的解密结果一致。
+// Why(English): An embedded signature block is stripped before decryption, so signed and unsigned files decrypt identically.
+func TestRunDecryptsSignedEnvelope(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.lock")
+	outPath := filepath.Join(dir, "out.txt")
+	envelope := buildFixtureEnvelope(t, []byte("signed hello\n"))
+	sk, _ := derive.DeriveSK(fixtureMnemonic(), "777")
+	signer, _ := ethsig.AddressOf(sk)
+	sig, err := ethsig.Sign(sk, []byte(envelope))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := os.WriteFile(inPath, []byte(envelope+ethsig.BuildBlock(signer, sig)), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	if code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777"}, func(string) string { return fixtureMnemonic() }); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil || string(got) != "signed hello\n" {
+		t.Fatalf("unexpected plaintext %q err=%v", got, err)
+	}
+}
+
+// Why(中文): 解密侧按 magic 自动识别二进制容器，无需额外参数；损坏的容器与 Markdown 一样按处理错误拒绝。
+// Why(English): The decrypt side detects a binary container by its magic with no extra flag; a corrupted container fails as a processing error just like Markdown.
+func TestRunDecryptsBinaryEnvelope(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.lock")
+	outPath := filepath.Join(dir, "out.txt")
+	bin, ok := lockcore.MarkdownToBinary(buildFixtureEnvelope(t, []byte("binary hello\n")))
+	if !ok {
+		t.Fatalf("convert fixture failed")
+	}
+	getenv := func(string) string { return fixtureMnemonic() }
+	args := []string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777"}
+	if err := os.WriteFile(inPath, bin, 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	if code := run(args, getenv); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil || string(got) != "binary hello\n" {
+		t.Fatalf("unexpected plaintext %q err=%v", got, err)
+	}
+	if err := os.WriteFile(inPath, bin[:len(bin)-1], 0o644); err != nil {
+		t.Fatalf("write truncated input: %v", err)
+	}
+	if code := run(args, getenv); code != 2 {
+		t.Fatalf("expected 2 for truncated container, got %d", code)
+	}
+}
+
+// Why(中文): 非规范 base64 必须在派生 SK 之前被拒绝并单独报告：用校验和错误的助记词运行，规范信封会报派生失败，可塑信封则先报非规范编码。
+// Why(English): Non-canonical base64 must be rejected and reported on its own before the SK is derived: with a bad-checksum mnemonic a canonical envelope reports a derivation failure, while a malleated one reports the encoding first.
+func TestRunNonCanonicalRejectedBeforeDerivation(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.md")
+	raw := buildFixtureEnvelope(t, []byte("hello txlock\n"))
+	lines := strings.Split(raw, "\n")
+	ct := lines[7]
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	malleated := strings.Replace(raw, ct, ct[:len(ct)-1]+string(alphabet[strings.IndexByte(alphabet, ct[len(ct)-1])^1]), 1)
+	badMnemonic := func(string) string {
+		return "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"
+	}
+	for _, tc := range []struct{ input, want string }{
+		{raw, "derive key failed"},
+		{malleated, "non-canonical base64 encoding"},
+	} {
+		if err := os.WriteFile(inPath, []byte(tc.input), 0o644); err != nil {
+			t.Fatalf("write input: %v", err)
+		}
+		stderr := captureStderr(t, func() {
+			if code := run([]string{"-in", inPath, "-out", filepath.Join(dir, "out.txt"), "-mnemonic-env", "MNEM", "-index", "777"}, badMnemonic); code != 2 {
+				t.Fatalf("expected 2, got %d", code)
+			}
+		})
+		if !strings.Contains(stderr, tc.want) {
+			t.Fatalf("expected %q in stderr, got %q", tc.want, stderr)
+		}
+	}
+}
+
+// Why(中文): 诊断文本是区分错误类的唯一 CLI 输出，测试需要读取 stderr 才能断言它。
+// Why(English): The diagnostic text is the only CLI output that distinguishes error classes, so tests read stderr to assert on it.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	saved := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = saved }()
+	fn()
+	_ = w.Close()
+	var b bytes.Buffer
+	_, _ = b.ReadFrom(r)
+	return b.String()
+}
+
+// Why(中文): 头字段带标签时无需 -label；隐藏标签必须给出同一 -label；冲突标签、v1 上的标签与错误标签都以 exit 2 拒绝，非法标签或与口令组合为用法错误。
+// Why(English): A header label needs no -label; a hidden one needs the same -label; a conflicting label, a label on v1 and a wrong label all exit 2, while an invalid label or one combined with a password is a usage error.
+func TestRunLabelSubkey(t *testing.T) {
+	dir := t.TempDir()
+	sk, err := derive.DeriveSK(fixtureMnemonic(), "777")
+	if err != nil {
+		t.Fatalf("derive fixture sk: %v", err)
+	}
+	getenv := func(string) string { return fixtureMnemonic() }
+	write := func(name string, opts lockcore.SealOptionsV2) string {
+		sealed, err := lockcore.SealV2(sk, "m/44'/60'/0'/0/777", []byte("labeled\n"), opts, bytes.NewReader(make([]byte, 64)))
+		if err != nil {
+			t.Fatalf("seal fixture: %v", err)
+		}
+		p := filepath.Join(dir, name)
+		raw := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+		if err := os.WriteFile(p, []byte(raw), 0o644); err != nil {
+			t.Fatalf("write fixture input: %v", err)
+		}
+		return p
+	}
+	shown := write("shown.lock", lockcore.SealOptionsV2{Label: "notes.md"})
+	hidden := write("hidden.lock", lockcore.SealOptionsV2{Label: "notes.md", HideLabel: true})
+	v1 := filepath.Join(dir, "v1.lock")
+	if err := os.WriteFile(v1, []byte(buildFixtureEnvelope(t, []byte("labeled\n"))), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	outPath := filepath.Join(dir, "out.md")
+	for _, tc := range []struct {
+		in   string
+		args []string
+		want int
+	}{
+		{shown, nil, 0},
+		{shown, []string{"-label", "notes.md"}, 0},
+		{hidden, []string{"-label", "notes.md"}, 0},
+		{shown, []string{"-label", "other.md"}, 2},
+		{hidden, nil, 2},
+		{hidden, []string{"-label", "other.md"}, 2},
+		{v1, []string{"-label", "notes.md"}, 2},
+		{shown, []string{"-label", "bad label"}, 1},
+		{shown, []string{"-label", "notes.md", "-password-env", "PASS"}, 1},
+	} {
+		_ = os.Remove(outPath)
+		args := append([]string{"-in", tc.in, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777"}, tc.args...)
+		if code := run(args, getenv); code != tc.want {
+			t.Fatalf("%v: expected %d, got %d", args, tc.want, code)
+		}
+		if tc.want != 0 {
+			continue
+		}
+		if got, err := os.ReadFile(outPath); err != nil || string(got) != "labeled\n" {
+			t.Fatalf("%v: unexpected plaintext %q err=%v", args, got, err)
+		}
+	}
+}
+
+// Why(中文): 打开过 seq 2 后再给 seq 1 必须退出 2 且不写明文；-allow-rollback 放行但不降低记录；相同版本可重复打开，损坏的状态文件同样退出 2。
+// Why(English): After seq 2 has been opened, seq 1 must exit 2 without writing plaintext; -allow-rollback lets it through without lowering the record; the same version reopens fine, and a corrupt state file also exits 2.
+func TestRunRefusesRollback(t *testing.T) {
+	dir := t.TempDir()
+	sk, err := derive.DeriveSK(fixtureMnemonic(), "777")
+	if err != nil {
+		t.Fatalf("derive fixture sk: %v", err)
+	}
+	getenv := func(string) string { return fixtureMnemonic() }
+	write := func(name string, seq uint64) string {
+		sealed, err := lockcore.SealV2(sk, "m/44'/60'/0'/0/777", []byte("versioned\n"), lockcore.SealOptionsV2{ID: "notes", Seq: seq}, bytes.NewReader(make([]byte, 64)))
+		if err != nil {
+			t.Fatalf("seal fixture: %v", err)
+		}
+		p := filepath.Join(dir, name)
+		raw := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+		if err := os.WriteFile(p, []byte(raw), 0o644); err != nil {
+			t.Fatalf("write fixture input: %v", err)
+		}
+		return p
+	}
+	old, current := write("v1.lock", 1), write("v2.lock", 2)
+	state := filepath.Join(dir, "state", "seq-state.json")
+	outPath := filepath.Join(dir, "out.md")
+	for _, tc := range []struct {
+		in   string
+		args []string
+		want int
+	}{
+		{current, nil, 0},
+		{current, nil, 0},
+		{old, nil, 2},
+		{old, []string{"-allow-rollback"}, 0},
+		{old, nil, 2},
+	} {
+		_ = os.Remove(outPath)
+		args := append([]string{"-in", tc.in, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777", "-state", state}, tc.args...)
+		if code := run(args, getenv); code != tc.want {
+			t.Fatalf("%v: expected %d, got %d", args, tc.want, code)
+		}
+		if _, err := os.Stat(outPath); (err == nil) != (tc.want == 0) {
+			t.Fatalf("%v: plaintext written=%v with exit %d", args, err == nil, tc.want)
+		}
+	}
+	if err := os.WriteFile(state, []byte("{"), 0o600); err != nil {
+		t.Fatalf("corrupt state: %v", err)
+	}
+	if code := run([]string{"-in", current, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "777", "-state", state}, getenv); code != 2 {
+		t.Fatalf("corrupt state: expected 2, got %d", code)
+	}
+}
+
+// Why(中文): -json 在 stderr 输出一行结果对象并使用细分退出码：成功带版本与索引，错误索引为 AUTH_FAILED，非信封为 ENVELOPE_BOUNDARY，校验和错误的助记词为 MNEMONIC_CHECKSUM，回滚为 ROLLBACK；不加 -json 时同一失败仍退出 2。
+// Why(English): -json writes one result object to stderr and uses detailed exit codes: success carries version and index, a wrong index is AUTH_FAILED, a non-envelope is ENVELOPE_BOUNDARY, a bad-checksum mnemonic is MNEMONIC_CHECKSUM and a rollback is ROLLBACK; without -json the same failure still exits 2.
+func TestRunJSONResult(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "note.lock")
+	if err := os.WriteFile(envPath, []byte(buildFixtureEnvelope(t, []byte("hello\n"))), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	junkPath := filepath.Join(dir, "junk.lock")
+	if err := os.WriteFile(junkPath, []byte("not an envelope\n"), 0o644); err != nil {
+		t.Fatalf("write junk input: %v", err)
+	}
+	sk, err := derive.DeriveSK(fixtureMnemonic(), "777")
+	if err != nil {
+		t.Fatalf("derive fixture sk: %v", err)
+	}
+	seqPath := filepath.Join(dir, "seq.lock")
+	state := filepath.Join(dir, "seq-state.json")
+	sealed, err := lockcore.SealV2(sk, "m/44'/60'/0'/0/777", []byte("v1\n"), lockcore.SealOptionsV2{ID: "notes", Seq: 1}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal fixture: %v", err)
+	}
+	if err := os.WriteFile(seqPath, []byte(lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))), 0o644); err != nil {
+		t.Fatalf("write fixture input: %v", err)
+	}
+	if err := os.WriteFile(state, []byte(`{"version":1,"highest":{"notes":4}}`), 0o600); err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+	badChecksum := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"
+	outPath := filepath.Join(dir, "out.md")
+	for _, tc := range []struct {
+		in       string
+		mnemonic string
+		args     []string
+		want     errcode.Result
+	}{
+		{envPath, fixtureMnemonic(), []string{"-index", "777"}, errcode.Result{Status: "ok", Code: errcode.OK, Exit: 0, Version: "v1", Index: "777", Output: outPath}},
+		{envPath, fixtureMnemonic(), []string{"-index", "778"}, errcode.Result{Status: "error", Code: errcode.AuthFailed, Exit: 4, Version: "v1", Index: "778"}},
+		{junkPath, fixtureMnemonic(), []string{"-index", "777"}, errcode.Result{Status: "error", Code: errcode.EnvelopeBoundary, Exit: 3, Index: "777"}},
+		{envPath, badChecksum, []string{"-index", "777"}, errcode.Result{Status: "error", Code: errcode.MnemonicChecksum, Exit: 5, Version: "v1", Index: "777"}},
+		{seqPath, fixtureMnemonic(), []string{"-index", "777", "-state", state}, errcode.Result{Status: "error", Code: errcode.Rollback, Exit: 6, Version: "v2", Index: "777"}},
+		{envPath, fixtureMnemonic(), nil, errcode.Result{Status: "error", Code: errcode.Usage, Exit: 1}},
+	} {
+		_ = os.Remove(outPath)
+		args := append([]string{"-in", tc.in, "-out", outPath, "-mnemonic-env", "MNEM", "-json"}, tc.args...)
+		getenv := func(string) string { return tc.mnemonic }
+		var code int
+		stderr := captureStderr(t, func() { code = run(args, getenv) })
+		var got errcode.Result
+		if err := json.Unmarshal([]byte(stderr), &got); err != nil || !strings.HasSuffix(stderr, "}\n") || strings.Count(stderr, "\n") != 1 {
+			t.Fatalf("%v: expected one JSON line, got %q (%v)", args, stderr, err)
+		}
+		tc.want.Tool, tc.want.File, got.Message = "txlock-dec", tc.in, ""
+		if code != tc.want.Exit || got != tc.want {
+			t.Fatalf("%v: exit %d, got %+v, want %+v", args, code, got, tc.want)
+		}
+	}
+	if code := run([]string{"-in", envPath, "-out", outPath, "-mnemonic-env", "MNEM", "-index", "778"}, func(string) string { return fixtureMnemonic() }); code != 2 {
+		t.Fatalf("text mode must keep exit 2, got %d", code)
+	}
+}
+
+// Why(中文): 未知 flag 在解析阶段就失败，-json 仍须输出一行 USAGE 结果（退出 1）；不加 -json 保持静默退出 1。
+// Why(English): An unknown flag fails during parsing, yet -json must still print one USAGE result line (exit 1); without -json it stays a silent exit 1.
+func TestRunJSONReportsFlagParseError(t *testing.T) {
+	var code int
+	stderr := captureStderr(t, func() { code = run([]string{"-json", "-bogus"}, func(string) string { return "" }) })
+	var got errcode.Result
+	if err := json.Unmarshal([]byte(stderr), &got); err != nil || strings.Count(stderr, "\n") != 1 {
+		t.Fatalf("expected one JSON line, got %q (%v)", stderr, err)
+	}
+	if code != 1 || got.Status != "error" || got.Code != errcode.Usage || got.Exit != 1 || got.Tool != "txlock-dec" || !strings.Contains(got.Message, "bogus") {
+		t.Fatalf("unexpected result: exit %d, %+v", code, got)
+	}
+	stderr = captureStderr(t, func() { code = run([]string{"-bogus"}, func(string) string { return "" }) })
+	if code != 1 || stderr != "" {
+		t.Fatalf("text mode: expected silent exit 1, got %d %q", code, stderr)
+	}
+}
@@ -1,16 +1,30 @@
 package main
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/base64"
 	"flag"
 	"fmt"
 	"io"
+	"math"
+	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
+	"TXLOCK/internal/agent"
+	"TXLOCK/internal/archive"
 	"TXLOCK/internal/derive"
+	"TXLOCK/internal/errcode"
+	"TXLOCK/internal/ethsig"
+	"TXLOCK/internal/fieldlock"
 	"TXLOCK/internal/lockcore"
+	"TXLOCK/internal/secret"
+	"TXLOCK/internal/seqstate"
 )
 
 func main() {
@@ -28,89 +42,429 @@ func run(args []string, getenv func(string) string) int {
 	outPath := fs.String("out", "", "")
 	mnemonicEnv := fs.String("mnemonic-env", "", "")
 	encIndex := fs.String("index", "777", "")
+	fieldsFormat := fs.String("fields", "", "")
+	fieldsRegex := fs.String("fields-regex", "", "")
+	archiveDir := fs.String("archive", "", "")
+	maxSize := fs.Int64("max-size", lockcore.DefaultMaxPlaintext, "")
+	compress := fs.String("compress", "", "")
+	pad := fs.String("pad", "", "")
+	withMeta := fs.Bool("meta", false, "")
+	aeadName := fs.String("aead", "", "")
+	commit := fs.Bool("commit", false, "")
+	passwordEnv := fs.String("password-env", "", "")
+	passwordPrompt := fs.Bool("password-prompt", false, "")
+	argonMemory := fs.Uint("argon2-memory", uint(lockcore.DefaultArgon2ParamsV2.Memory), "")
+	argonTime := fs.Uint("argon2-time", uint(lockcore.DefaultArgon2ParamsV2.Time), "")
+	argonThreads := fs.Uint("argon2-threads", uint(lockcore.DefaultArgon2ParamsV2.Threads), "")
+	signMode := fs.String("sign", "", "")
+	deterministic := fs.Bool("deterministic", false, "")
+	asBinary := fs.Bool("binary", false, "")
+	label := fs.String("label", "", "")
+	labelHidden := fs.Bool("label-hidden", false, "")
+	versionID := fs.String("id", "", "")
+	versionSeq := fs.Uint64("seq", 0, "")
+	statePath := fs.String("state", "", "")
+	asJSON := fs.Bool("json", false, "")
 
 	if err := fs.Parse(args); err != nil {
 		if err == flag.ErrHelp {
 			printEncUsage()
 			return 0
 		}
+		if errcode.WantsJSON(args) {
+			return (&errcode.Reporter{Tool: "txlock-enc", JSON: true, W: os.Stderr}).Usage(err.Error())
+		}
 		return 1
 	}
+	r := &errcode.Reporter{Tool: "txlock-enc", JSON: *asJSON, W: os.Stderr, File: *inPath}
 	if fs.NArg() != 0 {
-		return 1
+		return r.Usage("unexpected argument: " + fs.Arg(0))
+	}
+	if *archiveDir != "" && (*inPath != "-" || *fieldsFormat != "") {
+		return r.Usage("-archive cannot be combined with -in or -fields")
 	}
 	if *outPath == "" {
-		path, err := defaultEncOutPath(*inPath)
+		src := *inPath
+		if *archiveDir != "" {
+			src = *archiveDir
+		}
+		path, err := defaultEncOutPath(src)
 		if err != nil {
-			return 2
+			return r.FailErr(err, "cannot create the default output directory")
 		}
 		*outPath = path
 	}
-	if *mnemonicEnv == "" {
-		return failEncUsage("-mnemonic-env is required")
+	if *archiveDir != "" {
+		r.File = *archiveDir
 	}
-	rawMnemonic := getenv(*mnemonicEnv)
-	if rawMnemonic == "" {
-		return failEncUsage("mnemonic env is empty: " + *mnemonicEnv)
+	r.Output = *outPath
+	passwordMode := *passwordEnv != "" || *passwordPrompt
+	if *passwordEnv != "" && *passwordPrompt {
+		return r.Usage("-password-env and -password-prompt are mutually exclusive")
 	}
-
-	mnemonicCanonical, ok := canonicalizeMnemonic(rawMnemonic)
-	if !ok {
-		return 2
+	params, ok := argon2ParamsFromFlags(*argonMemory, *argonTime, *argonThreads)
+	if passwordMode && !ok {
+		return r.Usage("invalid -argon2-* parameters (floor " + lockcore.MinArgon2ParamsV2.String() + ")")
+	}
+	readPassword := func() ([]byte, string) { return resolveEncPassword(*passwordEnv, *passwordPrompt, getenv) }
+	if *signMode != "" && *signMode != "detached" && *signMode != "embedded" {
+		return r.Usage("invalid -sign: " + *signMode + " (detached|embedded)")
+	}
+	if *signMode == "detached" && *outPath == "-" {
+		return r.Usage("-sign detached needs a file -out to place <out>.sig next to")
+	}
+	if *asBinary && *signMode == "embedded" {
+		return r.Usage("-binary cannot be combined with -sign embedded (use -sign detached)")
+	}
+	if *labelHidden && *label == "" {
+		return r.Usage("-label-hidden needs -label")
+	}
+	if *label != "" && !lockcore.ValidLabel(*label) {
+		return r.Usage("invalid -label: " + *label + " (1-64 of A-Z a-z 0-9 . _ / -)")
+	}
+	if *label != "" && passwordMode {
+		return r.Usage("-label needs wallet mode (not with -password-env/-password-prompt)")
+	}
+	if *versionSeq != 0 && *versionID == "" {
+		return r.Usage("-seq needs -id")
+	}
+	if *versionID != "" && !lockcore.ValidLabel(*versionID) {
+		return r.Usage("invalid -id: " + *versionID + " (1-64 of A-Z a-z 0-9 . _ / -)")
+	}
+	if *versionID != "" && *fieldsFormat != "" {
+		return r.Usage("-id cannot be combined with -fields")
+	}
+	if passwordMode && *mnemonicEnv == "" {
+		if *signMode != "" {
+			return r.Usage("-sign needs a wallet key (-mnemonic-env or agent)")
+		}
+		if *deterministic {
+			return r.Usage("-deterministic needs a wallet key (password modes salt Argon2id randomly)")
+		}
+		return runPasswordEnc(r, readPassword, params, *inPath, *archiveDir, *maxSize, *outPath, *fieldsFormat, *withMeta, *asBinary, *statePath,
+			lockcore.SealOptionsV2{AEAD: *aeadName, Commit: *commit, Compress: *compress, Pad: *pad, ID: *versionID, Seq: *versionSeq})
+	}
+	deriveSK, msg := resolveKeySource(*mnemonicEnv, getenv)
+	if msg != "" {
+		return r.Usage(msg)
+	}
+	if deriveSK == nil {
+		return r.Fail(errcode.MnemonicInvalid, "invalid mnemonic")
 	}
-	_ = mnemonicCanonical
 
 	if *encIndex == "" {
 		*encIndex = "777"
 	}
 
 	if !validateIndex(*encIndex) {
-		return failEncUsage("invalid -index: " + *encIndex)
+		return r.Usage("invalid -index: " + *encIndex)
 	}
 
 	path, ok := buildPathFromIndex(*encIndex)
 	if ok == false {
-		return failEncUsage("failed to build path from -index: " + *encIndex)
+		return r.Usage("failed to build path from -index: " + *encIndex)
 	}
+	r.Index = *encIndex
 
 	_ = path
-	sk, err := derive.DeriveSK(mnemonicCanonical, *encIndex)
+	format, match, msg := resolveFieldsMode(*fieldsFormat, *fieldsRegex, *inPath)
+	if msg != "" {
+		return r.Usage(msg)
+	}
+	if *compress != "" && (*compress != "gzip" || format != "") {
+		return r.Usage("invalid -compress: " + *compress + " (only gzip, not with -fields)")
+	}
+	if *pad != "" && (!lockcore.IsPaddingV2(*pad) || format != "") {
+		return r.Usage("invalid -pad: " + *pad + " (padme or bucket-<power of two>, not with -fields)")
+	}
+	if *aeadName != "" && (!lockcore.IsAEADV2(*aeadName) || format != "") {
+		return r.Usage("invalid -aead: " + *aeadName + " (" + strings.Join(lockcore.AEADNamesV2(), "|") + ", not with -fields)")
+	}
+	if *aeadName == lockcore.DefaultAEADV2 {
+		*aeadName = ""
+	}
+	if *commit && format != "" {
+		return r.Usage("-commit cannot be combined with -fields")
+	}
+	if *withMeta && format != "" {
+		return r.Usage("-meta cannot be combined with -fields")
+	}
+	if passwordMode && format != "" {
+		return r.Usage("-password-env/-password-prompt cannot be combined with -fields")
+	}
+	if *deterministic && (format != "" || passwordMode) {
+		return r.Usage("-deterministic cannot be combined with -fields or a password")
+	}
+	if *asBinary && format != "" {
+		return r.Usage("-binary cannot be combined with -fields")
+	}
+	if *label != "" && format != "" {
+		return r.Usage("-label cannot be combined with -fields")
+	}
+	if *signMode == "embedded" && format != "" {
+		return r.Usage("-sign embedded cannot be combined with -fields (use -sign detached)")
+	}
+	if code := reserveSequence(r, *versionID, versionSeq, *statePath); code != 0 {
+		return code
+	}
+	var password []byte
+	if passwordMode {
+		if password, msg = readPassword(); msg != "" {
+			return r.Usage(msg)
+		}
+	}
+	sk, err := deriveSK(*encIndex)
 	if err != nil {
-		return 2
+		return r.FailErr(err, "derive key failed: "+err.Error())
 	}
-	plain, err := readInputBytes(*inPath)
+	plain, err := readEncSource(*inPath, *archiveDir, *maxSize)
 	if err != nil {
-		return 2
+		return r.FailErr(err, "read input failed")
+	}
+	if format != "" {
+		out, err := fieldlock.Encrypt(format, plain, sk, path, match, rand.Reader)
+		if err != nil {
+			return r.FailErr(err, "field encrypt failed: "+err.Error())
+		}
+		if out, err = signOutput(sk, *signMode, *outPath, out); err != nil {
+			return r.FailErr(err, "sign output failed")
+		}
+		if err := writeOutputBytes(*outPath, out); err != nil {
+			return r.FailErr(err, "write output failed")
+		}
+		return r.Done()
+	}
+	opts := lockcore.SealOptionsV2{AEAD: *aeadName, Commit: *commit, Compress: *compress, Pad: *pad, Deterministic: *deterministic, Label: *label, HideLabel: *labelHidden, ID: *versionID, Seq: *versionSeq}
+	if *withMeta {
+		meta, err := buildFileMeta(*inPath, *archiveDir, plain)
+		if err != nil {
+			return r.FailErr(err, "read file metadata failed")
+		}
+		opts.Meta = &meta
+	}
+	var envelope string
+	if password != nil {
+		envelope, err = sealHybridEnvelope(sk, path, password, params, plain, opts)
+	} else {
+		envelope, err = sealEnvelope(sk, path, plain, opts)
 	}
-	sealed, err := lockcore.SealV1(sk, path, plain, rand.Reader)
 	if err != nil {
-		return 2
+		return r.FailErr(err, "encrypt failed")
 	}
-	ctB64 := base64.RawStdEncoding.EncodeToString(sealed.Ciphertext)
-	envelope := lockcore.BuildEnvelopeV1(path, sealed.SaltB64, sealed.NonceB64, ctB64)
-	if err := writeOutputBytes(*outPath, []byte(envelope)); err != nil {
-		return 2
+	r.Version = lockcore.DetectEnvelopeVersion(envelope)
+	encoded, ok := encodeEnvelope(envelope, *asBinary)
+	if !ok {
+		return r.Fail(errcode.Internal, "binary encoding failed")
 	}
+	out, err := signOutput(sk, *signMode, *outPath, encoded)
+	if err != nil {
+		return r.FailErr(err, "sign output failed")
+	}
+	if err := writeOutputBytes(*outPath, out); err != nil {
+		return r.FailErr(err, "write output failed")
+	}
+	return r.Done()
+}
 
-	return 0
+// Why(中文): 签名覆盖最终写出的全部字节，使用加密路径上的同一 SK；内嵌追加在 envelope 之后，独立签名写到 <out>.sig。
+// Why(English): The signature covers every output byte and uses the same SK as the encryption path; embedded appends after the envelope, detached writes <out>.sig.
+func signOutput(sk []byte, mode string, outPath string, data []byte) ([]byte, error) {
+	if mode == "" {
+		return data, nil
+	}
+	signer, err := ethsig.AddressOf(sk)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := ethsig.Sign(sk, data)
+	if err != nil {
+		return nil, err
+	}
+	block := ethsig.BuildBlock(signer, sig)
+	if mode == "embedded" {
+		return append(data, block...), nil
+	}
+	return data, os.WriteFile(outPath+".sig", []byte(block), 0o644)
+}
+
+// Why(中文): 二进制容器由同一份规范 Markdown 无损转换而来，两种输出共享全部封装逻辑，AAD 只构建一次。
+// Why(English): The binary container is a lossless conversion of the same canonical Markdown, so both outputs share all sealing logic and the AAD is built once.
+func encodeEnvelope(envelope string, asBinary bool) ([]byte, bool) {
+	if !asBinary {
+		return []byte(envelope), true
+	}
+	return lockcore.MarkdownToBinary(envelope)
+}
+
+// Why(中文): SK 来源二选一：-mnemonic-env 直接派生；未给且设置了 TXLOCK_AGENT_SOCK 时向 agent 请求。口令参数在此之前已分流到口令模式，环境里残留的 agent 不会把它变成双因子。
+// Why(English): The SK comes from -mnemonic-env, or from the agent when it is absent and TXLOCK_AGENT_SOCK is set; password flags were routed to password mode earlier, so a lingering agent never turns them into two-factor.
+func resolveKeySource(mnemonicEnv string, getenv func(string) string) (func(string) ([]byte, error), string) {
+	if mnemonicEnv == "" {
+		sock := getenv(agent.SockEnv)
+		if sock == "" {
+			return nil, "-mnemonic-env is required"
+		}
+		return func(index string) ([]byte, error) { return agent.Client{Path: sock}.Key("", index) }, ""
+	}
+	rawMnemonic := getenv(mnemonicEnv)
+	if rawMnemonic == "" {
+		return nil, "mnemonic env is empty: " + mnemonicEnv
+	}
+	mnemonicCanonical, ok := canonicalizeMnemonic(rawMnemonic)
+	if !ok {
+		return nil, ""
+	}
+	return func(index string) ([]byte, error) { return derive.DeriveSK(mnemonicCanonical, index) }, ""
+}
+
+// Why(中文): 口令模式不依赖助记词与索引，单独成段处理；套件、承诺、压缩、填充与元数据选项沿用钱包模式的同一套校验。
+// Why(English): Password mode needs no mnemonic or index, so it runs separately while reusing wallet mode's validation for suite, commitment, compression, padding and metadata.
+func runPasswordEnc(r *errcode.Reporter, readPassword func() ([]byte, string), params lockcore.Argon2ParamsV2, inPath string, archiveDir string, maxSize int64, outPath string, fieldsFormat string, withMeta bool, asBinary bool, statePath string, opts lockcore.SealOptionsV2) int {
+	if fieldsFormat != "" {
+		return r.Usage("-password-env/-password-prompt cannot be combined with -fields")
+	}
+	if opts.Compress != "" && opts.Compress != "gzip" {
+		return r.Usage("invalid -compress: " + opts.Compress + " (only gzip, not with -fields)")
+	}
+	if opts.Pad != "" && !lockcore.IsPaddingV2(opts.Pad) {
+		return r.Usage("invalid -pad: " + opts.Pad + " (padme or bucket-<power of two>, not with -fields)")
+	}
+	if opts.AEAD != "" && !lockcore.IsAEADV2(opts.AEAD) {
+		return r.Usage("invalid -aead: " + opts.AEAD + " (" + strings.Join(lockcore.AEADNamesV2(), "|") + ", not with -fields)")
+	}
+	if code := reserveSequence(r, opts.ID, &opts.Seq, statePath); code != 0 {
+		return code
+	}
+	password, msg := readPassword()
+	if msg != "" {
+		return r.Usage(msg)
+	}
+	plain, err := readEncSource(inPath, archiveDir, maxSize)
+	if err != nil {
+		return r.FailErr(err, "read input failed")
+	}
+	if withMeta {
+		meta, err := buildFileMeta(inPath, archiveDir, plain)
+		if err != nil {
+			return r.FailErr(err, "read file metadata failed")
+		}
+		opts.Meta = &meta
+	}
+	sealed, err := lockcore.SealPasswordV2(password, params, plain, opts, rand.Reader)
+	if err != nil {
+		return r.FailErr(err, "encrypt failed")
+	}
+	envelope := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	r.Version = lockcore.DetectEnvelopeVersion(envelope)
+	out, ok := encodeEnvelope(envelope, asBinary)
+	if !ok {
+		return r.Fail(errcode.Internal, "binary encoding failed")
+	}
+	if err := writeOutputBytes(outPath, out); err != nil {
+		return r.FailErr(err, "write output failed")
+	}
+	return r.Done()
+}
+
+// Why(中文): 测试替换该变量以模拟终端输入；正式运行时始终走无回显的终端读取。
+// Why(English): Tests swap this variable to simulate terminal input; real runs always use the no-echo terminal reader.
+var promptPassword = secret.Prompt
+
+// Why(中文): 口令来源二选一：环境变量便于脚本，终端提示避免口令进入环境；加密侧提示要求输入两次确认。
+// Why(English): The password comes from exactly one source: an env var for scripts or a terminal prompt that keeps it out of the environment; encryption asks twice to confirm.
+func resolveEncPassword(passwordEnv string, prompt bool, getenv func(string) string) ([]byte, string) {
+	if prompt {
+		password, err := promptPassword("Password", true)
+		if err != nil {
+			return nil, "password prompt failed: " + err.Error()
+		}
+		return password, ""
+	}
+	password := getenv(passwordEnv)
+	if password == "" {
+		return nil, "password env is empty: " + passwordEnv
+	}
+	return []byte(password), ""
 }
 
-// Why(中文): 参数类失败之前输出明确错误文本，避免仅靠退出码导致“看起来没报错”的误判。
-// Why(English): Emit explicit usage errors before returning so failures are visible instead of relying on exit code alone.
-func failEncUsage(msg string) int {
-	_, _ = io.WriteString(os.Stderr, "txlock-enc: "+msg+"\n")
-	return 1
+// Why(中文): flag 以 uint 接收，先做溢出检查再交给下限/上限校验，避免超大值截断后“看起来合法”。
+// Why(English): Flags arrive as uint, so check for overflow before the floor/ceiling check lest a huge value truncate into something that looks valid.
+func argon2ParamsFromFlags(memory uint, iterations uint, threads uint) (lockcore.Argon2ParamsV2, bool) {
+	if memory > math.MaxUint32 || iterations > math.MaxUint32 || threads > math.MaxUint8 {
+		return lockcore.Argon2ParamsV2{}, false
+	}
+	params := lockcore.Argon2ParamsV2{Memory: uint32(memory), Time: uint32(iterations), Threads: uint8(threads)}
+	return params, params.Valid()
+}
+
+// Why(中文): 同时提供助记词与口令即为双因子模式，输出总是 v2/v3 头，kdf 字段向解密方声明两个因子都必需。
+// Why(English): Supplying both mnemonic and password selects two-factor mode; output is always a v2/v3 header whose kdf tells the opener both factors are required.
+func sealHybridEnvelope(sk []byte, path string, password []byte, params lockcore.Argon2ParamsV2, plain []byte, opts lockcore.SealOptionsV2) (string, error) {
+	sealed, err := lockcore.SealHybridV2(sk, path, password, params, plain, opts, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext)), nil
+}
+
+// Why(中文): 未给 -seq 时取该 id 已用过的最大序号加一；显式 -seq 必须更大。序号在加密前写入状态文件预留，后续失败只会留下空号，不会让两个文件共用同一序号。
+// Why(English): Without -seq take the highest seq used for the id plus one; an explicit -seq must be higher. The seq is reserved in the state file before sealing, so a later failure leaves only a gap and never two files sharing one seq.
+func reserveSequence(r *errcode.Reporter, id string, seq *uint64, statePath string) int {
+	if id == "" {
+		return 0
+	}
+	path, err := seqstate.Resolve(statePath)
+	if err != nil {
+		return r.Usage("cannot locate the sequence state file; pass -state")
+	}
+	state, err := seqstate.Load(path)
+	if err != nil {
+		return r.FailErr(err, "load sequence state failed: "+err.Error())
+	}
+	highest := state.Highest(id)
+	if *seq == 0 {
+		*seq = highest + 1
+	} else if *seq <= highest {
+		return r.Fail(errcode.Rollback, fmt.Sprintf("-seq %d is not above seq %d already used for id %s", *seq, highest, id))
+	}
+	state.Record(id, *seq)
+	if err := state.Save(); err != nil {
+		return r.FailErr(err, "save sequence state failed: "+err.Error())
+	}
+	return 0
 }
 
 // Why(中文): 在保持原有退出码语义的同时，单独处理帮助请求，避免被静默丢弃造成“命令无响应”误判。
 // Why(English): Handle help explicitly so usage isn't swallowed by discarded flag output while preserving existing exit-code semantics.
 func printEncUsage() {
-	fmt.Fprintln(os.Stdout, "Usage: txlock-enc -mnemonic-env ENV [-in PATH|-] [-out PATH|-] [-index N]")
+	fmt.Fprintln(os.Stdout, "Usage: txlock-enc [-mnemonic-env ENV [-index N]] [-password-env ENV|-password-prompt [-argon2-memory KiB] [-argon2-time N] [-argon2-threads N]] [-in PATH|-|-archive DIR [-max-size N]] [-out PATH|-] [-aead SUITE] [-commit] [-meta] [-compress gzip] [-pad SCHEME] [-fields FORMAT [-fields-regex RE]] [-sign detached|embedded] [-deterministic] [-label NAME [-label-hidden]] [-id ID [-seq N] [-state PATH]] [-binary] [-json]")
 	fmt.Fprintln(os.Stdout, "Flags:")
-	fmt.Fprintln(os.Stdout, "  -mnemonic-env string   环境变量名，变量值为助记词 (required)")
+	fmt.Fprintln(os.Stdout, "  -mnemonic-env string   环境变量名，变量值为助记词（钱包/双因子模式必填；钱包模式下可由 TXLOCK_AGENT_SOCK 指向的 agent 代替）")
+	fmt.Fprintln(os.Stdout, "  -password-env string   环境变量名，变量值为口令（Argon2id 派生）；单独使用为口令模式，与 -mnemonic-env 同用为双因子")
+	fmt.Fprintln(os.Stdout, "  -password-prompt        从终端无回显读取口令（输入两次确认），与 -password-env 二选一")
+	fmt.Fprintln(os.Stdout, "  -argon2-memory uint    Argon2id 内存（KiB），默认 65536，下限 19456")
+	fmt.Fprintln(os.Stdout, "  -argon2-time uint      Argon2id 迭代次数，默认 3，下限 2")
+	fmt.Fprintln(os.Stdout, "  -argon2-threads uint   Argon2id 并行度，默认 4")
 	fmt.Fprintln(os.Stdout, "  -in string             输入文件路径，默认 - (stdin)")
 	fmt.Fprintln(os.Stdout, "  -out string            输出文件路径，默认 ./lockfile/lock/<name>.lock")
 	fmt.Fprintln(os.Stdout, "  -index string          派生索引，默认 777")
+	fmt.Fprintln(os.Stdout, "  -aead string           AEAD 套件：aes-256-gcm（默认，输出 v1）|aes-256-gcm-siv|xchacha20-poly1305（输出 v2）")
+	fmt.Fprintln(os.Stdout, "  -commit                输出带密钥承诺的 v3 envelope，错误密钥在 AEAD 解密前即被拒绝")
+	fmt.Fprintln(os.Stdout, "  -meta                  在密文内保存原文件名、权限、mtime、MIME 与 SHA-256，供解密还原")
+	fmt.Fprintln(os.Stdout, "  -compress string       加密前压缩（仅 gzip，写入 v2 头并受 AAD 保护；默认关闭以免长度泄露）")
+	fmt.Fprintln(os.Stdout, "  -pad string            AEAD 内长度隐藏填充：padme 或 bucket-<2 的幂>（如 bucket-4096）")
+	fmt.Fprintln(os.Stdout, "  -archive string        将整个目录打包为 tar 后加密为单个 envelope（保留权限与 mtime）")
+	fmt.Fprintln(os.Stdout, "  -fields string         字段级加密：json|yaml|dotenv|auto，仅替换叶子值")
+	fmt.Fprintln(os.Stdout, "  -fields-regex string   仅加密 JSON Pointer 路径匹配该正则的叶子")
+	fmt.Fprintln(os.Stdout, "  -deterministic         确定性加密（v2 头 det:hmac-sha512）：同一内容重复加密输出不变，仅泄露“内容是否相同”")
+	fmt.Fprintln(os.Stdout, "  -sign string           用加密路径上的以太坊密钥做 EIP-191 签名：detached（写 <out>.sig）|embedded（追加到 envelope 后）")
+	fmt.Fprintln(os.Stdout, "  -label string          以 HKDF(sk, \"txlock/file/\"+NAME) 派生本文件独立子密钥（输出 v2），标签写入 AAD 绑定的头字段")
+	fmt.Fprintln(os.Stdout, "  -label-hidden          标签不写入头字段，解密时必须用 -label 提供同一标签")
+	fmt.Fprintln(os.Stdout, "  -id string             逻辑文件标识，与 seq 一起写入 AAD 绑定的头字段（输出 v2），供解密端拒绝旧版本")
+	fmt.Fprintln(os.Stdout, "  -seq uint              版本序号，默认取状态文件中该 id 的最大值加一；显式给出时必须更大")
+	fmt.Fprintln(os.Stdout, "  -state string          序号状态文件，默认 <用户配置目录>/txlock/seq-state.json")
+	fmt.Fprintln(os.Stdout, "  -binary                输出二进制紧凑容器（原始密文 + TLV 头，AAD 与 Markdown 形态相同；可用 txlock convert 互转）")
+	fmt.Fprintln(os.Stdout, "  -json                  在 stderr 输出一行 JSON 结果（status/code/exit/file/index/version），并启用细分退出码 3-6")
 }
 
 // Why(中文): 把输入源选择逻辑集中化，确保文件与 stdin 两种路径遵循同一错误语义。
@@ -122,6 +476,82 @@ func readInputBytes(path string) ([]byte, error) {
 	return os.ReadFile(path)
 }
 
+// Why(中文): 未启用任何 v2 选项时继续输出 v1，既有文件格式与向量保持不变；启用后切换为可扩展的 v2 头。
+// Why(English): Keep emitting v1 when no v2 option is set so the existing format and vectors stay unchanged; switch to the extensible v2 header otherwise.
+func sealEnvelope(sk []byte, path string, plain []byte, opts lockcore.SealOptionsV2) (string, error) {
+	if opts == (lockcore.SealOptionsV2{}) {
+		sealed, err := lockcore.SealV1(sk, path, plain, rand.Reader)
+		if err != nil {
+			return "", err
+		}
+		ctB64 := base64.RawStdEncoding.EncodeToString(sealed.Ciphertext)
+		return lockcore.BuildEnvelopeV1(path, sealed.SaltB64, sealed.NonceB64, ctB64), nil
+	}
+	sealed, err := lockcore.SealV2(sk, path, plain, opts, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext)), nil
+}
+
+// Why(中文): 元数据只取自本地文件系统与内容嗅探，stdin 没有名称与权限时仅保留类型与摘要。
+// Why(English): Metadata comes only from the local filesystem and content sniffing; stdin has no name or mode, so only type and digest are kept.
+func buildFileMeta(inPath string, archiveDir string, plain []byte) (lockcore.FileMetaV2, error) {
+	if archiveDir != "" {
+		fi, err := os.Stat(archiveDir)
+		if err != nil {
+			return lockcore.FileMetaV2{}, err
+		}
+		abs, err := filepath.Abs(archiveDir)
+		if err != nil {
+			return lockcore.FileMetaV2{}, err
+		}
+		return lockcore.NewFileMetaV2(filepath.Base(abs)+".tar", 0o644, fi.ModTime(), "application/x-tar"), nil
+	}
+	if inPath == "-" {
+		return lockcore.NewFileMetaV2("", 0, time.Time{}, http.DetectContentType(plain)), nil
+	}
+	fi, err := os.Stat(inPath)
+	if err != nil {
+		return lockcore.FileMetaV2{}, err
+	}
+	ctype := mime.TypeByExtension(filepath.Ext(inPath))
+	if ctype == "" {
+		ctype = http.DetectContentType(plain)
+	}
+	return lockcore.NewFileMetaV2(filepath.Base(inPath), fi.Mode(), fi.ModTime(), ctype), nil
+}
+
+// Why(中文): 目录归档与单文件输入在加密层之前汇合为同一字节流，后续封装流程无需区分来源；归档在内存中构建，超过 maxSize 即以 ErrTooLarge 停止，与解密侧的 -max-size 策略一致。
+// Why(English): Directory archives and single inputs converge into one byte stream before sealing so the envelope path ignores the source; the archive is built in memory and stops with ErrTooLarge past maxSize, matching the -max-size policy on decrypt.
+func readEncSource(inPath string, archiveDir string, maxSize int64) ([]byte, error) {
+	if archiveDir == "" {
+		return readInputBytes(inPath)
+	}
+	if maxSize <= 0 {
+		maxSize = lockcore.DefaultMaxPlaintext
+	}
+	buf := &cappedBuffer{limit: maxSize}
+	if err := archive.Pack(archiveDir, buf); err != nil {
+		return nil, err
+	}
+	return buf.buf.Bytes(), nil
+}
+
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int64
+}
+
+// Why(中文): 在写入前检查上限，超大目录在占满内存之前就失败，而不是打包完成后才发现。
+// Why(English): Check the limit before buffering so an oversized directory fails before it fills memory, not after packing completes.
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if int64(c.buf.Len())+int64(len(p)) > c.limit {
+		return 0, lockcore.ErrTooLarge
+	}
+	return c.buf.Write(p)
+}
+
 // Why(中文): 把输出目标选择逻辑集中化，确保文件与 stdout 两种路径遵循同一写出规则。
 // Why(English): Centralize output target selection so file and stdout writes follow one consistent rule.
 func writeOutputBytes(path string, data []byte) error {
@@ -146,3 +576,33 @@ func defaultEncOutPath(inPath string) (string, error) {
 	}
 	return filepath.Join(dir, name+".lock"), nil
 }
+
+// Why(中文): 结构化模式的格式与正则属于参数层，必须在派生密钥前校验完毕，非法值按用法错误 exit 1 处理。
+// Why(English): Structured-mode format and regex are usage-level inputs, validated before key derivation so bad values exit 1.
+func resolveFieldsMode(format string, pattern string, inPath string) (string, *regexp.Regexp, string) {
+	if format == "" {
+		if pattern != "" {
+			return "", nil, "-fields-regex requires -fields"
+		}
+		return "", nil, ""
+	}
+	if format == "auto" {
+		format = fieldlock.DetectFormat(filepath.Base(inPath))
+		if format == "" {
+			return "", nil, "cannot detect -fields format from input: " + inPath
+		}
+	}
+	switch format {
+	case "json", "yaml", "dotenv":
+	default:
+		return "", nil, "invalid -fields: " + format
+	}
+	if pattern == "" {
+		return format, nil, ""
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", nil, "invalid -fields-regex: " + pattern
+	}
+	return format, re, ""
+}
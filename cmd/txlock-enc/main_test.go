@@ -1,13 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"TXLOCK/internal/agent"
 	"TXLOCK/internal/derive"
+	"TXLOCK/internal/errcode"
+	"TXLOCK/internal/ethsig"
 	"TXLOCK/internal/lockcore"
+	"TXLOCK/internal/secret"
 )
 
 // Why(中文): 固定合法助记词夹具，避免“助记词非法”干扰索引规则与参数层测试目标。
@@ -164,3 +171,725 @@ func TestRunDefaultOutToTmp(t *testing.T) {
 		t.Fatalf("expected default output in lockfile/lock, err=%v", err)
 	}
 }
+
+// Why(中文): 结构化模式需要与 dec 侧闭环：enc 产出的 JSON 必须能被 dec -fields 还原为原文。
+// Why(English): Structured mode must close the loop with dec: JSON produced by enc must restore to the original via dec -fields.
+func TestRunFieldsJSONHidesValues(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "config.json")
+	outPath := filepath.Join(dir, "config.json.lock")
+	if err := os.WriteFile(inPath, []byte("{\n  \"user\": \"admin\",\n  \"password\": \"s3cret\"\n}\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-fields", "auto", "-fields-regex", "password"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if !strings.Contains(string(raw), "\"admin\"") || strings.Contains(string(raw), "s3cret") || !strings.Contains(string(raw), "\"txlock\"") {
+		t.Fatalf("unexpected structured output: %s", raw)
+	}
+}
+
+// Why(中文): 格式名属于参数层，非法值必须在读取输入前以 exit 1 失败。
+// Why(English): The format name is a usage-level argument; invalid values must exit 1 before any input is read.
+func TestRunFieldsInvalidFormatReturns1(t *testing.T) {
+	code := run([]string{"-mnemonic-env", "MNEM", "-fields", "toml"}, func(string) string { return fixtureMnemonic() })
+	if code != 1 {
+		t.Fatalf("expected 1, got %d", code)
+	}
+	code = run([]string{"-mnemonic-env", "MNEM", "-fields-regex", "x"}, func(string) string { return fixtureMnemonic() })
+	if code != 1 {
+		t.Fatalf("expected 1 for regex without -fields, got %d", code)
+	}
+}
+
+// Why(中文): 目录归档必须产出单个可解析的 envelope，且不能与 -in 同时使用以免输入来源歧义。
+// Why(English): Archiving a directory must yield one parseable envelope and refuse -in so the input source is never ambiguous.
+func TestRunArchiveProducesSingleEnvelope(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "secrets")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	outPath := filepath.Join(dir, "secrets.lock")
+	code := run([]string{"-archive", src, "-out", outPath, "-mnemonic-env", "MNEM"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read envelope: %v", err)
+	}
+	if _, _, _, _, ok := lockcore.ParseEnvelopeV1(string(raw)); !ok || strings.Contains(string(raw), "a.txt") {
+		t.Fatalf("expected opaque single envelope")
+	}
+	code = run([]string{"-archive", src, "-in", filepath.Join(src, "a.txt"), "-mnemonic-env", "MNEM"}, func(string) string { return fixtureMnemonic() })
+	if code != 1 {
+		t.Fatalf("expected 1 for -archive with -in, got %d", code)
+	}
+}
+
+// Why(中文): 归档在内存中构建，tar 超过 -max-size 必须在写出前失败：文本模式 exit 2，-json 为 TOO_LARGE（exit 6），且不留下输出文件。
+// Why(English): Archives are built in memory, so a tar past -max-size must fail before anything is written: exit 2 in text mode, TOO_LARGE (exit 6) with -json, and no output file left behind.
+func TestRunArchiveRespectsMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "secrets")
+	if err := os.Mkdir(src, 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "big.bin"), bytes.Repeat([]byte("x"), 8192), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	outPath := filepath.Join(dir, "secrets.lock")
+	good := func(string) string { return fixtureMnemonic() }
+	if code := run([]string{"-archive", src, "-out", outPath, "-mnemonic-env", "MNEM", "-max-size", "4096"}, good); code != 2 {
+		t.Fatalf("expected 2 for oversized archive, got %d", code)
+	}
+	if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+		t.Fatalf("oversized archive must not write output: %v", err)
+	}
+	var code int
+	stderr := captureStderr(t, func() {
+		code = run([]string{"-archive", src, "-out", outPath, "-mnemonic-env", "MNEM", "-max-size", "4096", "-json"}, good)
+	})
+	var got errcode.Result
+	if err := json.Unmarshal([]byte(stderr), &got); err != nil || code != errcode.ExitPolicy || got.Code != errcode.TooLarge {
+		t.Fatalf("expected TOO_LARGE exit %d, got %d %q (%v)", errcode.ExitPolicy, code, stderr, err)
+	}
+	if code := run([]string{"-archive", src, "-out", outPath, "-mnemonic-env", "MNEM", "-max-size", "65536"}, good); code != 0 {
+		t.Fatalf("expected 0 within -max-size, got %d", code)
+	}
+}
+
+// Why(中文): -compress 必须切换到带 compress 头的 v2 envelope；未知算法属于用法错误。
+// Why(English): -compress must switch to a v2 envelope carrying the compress header; unknown algorithms are usage errors.
+func TestRunCompressWritesV2Header(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.md")
+	outPath := filepath.Join(dir, "out.lock")
+	if err := os.WriteFile(inPath, []byte(strings.Repeat("compressible line\n", 100)), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-compress", "gzip"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read envelope: %v", err)
+	}
+	h, _, ok := lockcore.ParseEnvelopeV2(string(raw))
+	if v, _ := lockcore.HeaderValue(h, "compress"); !ok || v != "gzip" {
+		t.Fatalf("expected v2 envelope with compress:gzip, got %q", raw)
+	}
+	code = run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-compress", "zstd"}, func(string) string { return fixtureMnemonic() })
+	if code != 1 {
+		t.Fatalf("expected 1 for unsupported -compress, got %d", code)
+	}
+}
+
+// Why(中文): -aead 选择非默认套件时写出带对应 aead 头的 v2；显式默认套件仍输出 v1，未知套件属于用法错误。
+// Why(English): A non-default -aead writes v2 with that aead header; the explicit default still writes v1, and unknown suites are usage errors.
+func TestRunAEADSelectsSuite(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.md")
+	outPath := filepath.Join(dir, "out.lock")
+	if err := os.WriteFile(inPath, []byte("hello suites\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	for _, suite := range []string{"xchacha20-poly1305", "aes-256-gcm-siv"} {
+		code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-aead", suite}, func(string) string { return fixtureMnemonic() })
+		if code != 0 {
+			t.Fatalf("%s: expected 0, got %d", suite, code)
+		}
+		raw, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("read envelope: %v", err)
+		}
+		h, _, ok := lockcore.ParseEnvelopeV2(string(raw))
+		if v, _ := lockcore.HeaderValue(h, "aead"); !ok || v != suite {
+			t.Fatalf("%s: expected v2 envelope with matching aead, got %q", suite, raw)
+		}
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-aead", "aes-256-gcm"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read envelope: %v", err)
+	}
+	if _, _, _, _, ok := lockcore.ParseEnvelopeV1(string(raw)); !ok {
+		t.Fatalf("expected v1 envelope for default suite, got %q", raw)
+	}
+	code = run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-aead", "chacha20-poly1305"}, func(string) string { return fixtureMnemonic() })
+	if code != 1 {
+		t.Fatalf("expected 1 for unknown -aead, got %d", code)
+	}
+}
+
+// Why(中文): -commit 必须输出带 commit_b64 的 v3 envelope，且不能与 -fields 组合。
+// Why(English): -commit must write a v3 envelope carrying commit_b64 and cannot be combined with -fields.
+func TestRunCommitWritesV3(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.md")
+	outPath := filepath.Join(dir, "out.lock")
+	if err := os.WriteFile(inPath, []byte("hello commit\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-commit"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read envelope: %v", err)
+	}
+	h, _, ok := lockcore.ParseEnvelopeV2(string(raw))
+	if _, has := lockcore.HeaderValue(h, "commit_b64"); !ok || !has || lockcore.DetectEnvelopeVersion(string(raw)) != "v3" {
+		t.Fatalf("expected v3 envelope with commitment, got %q", raw)
+	}
+	code = run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-commit", "-fields", "json"}, func(string) string { return fixtureMnemonic() })
+	if code != 1 {
+		t.Fatalf("expected 1 for -commit with -fields, got %d", code)
+	}
+}
+
+// Why(中文): 口令模式写出 kdf:argon2id 的 v2 头并带上参数；两种口令来源同用或参数低于下限都属于用法错误。
+// Why(English): Password mode writes a v2 header with kdf:argon2id and its parameters; using both password sources or going below the floors is a usage error.
+func TestRunPasswordModeWritesArgon2Header(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "audit.md")
+	outPath := filepath.Join(dir, "audit.lock")
+	if err := os.WriteFile(inPath, []byte("for the auditor\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	getenv := func(k string) string {
+		if k == "PASS" {
+			return "correct horse battery staple"
+		}
+		return fixtureMnemonic()
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-password-env", "PASS", "-argon2-memory", "19456", "-argon2-time", "2", "-argon2-threads", "1"}, getenv)
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read envelope: %v", err)
+	}
+	h, _, ok := lockcore.ParseEnvelopeV2(string(raw))
+	kdf, _ := lockcore.HeaderValue(h, "kdf")
+	params, _ := lockcore.HeaderValue(h, "kdf_params")
+	if !ok || kdf != "argon2id" || params != "m=19456,t=2,p=1" || strings.Contains(string(raw), "path") {
+		t.Fatalf("unexpected password envelope: %q", raw)
+	}
+	for i, args := range [][]string{
+		{"-in", inPath, "-out", outPath, "-password-env", "PASS", "-password-prompt"},
+		{"-in", inPath, "-out", outPath, "-password-env", "PASS", "-argon2-memory", "1024"},
+		{"-in", inPath, "-out", outPath, "-password-env", "PASS", "-argon2-threads", "300"},
+		{"-in", inPath, "-out", outPath, "-password-env", "EMPTY"},
+	} {
+		if code := run(args, func(k string) string {
+			if k == "EMPTY" {
+				return ""
+			}
+			return getenv(k)
+		}); code != 1 {
+			t.Fatalf("case %d: expected 1, got %d", i, code)
+		}
+	}
+}
+
+// Why(中文): 助记词与口令同时提供即写出双因子头；-password-prompt 走终端读取（测试中替换为假输入），确认失败属于用法错误。
+// Why(English): Mnemonic plus password writes a two-factor header; -password-prompt reads from the terminal (faked in tests) and a failed confirmation is a usage error.
+func TestRunHybridWritesTwoFactorHeader(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "vault.md")
+	outPath := filepath.Join(dir, "vault.lock")
+	if err := os.WriteFile(inPath, []byte("high value\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	orig := promptPassword
+	defer func() { promptPassword = orig }()
+	promptPassword = func(string, bool) ([]byte, error) { return []byte("correct horse battery staple"), nil }
+	code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-password-prompt", "-argon2-memory", "19456", "-argon2-time", "2", "-argon2-threads", "1"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read envelope: %v", err)
+	}
+	h, _, ok := lockcore.ParseEnvelopeV2(string(raw))
+	if kdf, _ := lockcore.HeaderValue(h, "kdf"); !ok || kdf != lockcore.KDFHybridV2 {
+		t.Fatalf("expected two-factor header, got %q", raw)
+	}
+	promptPassword = func(string, bool) ([]byte, error) { return nil, secret.ErrMismatch }
+	if code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-password-prompt"}, func(string) string { return fixtureMnemonic() }); code != 1 {
+		t.Fatalf("expected 1 for failed prompt, got %d", code)
+	}
+	if code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-password-prompt", "-fields", "json"}, func(string) string { return fixtureMnemonic() }); code != 1 {
+		t.Fatalf("expected 1 for password with -fields, got %d", code)
+	}
+}
+
+// Why(中文): 分桶填充后不同长度的明文必须得到相同长度的 envelope，非法方案名属于用法错误。
+// Why(English): With bucket padding, different plaintext lengths must yield equal-length envelopes; bad scheme names are usage errors.
+func TestRunPadEqualizesEnvelopeLength(t *testing.T) {
+	dir := t.TempDir()
+	var sizes []int
+	for i, body := range []string{strings.Repeat("abandon ", 11) + "about\n", strings.Repeat("abandon ", 23) + "art\n"} {
+		inPath := filepath.Join(dir, "in.txt")
+		outPath := filepath.Join(dir, "out.lock")
+		if err := os.WriteFile(inPath, []byte(body), 0o644); err != nil {
+			t.Fatalf("write input: %v", err)
+		}
+		code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-pad", "bucket-256"}, func(string) string { return fixtureMnemonic() })
+		if code != 0 {
+			t.Fatalf("case %d: expected 0, got %d", i, code)
+		}
+		fi, err := os.Stat(outPath)
+		if err != nil {
+			t.Fatalf("stat output: %v", err)
+		}
+		sizes = append(sizes, int(fi.Size()))
+	}
+	if sizes[0] != sizes[1] {
+		t.Fatalf("expected equal envelope sizes, got %v", sizes)
+	}
+	code := run([]string{"-mnemonic-env", "MNEM", "-pad", "bucket-100"}, func(string) string { return fixtureMnemonic() })
+	if code != 1 {
+		t.Fatalf("expected 1 for invalid -pad, got %d", code)
+	}
+}
+
+// Why(中文): -meta 必须切换到带 meta 头的 v2，且文件名只存在于密文中，不出现在可见头里。
+// Why(English): -meta must switch to v2 with a meta header while the file name lives only inside the ciphertext.
+func TestRunMetaKeepsNameInsideCiphertext(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "wallet-notes.md")
+	outPath := filepath.Join(dir, "out.lock")
+	if err := os.WriteFile(inPath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-meta"}, func(string) string { return fixtureMnemonic() })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read envelope: %v", err)
+	}
+	h, _, ok := lockcore.ParseEnvelopeV2(string(raw))
+	if v, _ := lockcore.HeaderValue(h, "meta"); !ok || v != "json" || strings.Contains(string(raw), "wallet-notes") {
+		t.Fatalf("unexpected envelope: %s", raw)
+	}
+}
+
+// Why(中文): 在短路径临时目录启动进程内 agent 并载入夹具账户，CLI 测试无需真实后台进程。
+// Why(English): Start an in-process agent under a short temp dir with the fixture account loaded so CLI tests need no background process.
+func startFixtureAgent(t *testing.T, load bool) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "txa")
+	if err != nil {
+		t.Fatalf("mkdir temp: %v", err)
+	}
+	sock := filepath.Join(dir, "agent.sock")
+	ln, err := agent.Listen(sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	a := agent.New(0, 0)
+	if load {
+		account, err := derive.DeriveAccountKey(fixtureMnemonic())
+		if err != nil {
+			t.Fatalf("derive account: %v", err)
+		}
+		if _, err := a.Add(account, 0); err != nil {
+			t.Fatalf("agent add: %v", err)
+		}
+	}
+	served := make(chan error, 1)
+	go func() { served <- a.Serve(ln) }()
+	t.Cleanup(func() {
+		a.Kill()
+		<-served
+		os.RemoveAll(dir)
+	})
+	return sock
+}
+
+// Why(中文): 未给 -mnemonic-env 时经 TXLOCK_AGENT_SOCK 取 SK，产物必须能用助记词直接解开；agent 无密钥时按处理错误返回。
+// Why(English): Without -mnemonic-env the SK comes via TXLOCK_AGENT_SOCK and the output must open with the mnemonic directly; an empty agent is a processing error.
+func TestRunUsesAgentWithoutMnemonic(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "note.md")
+	outPath := filepath.Join(dir, "note.lock")
+	if err := os.WriteFile(inPath, []byte("agent sealed\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	sock := startFixtureAgent(t, true)
+	getenv := func(k string) string {
+		if k == agent.SockEnv {
+			return sock
+		}
+		return ""
+	}
+	if code := run([]string{"-in", inPath, "-out", outPath, "-index", "778", "-commit"}, getenv); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read envelope: %v", err)
+	}
+	h, ct, ok := lockcore.ParseEnvelopeV2(string(raw))
+	if !ok {
+		t.Fatalf("invalid envelope: %q", raw)
+	}
+	sk, _ := derive.DeriveSK(fixtureMnemonic(), "778")
+	got, err := lockcore.OpenV2(sk, "m/44'/60'/0'/0/778", h, ct, lockcore.OpenOptionsV2{})
+	if err != nil || string(got) != "agent sealed\n" {
+		t.Fatalf("agent-sealed output must open with the mnemonic: err=%v", err)
+	}
+	empty := startFixtureAgent(t, false)
+	if code := run([]string{"-in", inPath, "-out", outPath}, func(k string) string {
+		if k == agent.SockEnv {
+			return empty
+		}
+		return ""
+	}); code != 2 {
+		t.Fatalf("expected 2 for empty agent, got %d", code)
+	}
+	if code := run([]string{"-in", inPath, "-out", outPath}, func(string) string { return "" }); code != 1 {
+		t.Fatalf("expected 1 without mnemonic or agent, got %d", code)
+	}
+}
+
+// Why(中文): 内嵌签名追加在 envelope 之后、独立签名写到 <out>.sig，两者都能用索引对应的地址验证；口令模式与字段模式下的非法组合属于用法错误。
+// Why(English): Embedded signatures follow the envelope and detached ones go to <out>.sig, both verifying against the index's address; invalid combinations with password or field mode are usage errors.
+func TestRunSignEmbeddedAndDetached(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(inPath, []byte("signed note\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	getenv := func(k string) string {
+		if k == "PASS" {
+			return "correct horse battery staple"
+		}
+		return fixtureMnemonic()
+	}
+	sk, _ := derive.DeriveSK(fixtureMnemonic(), "777")
+	signer, _ := ethsig.AddressOf(sk)
+	embedded := filepath.Join(dir, "a.lock")
+	if code := run([]string{"-in", inPath, "-out", embedded, "-mnemonic-env", "MNEM", "-sign", "embedded"}, getenv); code != 0 {
+		t.Fatalf("embedded: expected 0, got %d", code)
+	}
+	raw, _ := os.ReadFile(embedded)
+	body, block, ok := ethsig.SplitEmbedded(string(raw))
+	if !ok || lockcore.DetectEnvelopeVersion(body) != "v1" || ethsig.VerifyBlock([]byte(body), block, signer) != nil {
+		t.Fatalf("embedded signature must verify against %s", signer)
+	}
+	detached := filepath.Join(dir, "b.lock")
+	if code := run([]string{"-in", inPath, "-out", detached, "-mnemonic-env", "MNEM", "-sign", "detached", "-aead", "xchacha20-poly1305"}, getenv); code != 0 {
+		t.Fatalf("detached: expected 0, got %d", code)
+	}
+	raw, _ = os.ReadFile(detached)
+	sigBlock, err := os.ReadFile(detached + ".sig")
+	if err != nil || ethsig.VerifyBlock(raw, string(sigBlock), signer) != nil {
+		t.Fatalf("detached signature must verify, err=%v", err)
+	}
+	for _, args := range [][]string{
+		{"-in", inPath, "-out", embedded, "-mnemonic-env", "MNEM", "-sign", "inline"},
+		{"-in", inPath, "-out", "-", "-mnemonic-env", "MNEM", "-sign", "detached"},
+		{"-in", inPath, "-out", embedded, "-password-env", "PASS", "-sign", "embedded"},
+		{"-in", inPath, "-out", embedded, "-mnemonic-env", "MNEM", "-fields", "json", "-sign", "embedded"},
+	} {
+		if code := run(args, getenv); code != 1 {
+			t.Fatalf("%v: expected 1, got %d", args, code)
+		}
+	}
+}
+
+// Why(中文): 确定性模式下重复加密同一文件输出逐字节一致，内容变化则输出变化；与字段模式或口令同用属于用法错误。
+// Why(English): In deterministic mode re-encrypting the same file is byte-identical and changed content changes the output; combining with field mode or a password is a usage error.
+func TestRunDeterministicIsReproducible(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(inPath, []byte("stable\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	getenv := func(k string) string {
+		if k == "PASS" {
+			return "correct horse battery staple"
+		}
+		return fixtureMnemonic()
+	}
+	var outs []string
+	for i, content := range []string{"stable\n", "stable\n", "changed\n"} {
+		if err := os.WriteFile(inPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("write input: %v", err)
+		}
+		outPath := filepath.Join(dir, "out"+string(rune('a'+i))+".lock")
+		if code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-deterministic", "-pad", "padme"}, getenv); code != 0 {
+			t.Fatalf("expected 0, got %d", code)
+		}
+		raw, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("read output: %v", err)
+		}
+		outs = append(outs, string(raw))
+	}
+	if outs[0] != outs[1] || outs[0] == outs[2] || !strings.Contains(outs[0], "\ndet:hmac-sha512\n") {
+		t.Fatalf("unexpected deterministic outputs:\n%s\n%s", outs[0], outs[2])
+	}
+	for _, args := range [][]string{
+		{"-in", inPath, "-out", filepath.Join(dir, "x.lock"), "-password-env", "PASS", "-deterministic"},
+		{"-in", inPath, "-out", filepath.Join(dir, "x.lock"), "-mnemonic-env", "MNEM", "-password-env", "PASS", "-deterministic"},
+		{"-in", inPath, "-out", filepath.Join(dir, "x.lock"), "-mnemonic-env", "MNEM", "-fields", "json", "-deterministic"},
+	} {
+		if code := run(args, getenv); code != 1 {
+			t.Fatalf("%v: expected 1, got %d", args, code)
+		}
+	}
+}
+
+// Why(中文): -binary 输出二进制容器，转回 Markdown 后用同一 SK 可解密；与 -fields 或内嵌签名组合属于用法错误。
+// Why(English): -binary writes a binary container that decrypts with the same SK after converting back to Markdown; combining it with -fields or an embedded signature is a usage error.
+func TestRunBinaryOutput(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "note.md")
+	outPath := filepath.Join(dir, "note.lock")
+	if err := os.WriteFile(inPath, []byte("compact\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	getenv := func(string) string { return fixtureMnemonic() }
+	if code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-commit", "-binary"}, getenv); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil || !lockcore.IsBinaryEnvelope(raw) {
+		t.Fatalf("expected binary output, err=%v", err)
+	}
+	version, h, ct, ok := lockcore.ParseBinaryEnvelope(raw)
+	if !ok || version != "v3" {
+		t.Fatalf("unexpected binary parse: %q %v", version, ok)
+	}
+	sk, _ := derive.DeriveSK(fixtureMnemonic(), "777")
+	plain, err := lockcore.OpenV2(sk, "m/44'/60'/0'/0/777", h, ct, lockcore.OpenOptionsV2{})
+	if err != nil || string(plain) != "compact\n" {
+		t.Fatalf("unexpected plaintext %q err=%v", plain, err)
+	}
+	for _, args := range [][]string{
+		{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-binary", "-fields", "json"},
+		{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-binary", "-sign", "embedded"},
+	} {
+		if code := run(args, getenv); code != 1 {
+			t.Fatalf("%v: expected 1, got %d", args, code)
+		}
+	}
+}
+
+// Why(中文): -label 写出带 label 头字段的 v2，文件密钥是标签子密钥而非 sk；-label-hidden 不落盘标签；与口令、-fields 组合或缺少 -label 属于用法错误。
+// Why(English): -label writes a v2 with a label header keyed by the label subkey rather than sk; -label-hidden keeps the label off disk; combining with a password or -fields, or omitting -label, is a usage error.
+func TestRunLabelSubkey(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(inPath, []byte("labeled\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	getenv := func(k string) string {
+		if k == "PASS" {
+			return "correct horse battery staple"
+		}
+		return fixtureMnemonic()
+	}
+	sk, _ := derive.DeriveSK(fixtureMnemonic(), "777")
+	path := "m/44'/60'/0'/0/777"
+	for _, hidden := range []bool{false, true} {
+		outPath := filepath.Join(dir, "note.lock")
+		args := []string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-label", "notes/today.md"}
+		if hidden {
+			args = append(args, "-label-hidden")
+		}
+		if code := run(args, getenv); code != 0 {
+			t.Fatalf("%v: expected 0, got %d", args, code)
+		}
+		raw, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("read output: %v", err)
+		}
+		h, ct, ok := lockcore.ParseEnvelopeV2(string(raw))
+		if !ok {
+			t.Fatalf("expected a v2 envelope:\n%s", raw)
+		}
+		if _, has := lockcore.HeaderValue(h, "label"); has == hidden {
+			t.Fatalf("hidden=%v but label header present=%v", hidden, has)
+		}
+		if _, err := lockcore.OpenV2(sk, path, h, ct, lockcore.OpenOptionsV2{}); err == nil && hidden {
+			t.Fatalf("a hidden-label file must not open without its label")
+		}
+		plain, err := lockcore.OpenV2(sk, path, h, ct, lockcore.OpenOptionsV2{Label: "notes/today.md"})
+		if err != nil || string(plain) != "labeled\n" {
+			t.Fatalf("unexpected plaintext %q err=%v", plain, err)
+		}
+	}
+	for _, args := range [][]string{
+		{"-in", inPath, "-out", filepath.Join(dir, "x.lock"), "-mnemonic-env", "MNEM", "-label-hidden"},
+		{"-in", inPath, "-out", filepath.Join(dir, "x.lock"), "-mnemonic-env", "MNEM", "-label", "bad label"},
+		{"-in", inPath, "-out", filepath.Join(dir, "x.lock"), "-password-env", "PASS", "-label", "a"},
+		{"-in", inPath, "-out", filepath.Join(dir, "x.lock"), "-mnemonic-env", "MNEM", "-password-env", "PASS", "-label", "a"},
+		{"-in", inPath, "-out", filepath.Join(dir, "x.lock"), "-mnemonic-env", "MNEM", "-fields", "json", "-label", "a"},
+	} {
+		if code := run(args, getenv); code != 1 {
+			t.Fatalf("%v: expected 1, got %d", args, code)
+		}
+	}
+}
+
+// Why(中文): -id 未给 -seq 时按状态文件自动递增；显式 -seq 不高于已用值时拒绝（退出 2）；口令模式同样写入 id/seq；-seq 缺 -id、非法 id 与 -fields 组合为用法错误。
+// Why(English): -id without -seq auto-increments from the state file; an explicit -seq not above the used value is refused (exit 2); password mode writes id/seq too; -seq without -id, an invalid id and -fields are usage errors.
+func TestRunSequenceState(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(inPath, []byte("versioned\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	getenv := func(k string) string {
+		if k == "PASS" {
+			return "correct horse battery staple"
+		}
+		return fixtureMnemonic()
+	}
+	state := filepath.Join(dir, "state", "seq-state.json")
+	outPath := filepath.Join(dir, "note.lock")
+	seqOf := func() uint64 {
+		raw, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("read output: %v", err)
+		}
+		h, _, ok := lockcore.ParseEnvelopeV2(string(raw))
+		if !ok {
+			t.Fatalf("expected a v2 envelope:\n%s", raw)
+		}
+		id, seq, ok := lockcore.SequenceOf(h)
+		if !ok || id != "notes" {
+			t.Fatalf("missing id/seq headers:\n%s", raw)
+		}
+		return seq
+	}
+	wallet := []string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-id", "notes", "-state", state}
+	for want := uint64(1); want <= 2; want++ {
+		if code := run(wallet, getenv); code != 0 {
+			t.Fatalf("auto seq: expected 0, got %d", code)
+		}
+		if got := seqOf(); got != want {
+			t.Fatalf("auto seq: expected %d, got %d", want, got)
+		}
+	}
+	if code := run(append(wallet, "-seq", "2"), getenv); code != 2 {
+		t.Fatalf("reused -seq: expected 2, got %d", code)
+	}
+	if code := run(append(wallet, "-seq", "10"), getenv); code != 0 || seqOf() != 10 {
+		t.Fatalf("explicit -seq: code %d", code)
+	}
+	password := []string{"-in", inPath, "-out", outPath, "-password-env", "PASS", "-argon2-memory", "19456", "-argon2-time", "2", "-argon2-threads", "1", "-id", "notes", "-state", state}
+	if code := run(password, getenv); code != 0 || seqOf() != 11 {
+		t.Fatalf("password auto seq: code %d", code)
+	}
+	for _, args := range [][]string{
+		{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-seq", "3", "-state", state},
+		{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-id", "bad id", "-state", state},
+		{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-id", "notes", "-fields", "json", "-state", state},
+	} {
+		if code := run(args, getenv); code != 1 {
+			t.Fatalf("%v: expected 1, got %d", args, code)
+		}
+	}
+}
+
+// Why(中文): 读取 -json 写到 stderr 的结果对象；与解密侧测试同一做法，临时把 os.Stderr 换成管道。
+// Why(English): Read the result object -json writes to stderr by swapping os.Stderr for a pipe, as the decrypt-side tests do.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	saved := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = saved }()
+	fn()
+	_ = w.Close()
+	raw, _ := io.ReadAll(r)
+	return string(raw)
+}
+
+// Why(中文): 加密侧 -json 成功时报告输出路径、索引与实际写出的版本（-commit 为 v3）；校验和错误的助记词为 MNEMONIC_CHECKSUM（退出 5），不加 -json 仍为 2；重复的 -seq 为 ROLLBACK（退出 6）。
+// Why(English): On success encrypt-side -json reports the output path, index and the version actually written (v3 with -commit); a bad-checksum mnemonic is MNEMONIC_CHECKSUM (exit 5) and still 2 without -json; a reused -seq is ROLLBACK (exit 6).
+func TestRunJSONResult(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(inPath, []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	outPath := filepath.Join(dir, "note.lock")
+	state := filepath.Join(dir, "seq-state.json")
+	good := func(string) string { return fixtureMnemonic() }
+	bad := func(string) string {
+		return "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"
+	}
+	for _, tc := range []struct {
+		getenv func(string) string
+		args   []string
+		want   errcode.Result
+	}{
+		{good, []string{"-commit"}, errcode.Result{Status: "ok", Code: errcode.OK, Exit: 0, Version: "v3"}},
+		{good, []string{"-id", "notes", "-state", state}, errcode.Result{Status: "ok", Code: errcode.OK, Exit: 0, Version: "v2"}},
+		{good, []string{"-id", "notes", "-seq", "1", "-state", state}, errcode.Result{Status: "error", Code: errcode.Rollback, Exit: 6}},
+		{bad, nil, errcode.Result{Status: "error", Code: errcode.MnemonicChecksum, Exit: 5}},
+	} {
+		args := append([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM", "-json"}, tc.args...)
+		var code int
+		stderr := captureStderr(t, func() { code = run(args, tc.getenv) })
+		var got errcode.Result
+		if err := json.Unmarshal([]byte(stderr), &got); err != nil || strings.Count(stderr, "\n") != 1 {
+			t.Fatalf("%v: expected one JSON line, got %q (%v)", args, stderr, err)
+		}
+		tc.want.Tool, tc.want.File, tc.want.Output, tc.want.Index, got.Message = "txlock-enc", inPath, outPath, "777", ""
+		if code != tc.want.Exit || got != tc.want {
+			t.Fatalf("%v: exit %d, got %+v, want %+v", args, code, got, tc.want)
+		}
+	}
+	if code := run([]string{"-in", inPath, "-out", outPath, "-mnemonic-env", "MNEM"}, bad); code != 2 {
+		t.Fatalf("text mode must keep exit 2, got %d", code)
+	}
+}
+
+// Why(中文): 未知 flag 在解析阶段就失败，-json 仍须输出一行 USAGE 结果（退出 1）；不加 -json 保持静默退出 1。
+// Why(English): An unknown flag fails during parsing, yet -json must still print one USAGE result line (exit 1); without -json it stays a silent exit 1.
+func TestRunJSONReportsFlagParseError(t *testing.T) {
+	var code int
+	stderr := captureStderr(t, func() { code = run([]string{"-json", "-bogus"}, func(string) string { return "" }) })
+	var got errcode.Result
+	if err := json.Unmarshal([]byte(stderr), &got); err != nil || strings.Count(stderr, "\n") != 1 {
+		t.Fatalf("expected one JSON line, got %q (%v)", stderr, err)
+	}
+	if code != 1 || got.Status != "error" || got.Code != errcode.Usage || got.Exit != 1 || got.Tool != "txlock-enc" || !strings.Contains(got.Message, "bogus") {
+		t.Fatalf("unexpected result: exit %d, %+v", code, got)
+	}
+	stderr = captureStderr(t, func() { code = run([]string{"-bogus"}, func(string) string { return "" }) })
+	if code != 1 || stderr != "" {
+		t.Fatalf("text mode: expected silent exit 1, got %d %q", code, stderr)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"TXLOCK/internal/agent"
+	"TXLOCK/internal/derive"
+	"TXLOCK/internal/secret"
+)
+
+// Why(中文): 自动创建的 socket 目录使用固定前缀，退出时只清理带此前缀的临时目录，不会误删用户指定的目录。
+// Why(English): Auto-created socket dirs share a fixed prefix so shutdown removes only those temp dirs and never a user-chosen one.
+const agentDirPrefix = "txlock-agent-"
+
+// Why(中文): 测试替换该变量以模拟终端输入；正式运行时始终走无回显的终端读取。
+// Why(English): Tests swap this variable to simulate terminal input; real runs always use the no-echo terminal reader.
+var promptSecret = secret.Prompt
+
+// Why(中文): 首个参数为管理子命令时转发给客户端，否则视为启动 agent，与 ssh-agent 的用法习惯一致。
+// Why(English): A management subcommand as the first argument goes to the client; anything else starts the agent, mirroring ssh-agent.
+func runAgent(args []string, getenv func(string) string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "add":
+			return runAgentAdd(args[1:], getenv)
+		case "list", "lock", "kill":
+			if len(args) != 1 {
+				return failUsage("agent " + args[0] + " takes no arguments")
+			}
+			return runAgentClient(args[0], getenv)
+		}
+	}
+	return runAgentStart(args)
+}
+
+// Why(中文): 默认在后台启动并打印可 eval 的环境变量行；-foreground 供调试与进程管理器使用。
+// Why(English): By default start in the background and print an eval-able env line; -foreground serves debugging and process supervisors.
+func runAgentStart(args []string) int {
+	fs := flag.NewFlagSet("txlock agent", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	sock := fs.String("sock", "", "")
+	ttl := fs.Duration("ttl", 8*time.Hour, "")
+	idle := fs.Duration("idle", 30*time.Minute, "")
+	foreground := fs.Bool("foreground", false, "")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() != 0 {
+		return failUsage("unexpected argument: " + fs.Arg(0))
+	}
+	if *ttl < 0 || *idle < 0 {
+		return failUsage("-ttl and -idle must not be negative")
+	}
+	if *sock == "" {
+		dir, err := os.MkdirTemp("", agentDirPrefix)
+		if err != nil {
+			return failProcess("create socket dir failed")
+		}
+		*sock = filepath.Join(dir, "agent.sock")
+	} else if err := agent.CheckSocketDir(filepath.Dir(*sock)); err != nil {
+		return failProcess("unsafe -sock " + *sock + ": " + err.Error())
+	}
+	if *foreground {
+		return serveAgent(*sock, *ttl, *idle, os.Stdout)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return failProcess("locate executable failed")
+	}
+	cmd := exec.Command(exe, "agent", "-foreground", "-sock", *sock, "-ttl", ttl.String(), "-idle", idle.String())
+	cmd.SysProcAttr = detachAttr()
+	if err := cmd.Start(); err != nil {
+		return failProcess("start agent failed")
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(*sock); err == nil {
+			printAgentEnv(os.Stdout, *sock, cmd.Process.Pid)
+			return 0
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+	_ = cmd.Process.Kill()
+	return failProcess("agent did not come up")
+}
+
+// Why(中文): 输出格式与 ssh-agent 相同，可直接 eval "$(txlock agent)"。
+// Why(English): Output matches ssh-agent so eval "$(txlock agent)" works as is.
+func printAgentEnv(w io.Writer, sock string, pid int) {
+	fmt.Fprintf(w, "%s=%s; export %s;\n", agent.SockEnv, sock, agent.SockEnv)
+	fmt.Fprintf(w, "echo Agent pid %d;\n", pid)
+}
+
+// Why(中文): 收到 INT/TERM/HUP 时先清零密钥再退出，socket 与自动创建的目录随之删除。
+// Why(English): On INT/TERM/HUP wipe keys before exiting, then remove the socket and any auto-created dir.
+func serveAgent(sock string, ttl time.Duration, idle time.Duration, out io.Writer) int {
+	ln, err := agent.Listen(sock)
+	if err != nil {
+		return failProcess("listen on " + sock + " failed: " + err.Error())
+	}
+	defer cleanupSocket(sock)
+	a := agent.New(ttl, idle)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+	go func() {
+		if _, ok := <-sigs; ok {
+			a.Kill()
+		}
+	}()
+	printAgentEnv(out, sock, os.Getpid())
+	if err := a.Serve(ln); err != nil {
+		a.Kill()
+		return failProcess("agent stopped: " + err.Error())
+	}
+	return 0
+}
+
+// Why(中文): 只删除 socket 文件；所在目录仅在是本命令自动创建的临时目录时才删除。
+// Why(English): Remove the socket file, and its directory only when it is a temp dir this command created.
+func cleanupSocket(sock string) {
+	_ = os.Remove(sock)
+	dir := filepath.Dir(sock)
+	if filepath.Dir(dir) == filepath.Clean(os.TempDir()) && strings.HasPrefix(filepath.Base(dir), agentDirPrefix) {
+		_ = os.Remove(dir)
+	}
+}
+
+// Why(中文): 助记词只在本进程内完成 PBKDF2 与硬化派生，socket 上传输的是账户级密钥，agent 永远见不到助记词。
+// Why(English): PBKDF2 and the hardened steps run in this process; only the account key crosses the socket, so the agent never sees the mnemonic.
+func runAgentAdd(args []string, getenv func(string) string) int {
+	fs := flag.NewFlagSet("txlock agent add", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	mnemonicEnv := fs.String("mnemonic-env", "", "")
+	ttl := fs.Duration("ttl", 0, "")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() != 0 {
+		return failUsage("unexpected argument: " + fs.Arg(0))
+	}
+	if *ttl < 0 {
+		return failUsage("-ttl must not be negative")
+	}
+	sock := getenv(agent.SockEnv)
+	if sock == "" {
+		return failUsage(agent.SockEnv + " is not set")
+	}
+	var raw string
+	if *mnemonicEnv != "" {
+		if raw = getenv(*mnemonicEnv); raw == "" {
+			return failUsage("mnemonic env is empty: " + *mnemonicEnv)
+		}
+	} else {
+		typed, err := promptSecret("Mnemonic", false)
+		if err != nil {
+			return failUsage("mnemonic prompt failed: " + err.Error())
+		}
+		raw = string(typed)
+		for i := range typed {
+			typed[i] = 0
+		}
+	}
+	mnemonicCanonical, ok := canonicalizeMnemonic(raw)
+	if !ok {
+		return failProcess("invalid mnemonic")
+	}
+	account, err := derive.DeriveAccountKey(mnemonicCanonical)
+	if err != nil {
+		return failProcess("derive key failed")
+	}
+	defer func() {
+		for i := range account {
+			account[i] = 0
+		}
+	}()
+	id, err := agent.Client{Path: sock}.Add(account, *ttl)
+	if err != nil {
+		return failProcess("agent add failed: " + err.Error())
+	}
+	fmt.Fprintln(os.Stdout, "added "+id)
+	return 0
+}
+
+// Why(中文): list/lock/kill 都是无参数的单次请求，共用同一段错误处理。
+// Why(English): list, lock and kill are argument-free single requests sharing one error path.
+func runAgentClient(op string, getenv func(string) string) int {
+	sock := getenv(agent.SockEnv)
+	if sock == "" {
+		return failUsage(agent.SockEnv + " is not set")
+	}
+	c := agent.Client{Path: sock}
+	var err error
+	switch op {
+	case "list":
+		var keys []agent.KeyInfo
+		if keys, err = c.List(); err == nil {
+			for _, k := range keys {
+				expires := "never"
+				if !k.Expires.IsZero() {
+					expires = k.Expires.UTC().Format(time.RFC3339)
+				}
+				fmt.Fprintf(os.Stdout, "%s added=%s expires=%s\n", k.ID, k.Added.UTC().Format(time.RFC3339), expires)
+			}
+		}
+	case "lock":
+		err = c.Lock()
+	case "kill":
+		err = c.Kill()
+	}
+	if err != nil {
+		return failProcess("agent " + op + " failed: " + err.Error())
+	}
+	return 0
+}
+
+// Why(中文): 与 enc/dec 使用同一助记词归一化语义，agent 中的账户与直接传助记词派生结果一致。
+// Why(English): Share the enc/dec mnemonic normalization so the agent's account matches direct mnemonic derivation.
+func canonicalizeMnemonic(raw string) (string, bool) {
+	parts := strings.Fields(raw)
+	for i := range parts {
+		parts[i] = strings.ToLower(parts[i])
+	}
+	out := strings.Join(parts, " ")
+	return out, out != ""
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"TXLOCK/internal/bench"
+)
+
+// Why(中文): 与 go test -bench 共用 internal/bench 的操作表，部署前可在目标机器上直接得到同口径的数字；-check 把预算越线变成退回码 2，便于在 CI 或验机脚本中使用。
+// Why(English): Shares internal/bench's op table with go test -bench so the target machine yields numbers on the same basis before deployment; -check turns budget misses into exit code 2 for CI or hardware-qualification scripts.
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("txlock bench", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	sizesFlag := fs.String("sizes", "", "")
+	opsFlag := fs.String("ops", "", "")
+	check := fs.Bool("check", false, "")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() != 0 {
+		return failUsage("unexpected argument: " + fs.Arg(0))
+	}
+	sizes := bench.DefaultSizes
+	switch *sizesFlag {
+	case "":
+	case "all":
+		sizes = bench.AllSizes
+	default:
+		sizes = nil
+		for _, s := range strings.Split(*sizesFlag, ",") {
+			n, err := bench.ParseSize(s)
+			if err != nil {
+				return failUsage("invalid -sizes entry: " + s + " (e.g. 1KB, 64KB, 1MB, 1GB; max 1GB)")
+			}
+			sizes = append(sizes, n)
+		}
+	}
+	var names []string
+	if *opsFlag != "" {
+		names = strings.Split(*opsFlag, ",")
+	}
+	ops, err := bench.SelectOps(names)
+	if err != nil {
+		return failUsage(err.Error())
+	}
+	fmt.Fprintf(os.Stdout, "%-18s %6s %14s %10s %12s %10s\n", "op", "size", "ns/op", "MB/s", "B/op", "allocs/op")
+	results, err := bench.Run(ops, sizes, func(r bench.Result) {
+		mbps := "-"
+		if r.Size > 0 {
+			mbps = fmt.Sprintf("%.1f", r.MBPerSec)
+		}
+		fmt.Fprintf(os.Stdout, "%-18s %6s %14d %10s %12d %10d\n", r.Op, bench.FormatSize(r.Size), r.NsPerOp, mbps, r.BytesPerOp, r.AllocsPerOp)
+	})
+	if err != nil {
+		return failProcess("bench failed: " + err.Error())
+	}
+	if !*check {
+		return 0
+	}
+	misses := bench.Check(results)
+	for _, m := range misses {
+		_, _ = io.WriteString(os.Stderr, "txlock: "+m+"\n")
+	}
+	if len(misses) > 0 {
+		return failProcess(fmt.Sprintf("%d of %d measurements missed their budget", len(misses), len(results)))
+	}
+	fmt.Fprintf(os.Stdout, "ok: %d measurements within budget\n", len(results))
+	return 0
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Why(中文): 单项测量应输出表头与一行结果并返回 0；尺寸、操作名或多余参数写错属于用法错误，返回 1 且不开始测量。
+// Why(English): A single measurement prints the header and one row and returns 0; a bad size, op name or stray argument is a usage error that returns 1 before any measuring starts.
+func TestBenchRunsAndRejectsBadFlags(t *testing.T) {
+	none := func(string) string { return "" }
+	code, out := captureStdout(t, func() int { return run([]string{"bench", "-ops", "derive-sk-cached", "-check"}, none) })
+	if code != 0 {
+		t.Fatalf("expected 0, got %d: %s", code, out)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "op ") || !strings.HasPrefix(lines[1], "derive-sk-cached ") || !strings.HasPrefix(lines[2], "ok: 1 ") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	for _, args := range [][]string{
+		{"bench", "-sizes", "2GB"},
+		{"bench", "-sizes", "1KB,"},
+		{"bench", "-ops", "seal"},
+		{"bench", "extra"},
+	} {
+		if code := run(args, none); code != 1 {
+			t.Fatalf("%v: expected 1, got %d", args, code)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"TXLOCK/internal/ethsig"
+	"TXLOCK/internal/lockcore"
+)
+
+// Why(中文): 转换只搬运头字段与密文字节，不需要任何密钥；默认方向取输入的相反形态，-to 与输入同形态时原样输出。
+// Why(English): Conversion only moves header fields and ciphertext bytes and needs no key; the default direction is the opposite of the input, and a -to matching the input copies it through.
+func runConvert(args []string) int {
+	fs := flag.NewFlagSet("txlock convert", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	inPath := fs.String("in", "-", "")
+	outPath := fs.String("out", "-", "")
+	to := fs.String("to", "", "")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() != 0 {
+		return failUsage("unexpected argument: " + fs.Arg(0))
+	}
+	if *to != "" && *to != "binary" && *to != "markdown" {
+		return failUsage("invalid -to: " + *to + " (binary|markdown)")
+	}
+	raw, err := readInput(*inPath)
+	if err != nil {
+		return failProcess("read input failed")
+	}
+	isBinary := lockcore.IsBinaryEnvelope(raw)
+	if !isBinary {
+		if _, _, signed := ethsig.SplitEmbedded(string(raw)); signed {
+			return failProcess("input carries an embedded signature; converting would invalidate it (re-sign after converting)")
+		}
+	}
+	var out []byte
+	ok := true
+	switch {
+	case isBinary && *to != "binary":
+		var text string
+		text, ok = lockcore.BinaryToMarkdown(raw)
+		out = []byte(text)
+	case !isBinary && *to != "markdown":
+		out, ok = lockcore.MarkdownToBinary(string(raw))
+	case isBinary:
+		_, _, _, ok = lockcore.ParseBinaryEnvelope(raw)
+		out = raw
+	default:
+		_, ok = lockcore.MarkdownToBinary(string(raw))
+		out = raw
+	}
+	if !ok {
+		return failProcess("input is not a canonical txlock envelope (field-level files and hand-edited layouts cannot be converted)")
+	}
+	if err := writeOutput(*outPath, out); err != nil {
+		return failProcess("write output failed")
+	}
+	return 0
+}
+
+// Why(中文): 与 enc/dec 相同，"-" 表示 stdout。
+// Why(English): As in enc/dec, "-" means stdout.
+func writeOutput(path string, data []byte) error {
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"TXLOCK/internal/ethsig"
+	"TXLOCK/internal/lockcore"
+)
+
+// Why(中文): 默认方向取输入的相反形态，Markdown→二进制→Markdown 必须逐字节还原；带内嵌签名或非信封输入属于处理错误，非法 -to 属于用法错误。
+// Why(English): The default direction flips the input form and Markdown→binary→Markdown must restore every byte; signed or non-envelope input is a processing error and a bad -to a usage error.
+func TestConvertRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	sk := bytes.Repeat([]byte{0x42}, 32)
+	sealed, err := lockcore.SealV2(sk, "m/44'/60'/0'/0/777", []byte("convert me"), lockcore.SealOptionsV2{Commit: true}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	text := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	signer, _ := ethsig.AddressOf(sk)
+	sig, _ := ethsig.Sign(sk, []byte(text))
+	md := filepath.Join(dir, "a.lock")
+	bin := filepath.Join(dir, "a.bin")
+	back := filepath.Join(dir, "b.lock")
+	signed := filepath.Join(dir, "s.lock")
+	junk := filepath.Join(dir, "j.lock")
+	for path, data := range map[string]string{md: text, signed: text + ethsig.BuildBlock(signer, sig), junk: "hello\n"} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	none := func(string) string { return "" }
+	if code := run([]string{"convert", "-in", md, "-out", bin}, none); code != 0 {
+		t.Fatalf("to binary: expected 0, got %d", code)
+	}
+	if code := run([]string{"convert", "-in", bin, "-out", back}, none); code != 0 {
+		t.Fatalf("to markdown: expected 0, got %d", code)
+	}
+	rawBin, _ := os.ReadFile(bin)
+	rawBack, _ := os.ReadFile(back)
+	if !lockcore.IsBinaryEnvelope(rawBin) || len(rawBin) >= len(text) || string(rawBack) != text {
+		t.Fatalf("round trip mismatch: binary=%d text=%d", len(rawBin), len(text))
+	}
+	cases := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"convert", "-in", bin, "-out", filepath.Join(dir, "c.bin"), "-to", "binary"}, 0},
+		{[]string{"convert", "-in", md, "-to", "xml"}, 1},
+		{[]string{"convert", "-in", signed, "-out", filepath.Join(dir, "d.bin")}, 2},
+		{[]string{"convert", "-in", junk, "-out", filepath.Join(dir, "e.bin")}, 2},
+	}
+	for _, c := range cases {
+		if code := run(c.args, none); code != c.want {
+			t.Fatalf("%v: expected %d, got %d", c.args, c.want, code)
+		}
+	}
+}
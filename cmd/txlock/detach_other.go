@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "syscall"
+
+// Why(中文): 非 unix 平台没有会话概念，按默认方式启动。
+// Why(English): Non-unix platforms have no sessions, so start with defaults.
+func detachAttr() *syscall.SysProcAttr {
+	return nil
+}
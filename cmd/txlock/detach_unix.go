@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// Why(中文): 新建会话让后台 agent 脱离终端，关闭启动它的 shell 不会把它一起带走。
+// Why(English): A new session detaches the background agent from the terminal so closing the launching shell does not take it down.
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv))
+}
+
+// Why(中文): txlock 作为多子命令入口承载不属于单次加解密的功能，txlock-enc/txlock-dec 的参数契约保持不变。
+// Why(English): txlock is the multi-subcommand entry for features beyond a single enc/dec run, leaving the txlock-enc/txlock-dec contracts untouched.
+func run(args []string, getenv func(string) string) int {
+	if len(args) == 0 {
+		printUsage()
+		return 1
+	}
+	switch args[0] {
+	case "agent":
+		return runAgent(args[1:], getenv)
+	case "verify":
+		return runVerify(args[1:])
+	case "convert":
+		return runConvert(args[1:])
+	case "paper":
+		return runPaper(args[1:])
+	case "paper-restore":
+		return runPaperRestore(args[1:])
+	case "recovery-kit":
+		return runRecoveryKit(args[1:])
+	case "vectors":
+		return runVectors(args[1:])
+	case "bench":
+		return runBench(args[1:])
+	case "vault":
+		return runVault(args[1:], getenv)
+	case "manifest":
+		return runManifest(args[1:], getenv)
+	case "-h", "-help", "--help", "help":
+		printUsage()
+		return 0
+	}
+	return failUsage("unknown command: " + args[0])
+}
+
+// Why(中文): 与 enc/dec 相同，用法错误打印 stderr 诊断并返回 1。
+// Why(English): As in enc/dec, usage errors print a stderr diagnostic and return 1.
+func failUsage(msg string) int {
+	_, _ = io.WriteString(os.Stderr, "txlock: "+msg+"\n")
+	return 1
+}
+
+// Why(中文): 处理层失败返回 2，与 enc/dec 的退出码语义一致。
+// Why(English): Processing failures return 2, matching the enc/dec exit-code semantics.
+func failProcess(msg string) int {
+	_, _ = io.WriteString(os.Stderr, "txlock: "+msg+"\n")
+	return 2
+}
+
+// Why(中文): 列出全部子命令，新增子命令时在此补充一行。
+// Why(English): List every subcommand; adding one means adding a line here.
+func printUsage() {
+	fmt.Fprintln(os.Stdout, "Usage: txlock <command> [flags]")
+	fmt.Fprintln(os.Stdout, "Commands:")
+	fmt.Fprintln(os.Stdout, "  agent [-sock PATH] [-ttl DUR] [-idle DUR] [-foreground]   启动密钥 agent，输出 TXLOCK_AGENT_SOCK 供 eval")
+	fmt.Fprintln(os.Stdout, "  agent add [-mnemonic-env ENV] [-ttl DUR]                  派生账户级密钥并加入 agent（未给 ENV 时终端无回显输入）")
+	fmt.Fprintln(os.Stdout, "  agent list                                                列出 agent 中的账户标识与到期时间")
+	fmt.Fprintln(os.Stdout, "  agent lock                                                立即清空 agent 中的全部密钥")
+	fmt.Fprintln(os.Stdout, "  agent kill                                                清空密钥并结束 agent")
+	fmt.Fprintln(os.Stdout, "  verify -signer 0xADDR [-in PATH|-] [-sig PATH]            校验 EIP-191 签名（内嵌或 -sig 独立签名）是否来自该地址")
+	fmt.Fprintln(os.Stdout, "  convert [-in PATH|-] [-out PATH|-] [-to binary|markdown]   在二进制紧凑容器与 Markdown 信封之间无损转换（无需密钥）")
+	fmt.Fprintln(os.Stdout, "  paper [-format text|qr|ascii] [-out PATH] [-chunk N] FILE.lock  导出纸质备份：带行校验的打印页、编号二维码 PNG 或终端二维码")
+	fmt.Fprintln(os.Stdout, "  paper-restore [-out PATH|-] [INPUT...]                    由扫描到的二维码载荷或手抄页还原 envelope，并指出出错的行/份")
+	fmt.Fprintln(os.Stdout, "  recovery-kit [-out DIR]                                   生成离线恢复包：规范、现场计算的向量、纯标准库参考解密器与校验和")
+	fmt.Fprintln(os.Stdout, "  vectors generate|check [-dir DIR]                         生成或校验 JSON 一致性向量套件（默认 testdata/vectors）")
+	fmt.Fprintln(os.Stdout, "  bench [-sizes 1KB,1MB,...|all] [-ops OP,...] [-check]     测量封装/解封/信封/base64/派生的吞吐与分配，-check 对照性能预算")
+	fmt.Fprintln(os.Stdout, "  vault init -dir DIR [-mnemonic-env ENV] [-index N]        初始化 vault：条目名称只存于加密清单，每个条目独立子密钥")
+	fmt.Fprintln(os.Stdout, "  vault add|get|rm -dir DIR [-in PATH|-] [-out PATH|-] NAME 加入（读 -in）、取出（写 -out）或删除条目；密钥参数同 init")
+	fmt.Fprintln(os.Stdout, "  vault ls -dir DIR [-filter TEXT]                          解锁后列出条目大小与名称，-filter 为不区分大小写的子串")
+	fmt.Fprintln(os.Stdout, "  vault mv -dir DIR OLD NEW                                 重命名条目（只改清单，不重新加密）")
+	fmt.Fprintln(os.Stdout, "  manifest build -dir DIR [-file PATH] [-min-seq N]         为目录下全部 .lock 写出带 Merkle 根与 MAC 的清单，已有清单先验证再递增序号")
+	fmt.Fprintln(os.Stdout, "  manifest check -dir DIR [-file PATH] [-min-seq N]         对照清单报告缺失、多出、被替换与回滚的文件；密钥参数同 vault")
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"TXLOCK/internal/agent"
+)
+
+func fixtureMnemonic() string {
+	return "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+}
+
+// Why(中文): 前台模式在 goroutine 中运行真实的 serveAgent，add/list/lock/kill 全部经 socket 往返，kill 后 socket 被清理。
+// Why(English): Run the real foreground serveAgent in a goroutine and drive add/list/lock/kill over the socket; after kill the socket is gone.
+func TestAgentSubcommandsRoundTrip(t *testing.T) {
+	dir, err := os.MkdirTemp("", "txa")
+	if err != nil {
+		t.Fatalf("mkdir temp: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "agent.sock")
+	served := make(chan int, 1)
+	devnull, _ := os.Open(os.DevNull)
+	defer devnull.Close()
+	go func() { served <- serveAgent(sock, time.Hour, time.Minute, devnull) }()
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(sock); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	getenv := func(k string) string {
+		if k == agent.SockEnv {
+			return sock
+		}
+		return fixtureMnemonic()
+	}
+	if code := run([]string{"agent", "add", "-mnemonic-env", "MNEM", "-ttl", "10m"}, getenv); code != 0 {
+		t.Fatalf("add: expected 0, got %d", code)
+	}
+	keys, err := agent.Client{Path: sock}.List()
+	if err != nil || len(keys) != 1 || keys[0].Expires.Sub(keys[0].Added) != 10*time.Minute {
+		t.Fatalf("unexpected keys after add: %#v err=%v", keys, err)
+	}
+	orig := promptSecret
+	defer func() { promptSecret = orig }()
+	promptSecret = func(string, bool) ([]byte, error) { return []byte("  " + fixtureMnemonic() + "\n"), nil }
+	if code := run([]string{"agent", "add"}, getenv); code != 0 {
+		t.Fatalf("prompted add: expected 0, got %d", code)
+	}
+	for _, args := range [][]string{{"agent", "list"}, {"agent", "lock"}} {
+		if code := run(args, getenv); code != 0 {
+			t.Fatalf("%v: expected 0, got %d", args, code)
+		}
+	}
+	if keys, _ := (agent.Client{Path: sock}).List(); len(keys) != 0 {
+		t.Fatalf("lock must clear keys, got %d", len(keys))
+	}
+	if code := run([]string{"agent", "kill"}, getenv); code != 0 {
+		t.Fatalf("kill: expected 0, got %d", code)
+	}
+	if code := <-served; code != 0 {
+		t.Fatalf("serveAgent: expected 0, got %d", code)
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Fatalf("socket must be removed after kill, err=%v", err)
+	}
+}
+
+// Why(中文): 缺少 socket 环境变量、多余参数、未知命令都属于用法错误；agent 不可达属于处理错误。
+// Why(English): A missing socket variable, extra arguments and unknown commands are usage errors; an unreachable agent is a processing error.
+func TestAgentUsageErrors(t *testing.T) {
+	none := func(string) string { return "" }
+	cases := []struct {
+		args []string
+		env  func(string) string
+		want int
+	}{
+		{[]string{}, none, 1},
+		{[]string{"frobnicate"}, none, 1},
+		{[]string{"agent", "list"}, none, 1},
+		{[]string{"agent", "list", "extra"}, none, 1},
+		{[]string{"agent", "add", "-mnemonic-env", "MNEM"}, none, 1},
+		{[]string{"agent", "-ttl", "-1s", "-foreground"}, none, 1},
+		{[]string{"agent", "lock"}, func(string) string { return filepath.Join(t.TempDir(), "missing.sock") }, 2},
+	}
+	for _, tc := range cases {
+		if code := run(tc.args, tc.env); code != tc.want {
+			t.Fatalf("%v: expected %d, got %d", tc.args, tc.want, code)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"TXLOCK/internal/manifest"
+)
+
+// Why(中文): 清单的 MAC 密钥与 vault 一样来自 -mnemonic-env 或 agent 加索引；build 读到已有清单时先验证再递增序号，check 逐项对照目录，任何发现都以退出码 2 报告。
+// Why(English): The manifest MAC key comes from -mnemonic-env or the agent plus an index, as for vault; build verifies an existing manifest before bumping its sequence, and check compares the tree entry by entry, reporting any finding with exit code 2.
+func runManifest(args []string, getenv func(string) string) int {
+	if len(args) == 0 || (args[0] != "build" && args[0] != "check") {
+		return failUsage("manifest needs build or check")
+	}
+	mode := args[0]
+	fs := flag.NewFlagSet("txlock manifest "+mode, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("dir", "", "")
+	file := fs.String("file", "", "")
+	mnemonicEnv := fs.String("mnemonic-env", "", "")
+	index := fs.String("index", "777", "")
+	minSeq := fs.String("min-seq", "0", "")
+	if err := fs.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return 0
+		}
+		return 1
+	}
+	if *dir == "" {
+		return failUsage("manifest " + mode + " needs -dir")
+	}
+	if fs.NArg() != 0 {
+		return failUsage("unexpected argument: " + fs.Arg(0))
+	}
+	floor, err := strconv.ParseUint(*minSeq, 10, 64)
+	if err != nil {
+		return failUsage("invalid -min-seq: " + *minSeq)
+	}
+	if _, ok := buildPathFromIndex(*index); !ok {
+		return failUsage("invalid -index: " + *index)
+	}
+	if *file == "" {
+		*file = filepath.Join(*dir, manifest.DefaultFile)
+	}
+	deriveSK, msg := resolveKeySource(*mnemonicEnv, getenv)
+	if msg != "" {
+		return failUsage(msg)
+	}
+	if deriveSK == nil {
+		return failProcess("invalid mnemonic")
+	}
+	sk, err := deriveSK(*index)
+	if err != nil {
+		return failProcess("derive key failed: " + err.Error())
+	}
+	key := manifest.Key(sk)
+	wipeBytes(sk)
+	defer wipeBytes(key)
+	raw, err := os.ReadFile(*file)
+	if mode == "build" && errors.Is(err, os.ErrNotExist) {
+		return buildManifest(*dir, *file, key, nil)
+	}
+	if err != nil {
+		return failProcess("read manifest failed: " + err.Error())
+	}
+	m, err := manifest.Parse(raw, key)
+	if err != nil {
+		return failProcess(*file + ": " + err.Error())
+	}
+	if mode == "build" {
+		if m.Seq < floor {
+			return failProcess(*file + ": " + manifest.ErrStale.Error())
+		}
+		return buildManifest(*dir, *file, key, m)
+	}
+	findings, err := manifest.Check(*dir, m, floor)
+	if err != nil {
+		return failProcess(err.Error())
+	}
+	for _, f := range findings {
+		fmt.Fprintf(os.Stdout, "%-12s %s\n", f.Kind, f.Path)
+	}
+	if len(findings) > 0 {
+		return failProcess(fmt.Sprintf("manifest check found %d problem(s)", len(findings)))
+	}
+	fmt.Fprintf(os.Stdout, "ok: %d files, seq %d, root %s\n", len(m.Entries), m.Seq, m.Root)
+	return 0
+}
+
+// Why(中文): 写出后打印序号与根，便于把二者记在目录之外；下次 check 用 -min-seq 防止整份清单被回滚。
+// Why(English): Print the seq and root after writing so both can be recorded outside the tree; the next check uses -min-seq to stop the whole manifest being rolled back.
+func buildManifest(dir string, file string, key []byte, prev *manifest.Manifest) int {
+	m, err := manifest.Build(dir, key, prev)
+	if err != nil {
+		return failProcess("build manifest failed: " + err.Error())
+	}
+	raw, err := m.Marshal()
+	if err != nil {
+		return failProcess("build manifest failed: " + err.Error())
+	}
+	if err := writeOutput(file, raw); err != nil {
+		return failProcess("write manifest failed")
+	}
+	fmt.Fprintf(os.Stdout, "seq %d, %d files, root %s\n", m.Seq, len(m.Entries), m.Root)
+	return 0
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Why(中文): 经 CLI 走完 build→check→改动→check→rebuild：发现问题退出 2 并逐行列出；错误索引、回滚的序号与被改的清单都退出 2，缺少 -dir 或未知模式为用法错误。
+// Why(English): Drive build→check→tamper→check→rebuild through the CLI: findings exit 2 and are listed line by line; a wrong index, a rolled-back seq and an edited manifest all exit 2, while a missing -dir or unknown mode is a usage error.
+func TestManifestSubcommands(t *testing.T) {
+	dir := t.TempDir()
+	getenv := func(k string) string {
+		if k == "M" {
+			return fixtureMnemonic()
+		}
+		return ""
+	}
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	write("a.lock", "A1")
+	write("b.lock", "B1")
+	manifestRun := func(args ...string) (int, string) {
+		return captureStdout(t, func() int { return run(append([]string{"manifest"}, args...), getenv) })
+	}
+	if code, out := manifestRun("build", "-dir", dir, "-mnemonic-env", "M"); code != 0 || !strings.HasPrefix(out, "seq 1, 2 files, root ") {
+		t.Fatalf("build: %d %q", code, out)
+	}
+	if code, out := manifestRun("check", "-dir", dir, "-mnemonic-env", "M", "-min-seq", "1"); code != 0 || !strings.HasPrefix(out, "ok: 2 files, seq 1") {
+		t.Fatalf("clean check: %d %q", code, out)
+	}
+	write("a.lock", "A2")
+	if code, _ := manifestRun("build", "-dir", dir, "-mnemonic-env", "M"); code != 0 {
+		t.Fatalf("rebuild: %d", code)
+	}
+	write("a.lock", "A1")
+	write("c.lock", "C1")
+	if err := os.Remove(filepath.Join(dir, "b.lock")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	code, out := manifestRun("check", "-dir", dir, "-mnemonic-env", "M")
+	if code != 2 || out != "rolled-back  a.lock\nmissing      b.lock\nextra        c.lock\n" {
+		t.Fatalf("tampered check: %d %q", code, out)
+	}
+	if code, _ := manifestRun("check", "-dir", dir, "-mnemonic-env", "M", "-index", "778"); code != 2 {
+		t.Fatalf("wrong index: expected 2, got %d", code)
+	}
+	if code, _ := manifestRun("check", "-dir", dir, "-mnemonic-env", "M", "-min-seq", "3"); code != 2 {
+		t.Fatalf("stale manifest: expected 2, got %d", code)
+	}
+	file := filepath.Join(dir, "txlock-manifest.json")
+	raw, _ := os.ReadFile(file)
+	if err := os.WriteFile(file, []byte(strings.Replace(string(raw), `"seq": 2,`, `"seq": 9,`, 1)), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if code, _ := manifestRun("build", "-dir", dir, "-mnemonic-env", "M"); code != 2 {
+		t.Fatalf("build over an edited manifest: expected 2, got %d", code)
+	}
+	for _, args := range [][]string{
+		{"check", "-mnemonic-env", "M"},
+		{"verify", "-dir", dir, "-mnemonic-env", "M"},
+		{"check", "-dir", dir, "-mnemonic-env", "M", "-min-seq", "x"},
+		{"check", "-dir", dir, "-mnemonic-env", "M", "extra"},
+	} {
+		if code, _ := manifestRun(args...); code != 1 {
+			t.Fatalf("%v: expected 1, got %d", args, code)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"TXLOCK/internal/paper"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Why(中文): 纸质导出只读取 envelope 文本，不需要密钥；text 为可手抄的带行校验打印页，qr 写出编号 PNG，ascii 直接在终端显示二维码。
+// Why(English): Paper export reads only the envelope text and needs no key; text is a retypeable sheet with line checksums, qr writes numbered PNGs and ascii draws the codes in the terminal.
+func runPaper(args []string) int {
+	fs := flag.NewFlagSet("txlock paper", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("format", "text", "")
+	outPath := fs.String("out", "", "")
+	chunk := fs.Int("chunk", 600, "")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() != 1 {
+		return failUsage("paper needs exactly one FILE.lock")
+	}
+	if *format != "text" && *format != "qr" && *format != "ascii" {
+		return failUsage("invalid -format: " + *format + " (text|qr|ascii)")
+	}
+	if *chunk < 128 || *chunk > 2000 {
+		return failUsage("invalid -chunk: must be between 128 and 2000 bytes")
+	}
+	inPath := fs.Arg(0)
+	raw, err := readInput(inPath)
+	if err != nil {
+		return failProcess("read input failed")
+	}
+	if err := paper.CheckEnvelope(raw); err != nil {
+		return failProcess(err.Error() + " (binary containers: run txlock convert -to markdown first)")
+	}
+	switch *format {
+	case "text":
+		if *outPath == "" {
+			*outPath = "-"
+		}
+		if err := writeOutput(*outPath, []byte(paper.Sheet(raw))); err != nil {
+			return failProcess("write output failed")
+		}
+	case "ascii":
+		payloads := paper.QRPayloads(raw, *chunk)
+		var b strings.Builder
+		for i, p := range payloads {
+			q, err := qrcode.New(p, qrcode.Medium)
+			if err != nil {
+				return failProcess("qr encode failed: " + err.Error())
+			}
+			fmt.Fprintf(&b, "== %s part %d/%d ==\n%s", filepath.Base(inPath), i+1, len(payloads), q.ToSmallString(false))
+		}
+		if err := writeOutput(orStdout(*outPath), []byte(b.String())); err != nil {
+			return failProcess("write output failed")
+		}
+	case "qr":
+		dir := *outPath
+		if dir == "" {
+			dir = inPath + ".paper"
+		}
+		if err := writeQRDir(dir, paper.QRPayloads(raw, *chunk)); err != nil {
+			return failProcess("write qr codes failed: " + err.Error())
+		}
+		fmt.Fprintln(os.Stdout, dir)
+	}
+	return 0
+}
+
+// Why(中文): 未指定 -out 时终端类输出写到 stdout。
+// Why(English): Terminal-style output goes to stdout when -out is not given.
+func orStdout(path string) string {
+	if path == "" {
+		return "-"
+	}
+	return path
+}
+
+// Why(中文): 文件名带“序号-of-总数”，打印后即使散页也能按名排序；拒绝写入非空目录，避免与旧备份的编号混在一起。
+// Why(English): File names carry "index-of-total" so loose printouts sort by name; a non-empty directory is refused so parts never mix with an older backup's numbering.
+func writeQRDir(dir string, payloads []string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) > 0 {
+		if err == nil {
+			err = fmt.Errorf("%s is not empty", dir)
+		}
+		return err
+	}
+	for i, p := range payloads {
+		q, err := qrcode.New(p, qrcode.Medium)
+		if err != nil {
+			return err
+		}
+		name := filepath.Join(dir, fmt.Sprintf("part-%02d-of-%02d.png", i+1, len(payloads)))
+		if err := q.WriteFile(-6, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Why(中文): 输入可以是多份扫描结果或手抄页，按给定顺序拼接后交给 paper.Restore；诊断逐条写 stderr，定位到具体行或份后返回 2。
+// Why(English): Inputs may be several scan results or retyped sheets, concatenated in order for paper.Restore; diagnostics go line by line to stderr, naming the exact line or part, and the exit code is 2.
+func runPaperRestore(args []string) int {
+	fs := flag.NewFlagSet("txlock paper-restore", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	outPath := fs.String("out", "-", "")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return 0
+		}
+		return 1
+	}
+	inputs := fs.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+	var b strings.Builder
+	for _, in := range inputs {
+		raw, err := readInput(in)
+		if err != nil {
+			return failProcess("read input failed: " + in)
+		}
+		b.Write(raw)
+		b.WriteString("\n")
+	}
+	envelope, issues, err := paper.Restore(b.String())
+	for _, issue := range issues {
+		_, _ = io.WriteString(os.Stderr, "txlock: "+issue+"\n")
+	}
+	if err != nil {
+		return failProcess(err.Error())
+	}
+	if err := writeOutput(*outPath, envelope); err != nil {
+		return failProcess("write output failed")
+	}
+	return 0
+}
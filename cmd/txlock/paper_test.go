@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"TXLOCK/internal/lockcore"
+)
+
+// Why(中文): 打印页导出后可原样还原；二维码目录写出可解码的编号 PNG；二进制容器、非法格式与抄错的页按各自退出码拒绝。
+// Why(English): An exported sheet restores byte for byte, the QR directory holds decodable numbered PNGs, and binary containers, bad formats and mistyped sheets fail with their own exit codes.
+func TestPaperExportAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	sealed, err := lockcore.SealV2(bytes.Repeat([]byte{0x42}, 32), "m/44'/60'/0'/0/777", bytes.Repeat([]byte("paper "), 300), lockcore.SealOptionsV2{}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	text := lockcore.BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	bin, _ := lockcore.MarkdownToBinary(text)
+	lock := filepath.Join(dir, "a.lock")
+	binLock := filepath.Join(dir, "b.lock")
+	sheet := filepath.Join(dir, "a.txt")
+	restored := filepath.Join(dir, "r.lock")
+	_ = os.WriteFile(lock, []byte(text), 0o644)
+	_ = os.WriteFile(binLock, bin, 0o644)
+	none := func(string) string { return "" }
+	if code := run([]string{"paper", "-out", sheet, lock}, none); code != 0 {
+		t.Fatalf("paper text: expected 0, got %d", code)
+	}
+	if code := run([]string{"paper-restore", "-out", restored, sheet}, none); code != 0 {
+		t.Fatalf("paper-restore: expected 0, got %d", code)
+	}
+	if got, _ := os.ReadFile(restored); string(got) != text {
+		t.Fatalf("restored envelope differs")
+	}
+	qrDir := filepath.Join(dir, "qr")
+	if code := run([]string{"paper", "-format", "qr", "-chunk", "400", "-out", qrDir, lock}, none); code != 0 {
+		t.Fatalf("paper qr: expected 0, got %d", code)
+	}
+	entries, _ := os.ReadDir(qrDir)
+	if len(entries) < 2 || !strings.HasSuffix(entries[0].Name(), ".png") {
+		t.Fatalf("expected several PNG parts, got %d", len(entries))
+	}
+	f, _ := os.Open(filepath.Join(qrDir, entries[0].Name()))
+	defer f.Close()
+	if _, err := png.Decode(f); err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	raw, _ := os.ReadFile(sheet)
+	typo := strings.Replace(string(raw), "txlock:v2", "txlock:v9", 1)
+	_ = os.WriteFile(sheet, []byte(typo), 0o644)
+	cases := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"paper", "-format", "svg", lock}, 1},
+		{[]string{"paper"}, 1},
+		{[]string{"paper", binLock}, 2},
+		{[]string{"paper", "-format", "qr", "-out", qrDir, lock}, 2},
+		{[]string{"paper-restore", "-out", restored, sheet}, 2},
+	}
+	for _, c := range cases {
+		if code := run(c.args, none); code != c.want {
+			t.Fatalf("%v: expected %d, got %d", c.args, c.want, code)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"TXLOCK/internal/recoverykit"
+)
+
+// Why(中文): 恢复包不含任何密钥，随时可以重新生成；目录非空时拒绝写入，保证校验和与内容一一对应。
+// Why(English): A recovery kit holds no key material and can be regenerated at any time; a non-empty directory is refused so checksums always match the contents.
+func runRecoveryKit(args []string) int {
+	fs := flag.NewFlagSet("txlock recovery-kit", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	outDir := fs.String("out", "txlock-recovery-kit", "")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() != 0 {
+		return failUsage("unexpected argument: " + fs.Arg(0))
+	}
+	if err := recoverykit.Write(*outDir); err != nil {
+		return failProcess("write recovery kit failed: " + err.Error())
+	}
+	fmt.Fprintln(os.Stdout, *outDir)
+	return 0
+}
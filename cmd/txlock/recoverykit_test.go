@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Why(中文): 子命令写出四个文件；再次写入同一目录按处理错误拒绝，多余参数属于用法错误。
+// Why(English): The subcommand writes the four files; writing the same directory again is a processing error and a stray argument a usage error.
+func TestRecoveryKitWritesDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "kit")
+	none := func(string) string { return "" }
+	if code := run([]string{"recovery-kit", "-out", dir}, none); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	for _, name := range []string{"SPEC.md", "VECTORS.md", "txlock-recover.go", "SHA256SUMS"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("missing %s: %v", name, err)
+		}
+	}
+	if code := run([]string{"recovery-kit", "-out", dir}, none); code != 2 {
+		t.Fatalf("expected 2 for non-empty dir, got %d", code)
+	}
+	if code := run([]string{"recovery-kit", "extra"}, none); code != 1 {
+		t.Fatalf("expected 1 for stray argument, got %d", code)
+	}
+}
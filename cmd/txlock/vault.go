@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"TXLOCK/internal/agent"
+	"TXLOCK/internal/derive"
+	"TXLOCK/internal/vault"
+)
+
+// Why(中文): 每个子命令的位置参数个数固定，解析前集中登记，写错个数直接按用法错误处理。
+// Why(English): Each subcommand takes a fixed number of positional names, registered here so a wrong count is a usage error before anything runs.
+var vaultArgCounts = map[string]int{"init": 0, "add": 1, "get": 1, "ls": 0, "rm": 1, "mv": 2}
+
+// Why(中文): vault 复用 enc/dec 的密钥来源（-mnemonic-env 或 agent）与索引规则，同一助记词与索引即可恢复整个 vault。
+// Why(English): vault reuses the enc/dec key sources (-mnemonic-env or the agent) and index rule, so the same mnemonic and index recover the whole vault.
+func runVault(args []string, getenv func(string) string) int {
+	if len(args) == 0 {
+		return failUsage("vault needs init, add, get, ls, rm or mv")
+	}
+	mode := args[0]
+	want, ok := vaultArgCounts[mode]
+	if !ok {
+		return failUsage("unknown vault mode: " + mode)
+	}
+	fs := flag.NewFlagSet("txlock vault "+mode, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("dir", "", "")
+	mnemonicEnv := fs.String("mnemonic-env", "", "")
+	index := fs.String("index", "777", "")
+	inPath := fs.String("in", "-", "")
+	outPath := fs.String("out", "-", "")
+	filter := fs.String("filter", "", "")
+	if err := fs.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return 0
+		}
+		return 1
+	}
+	if *dir == "" {
+		return failUsage("vault " + mode + " needs -dir")
+	}
+	if fs.NArg() != want {
+		return failUsage(fmt.Sprintf("vault %s takes %d name argument(s), got %d", mode, want, fs.NArg()))
+	}
+	path, ok := buildPathFromIndex(*index)
+	if !ok {
+		return failUsage("invalid -index: " + *index)
+	}
+	deriveSK, msg := resolveKeySource(*mnemonicEnv, getenv)
+	if msg != "" {
+		return failUsage(msg)
+	}
+	if deriveSK == nil {
+		return failProcess("invalid mnemonic")
+	}
+	sk, err := deriveSK(*index)
+	if err != nil {
+		return failProcess("derive key failed: " + err.Error())
+	}
+	var v *vault.Vault
+	if mode == "init" {
+		v, err = vault.Init(*dir, sk, path, rand.Reader)
+	} else {
+		v, err = vault.Open(*dir, sk, path, rand.Reader)
+	}
+	wipeBytes(sk)
+	if err != nil {
+		return failProcess(err.Error())
+	}
+	defer v.Close()
+	return runVaultMode(v, mode, fs.Args(), *inPath, *outPath, *filter, *dir)
+}
+
+// Why(中文): 解锁之后的分派与输出集中在一处；名称相关错误（重名、不存在、非法名称）与 I/O 失败一样返回 2。
+// Why(English): Dispatch and output after unlocking live in one place; name errors (taken, missing, invalid) return 2 like I/O failures.
+func runVaultMode(v *vault.Vault, mode string, names []string, inPath string, outPath string, filter string, dir string) int {
+	var err error
+	switch mode {
+	case "init":
+		fmt.Fprintln(os.Stdout, dir)
+		return 0
+	case "add":
+		data, rerr := readInput(inPath)
+		if rerr != nil {
+			return failProcess("read input failed")
+		}
+		err = v.Add(names[0], data)
+		wipeBytes(data)
+	case "get":
+		data, gerr := v.Get(names[0])
+		if gerr != nil {
+			return failProcess(gerr.Error())
+		}
+		err = writeOutput(outPath, data)
+		wipeBytes(data)
+		if err != nil {
+			return failProcess("write output failed")
+		}
+	case "ls":
+		for _, e := range v.List(filter) {
+			fmt.Fprintf(os.Stdout, "%10d  %s\n", e.Size, e.Name)
+		}
+	case "rm":
+		err = v.Remove(names[0])
+	case "mv":
+		err = v.Move(names[0], names[1])
+	}
+	if err != nil {
+		return failProcess(err.Error())
+	}
+	return 0
+}
+
+// Why(中文): 与 enc/dec 相同：未给 -mnemonic-env 时回退到 TXLOCK_AGENT_SOCK 指向的 agent；返回 nil 且无消息表示助记词无效。
+// Why(English): As in enc/dec: without -mnemonic-env fall back to the agent at TXLOCK_AGENT_SOCK; nil with no message means an invalid mnemonic.
+func resolveKeySource(mnemonicEnv string, getenv func(string) string) (func(string) ([]byte, error), string) {
+	if mnemonicEnv == "" {
+		sock := getenv(agent.SockEnv)
+		if sock == "" {
+			return nil, "-mnemonic-env is required"
+		}
+		return func(index string) ([]byte, error) { return agent.Client{Path: sock}.Key("", index) }, ""
+	}
+	rawMnemonic := getenv(mnemonicEnv)
+	if rawMnemonic == "" {
+		return nil, "mnemonic env is empty: " + mnemonicEnv
+	}
+	mnemonicCanonical, ok := canonicalizeMnemonic(rawMnemonic)
+	if !ok {
+		return nil, ""
+	}
+	return func(index string) ([]byte, error) { return derive.DeriveSK(mnemonicCanonical, index) }, ""
+}
+
+// Why(中文): 与 enc/dec 相同的索引契约与路径前缀，vault 的 AAD 路径因此与普通信封一致。
+// Why(English): The same index contract and path prefix as enc/dec, so the vault's AAD path matches ordinary envelopes.
+func buildPathFromIndex(index string) (string, bool) {
+	if index == "" || (len(index) > 1 && index[0] == '0') {
+		return "", false
+	}
+	for i := 0; i < len(index); i++ {
+		if index[i] < '0' || index[i] > '9' {
+			return "", false
+		}
+	}
+	n, err := strconv.ParseInt(index, 10, 64)
+	if err != nil || n > 2147483647 {
+		return "", false
+	}
+	return "m/44'/60'/0'/0/" + index, true
+}
+
+// Why(中文): sk 与明文在写出后立即清零，缩短在进程内存中的停留时间。
+// Why(English): Zero sk and plaintext right after use to shorten their time in process memory.
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Why(中文): 子命令直接写 os.Stdout，测试临时换成管道读取全部输出。
+// Why(English): Subcommands write straight to os.Stdout, so tests swap in a pipe and read everything back.
+func captureStdout(t *testing.T, fn func() int) (int, string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	code := fn()
+	os.Stdout = saved
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	return code, string(out)
+}
+
+// Why(中文): 经 CLI 走完 init/add/get/ls/mv/rm；名称操作失败返回 2，缺少 -dir、名称个数不对或未知模式属于用法错误返回 1。
+// Why(English): Drive init/add/get/ls/mv/rm through the CLI; failed name operations return 2, while a missing -dir, a wrong name count or an unknown mode are usage errors returning 1.
+func TestVaultSubcommands(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "vault")
+	getenv := func(k string) string {
+		if k == "M" {
+			return fixtureMnemonic()
+		}
+		return ""
+	}
+	vault := func(args ...string) int {
+		return run(append([]string{"vault", args[0], "-dir", dir, "-mnemonic-env", "M"}, args[1:]...), getenv)
+	}
+	in := filepath.Join(tmp, "in.txt")
+	if err := os.WriteFile(in, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if code, _ := captureStdout(t, func() int { return vault("init") }); code != 0 {
+		t.Fatalf("init: expected 0, got %d", code)
+	}
+	if code := vault("add", "-in", in, "wifi/home"); code != 0 {
+		t.Fatalf("add: expected 0, got %d", code)
+	}
+	if code := vault("add", "-in", in, "wifi/home"); code != 2 {
+		t.Fatalf("duplicate add: expected 2, got %d", code)
+	}
+	if code := vault("mv", "wifi/home", "WiFi Office"); code != 0 {
+		t.Fatalf("mv: expected 0, got %d", code)
+	}
+	code, out := captureStdout(t, func() int { return vault("ls", "-filter", "office") })
+	if code != 0 || out != "         7  WiFi Office\n" {
+		t.Fatalf("ls: %d %q", code, out)
+	}
+	got := filepath.Join(tmp, "out.txt")
+	if code := vault("get", "-out", got, "WiFi Office"); code != 0 {
+		t.Fatalf("get: expected 0, got %d", code)
+	}
+	if data, _ := os.ReadFile(got); string(data) != "s3cret\n" {
+		t.Fatalf("get content: %q", data)
+	}
+	if code := run([]string{"vault", "ls", "-dir", dir, "-mnemonic-env", "M", "-index", "778"}, getenv); code != 2 {
+		t.Fatalf("wrong index: expected 2, got %d", code)
+	}
+	if code := vault("rm", "WiFi Office"); code != 0 {
+		t.Fatalf("rm: expected 0, got %d", code)
+	}
+	if code := vault("get", "WiFi Office"); code != 2 {
+		t.Fatalf("get removed: expected 2, got %d", code)
+	}
+	for _, args := range [][]string{
+		{"vault"},
+		{"vault", "list", "-dir", dir},
+		{"vault", "ls", "-mnemonic-env", "M"},
+		{"vault", "mv", "-dir", dir, "-mnemonic-env", "M", "only-one"},
+		{"vault", "ls", "-dir", dir, "-mnemonic-env", "M", "-index", "01"},
+	} {
+		if code := run(args, getenv); code != 1 {
+			t.Fatalf("%v: expected 1, got %d", args, code)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"TXLOCK/internal/vectors"
+)
+
+// Why(中文): generate 由维护者在协议改动后运行并提交结果；check 供独立实现或 CI 验证一份套件，失败逐条列到 stderr 后返回 2。
+// Why(English): generate is run by maintainers after a protocol change and the output committed; check lets independent implementations or CI validate a suite, listing each failure on stderr and returning 2.
+func runVectors(args []string) int {
+	if len(args) == 0 {
+		return failUsage("vectors needs generate or check")
+	}
+	mode := args[0]
+	if mode != "generate" && mode != "check" {
+		return failUsage("unknown vectors mode: " + mode)
+	}
+	fs := flag.NewFlagSet("txlock vectors "+mode, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("dir", "testdata/vectors", "")
+	if err := fs.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			printUsage()
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() != 0 {
+		return failUsage("unexpected argument: " + fs.Arg(0))
+	}
+	if mode == "generate" {
+		if err := vectors.Write(*dir); err != nil {
+			return failProcess("generate vectors failed: " + err.Error())
+		}
+		fmt.Fprintln(os.Stdout, *dir)
+		return 0
+	}
+	n, failures, err := vectors.Check(*dir)
+	if err != nil {
+		return failProcess("check vectors failed: " + err.Error())
+	}
+	for _, f := range failures {
+		_, _ = io.WriteString(os.Stderr, "txlock: "+f+"\n")
+	}
+	if len(failures) > 0 {
+		return failProcess(fmt.Sprintf("%d of %d vector cases failed", len(failures), n))
+	}
+	fmt.Fprintf(os.Stdout, "ok: %d cases\n", n)
+	return 0
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Why(中文): generate 写出的目录 check 必须通过；篡改后 check 返回 2，缺少模式或未知模式属于用法错误。
+// Why(English): A directory written by generate must pass check; after tampering check returns 2, and a missing or unknown mode is a usage error.
+func TestVectorsGenerateAndCheck(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "vectors")
+	none := func(string) string { return "" }
+	if code := run([]string{"vectors", "generate", "-dir", dir}, none); code != 0 {
+		t.Fatalf("generate: expected 0, got %d", code)
+	}
+	if code := run([]string{"vectors", "check", "-dir", dir}, none); code != 0 {
+		t.Fatalf("check: expected 0, got %d", code)
+	}
+	seal := filepath.Join(dir, "seal.json")
+	data, err := os.ReadFile(seal)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if err := os.WriteFile(seal, []byte(strings.Replace(string(data), `hello txlock`, `hello txlocK`, 1)), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if code := run([]string{"vectors", "check", "-dir", dir}, none); code != 2 {
+		t.Fatalf("tampered check: expected 2, got %d", code)
+	}
+	if code := run([]string{"vectors"}, none); code != 1 {
+		t.Fatalf("missing mode: expected 1, got %d", code)
+	}
+	if code := run([]string{"vectors", "verify"}, none); code != 1 {
+		t.Fatalf("unknown mode: expected 1, got %d", code)
+	}
+}
+
+// Why(中文): 默认目录即仓库内提交的套件；在仓库根执行 check 必须通过。
+// Why(English): The default directory is the suite committed in the repository; check run from the repository root must pass.
+func TestVectorsCheckCommittedSuite(t *testing.T) {
+	if code := run([]string{"vectors", "check", "-dir", "../../testdata/vectors"}, func(string) string { return "" }); code != 0 {
+		t.Fatalf("expected committed suite to pass, got %d", code)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"TXLOCK/internal/ethsig"
+)
+
+// Why(中文): 内嵌与独立签名都能针对正确地址通过；换地址、改内容属于处理错误，缺少或写错 -signer 属于用法错误。
+// Why(English): Embedded and detached signatures pass for the right address; a different address or altered content is a processing error, a missing or malformed -signer a usage error.
+func TestVerifyEmbeddedAndDetached(t *testing.T) {
+	dir := t.TempDir()
+	sk := bytes.Repeat([]byte{0x42}, 32)
+	signer, _ := ethsig.AddressOf(sk)
+	other, _ := ethsig.AddressOf(bytes.Repeat([]byte{0x43}, 32))
+	envelope := "<!--\ntxlock:v1\nct_b64:\nAAAA\n-->\n"
+	sig, err := ethsig.Sign(sk, []byte(envelope))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	embedded := filepath.Join(dir, "a.lock")
+	detached := filepath.Join(dir, "b.lock")
+	tampered := filepath.Join(dir, "c.lock")
+	for path, data := range map[string]string{
+		embedded:          envelope + ethsig.BuildBlock(signer, sig),
+		detached:          envelope,
+		detached + ".sig": ethsig.BuildBlock(signer, sig),
+		tampered:          envelope + "\n",
+	} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	none := func(string) string { return "" }
+	cases := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"verify", "-in", embedded, "-signer", signer.String()}, 0},
+		{[]string{"verify", "-in", detached, "-sig", detached + ".sig", "-signer", signer.String()}, 0},
+		{[]string{"verify", "-in", embedded, "-signer", other.String()}, 2},
+		{[]string{"verify", "-in", tampered, "-sig", detached + ".sig", "-signer", signer.String()}, 2},
+		{[]string{"verify", "-in", detached, "-signer", signer.String()}, 2},
+		{[]string{"verify", "-in", embedded}, 1},
+		{[]string{"verify", "-in", embedded, "-signer", "0x1234"}, 1},
+	}
+	for _, tc := range cases {
+		if code := run(tc.args, none); code != tc.want {
+			t.Fatalf("%v: expected %d, got %d", tc.args, tc.want, code)
+		}
+	}
+}
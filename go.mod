@@ -3,9 +3,14 @@ module TXLOCK
 go 1.25.7
 
 require (
-	github.com/btcsuite/btcd/btcec/v2 v2.3.4 // indirect
-	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.3.0 // indirect
-	github.com/vcvvvc/go-wallet-sdk/crypto v0.1.0 // indirect
-	golang.org/x/crypto v0.36.0 // indirect
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	github.com/vcvvvc/go-wallet-sdk/crypto v0.1.0
+	golang.org/x/crypto v0.36.0
+	golang.org/x/term v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/kr/pretty v0.3.1 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 )
@@ -11,6 +11,7 @@ build_and_install() {
   mkdir -p "$BIN_DIR"
   go build -o "$BIN_DIR/txlock-enc" ./cmd/txlock-enc
   go build -o "$BIN_DIR/txlock-dec" ./cmd/txlock-dec
+  go build -o "$BIN_DIR/txlock" ./cmd/txlock
 
   if [ ! -d "$INSTALL_DIR" ]; then
     sudo mkdir -p "$INSTALL_DIR"
@@ -18,8 +19,9 @@ build_and_install() {
 
   sudo install -m 0755 "$BIN_DIR/txlock-enc" "$INSTALL_DIR/txlock-enc"
   sudo install -m 0755 "$BIN_DIR/txlock-dec" "$INSTALL_DIR/txlock-dec"
+  sudo install -m 0755 "$BIN_DIR/txlock" "$INSTALL_DIR/txlock"
 }
 
 cd "$ROOT_DIR"
 build_and_install
-echo "Installed: $INSTALL_DIR/txlock-enc $INSTALL_DIR/txlock-dec"
+echo "Installed: $INSTALL_DIR/txlock-enc $INSTALL_DIR/txlock-dec $INSTALL_DIR/txlock"
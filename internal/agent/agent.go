@@ -0,0 +1,297 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"TXLOCK/internal/derive"
+)
+
+// Why(中文): 与 ssh-agent 的 SSH_AUTH_SOCK 同理，CLI 只通过这个环境变量发现 agent，不需要额外参数。
+// Why(English): Like ssh-agent's SSH_AUTH_SOCK, the CLIs discover the agent through this one variable with no extra flags.
+const SockEnv = "TXLOCK_AGENT_SOCK"
+
+var (
+	ErrNoKey      = errors.New("agent has no key loaded")
+	ErrAmbiguous  = errors.New("agent holds several keys; pass an id")
+	ErrUnknownKey = errors.New("agent has no such key")
+	ErrProtocol   = errors.New("agent protocol error")
+	ErrSocketDir  = errors.New("socket directory must be a 0700 directory owned by the caller")
+)
+
+// Why(中文): 错误在 socket 上以文本传输，客户端按此表还原为哨兵错误，调用方可以用 == 判断。
+// Why(English): Errors cross the socket as text and the client maps them back through this table so callers can compare with ==.
+var wireErrors = map[string]error{
+	ErrNoKey.Error():               ErrNoKey,
+	ErrAmbiguous.Error():           ErrAmbiguous,
+	ErrUnknownKey.Error():          ErrUnknownKey,
+	derive.ErrInvalidIndex.Error(): derive.ErrInvalidIndex,
+}
+
+type request struct {
+	Op      string `json:"op"`
+	Account []byte `json:"account,omitempty"`
+	TTL     int64  `json:"ttl,omitempty"`
+	ID      string `json:"id,omitempty"`
+	Index   string `json:"index,omitempty"`
+}
+
+type response struct {
+	Error string    `json:"error,omitempty"`
+	ID    string    `json:"id,omitempty"`
+	Keys  []KeyInfo `json:"keys,omitempty"`
+	SK    []byte    `json:"sk,omitempty"`
+}
+
+type KeyInfo struct {
+	ID      string    `json:"id"`
+	Added   time.Time `json:"added"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+type entry struct {
+	account  []byte
+	deriver  *derive.Deriver
+	added    time.Time
+	expires  time.Time
+	lastUsed time.Time
+}
+
+// Why(中文): agent 只保存账户级密钥（锁定内存、到期即清零），按请求派生单个索引的 SK，助记词与 seed 从不进入 agent。
+// Why(English): The agent keeps only account-level keys (locked memory, wiped on expiry) and derives one index's SK per request; mnemonic and seed never reach it.
+type Agent struct {
+	mu   sync.Mutex
+	keys map[string]*entry
+	ttl  time.Duration
+	idle time.Duration
+	now  func() time.Time
+	done chan struct{}
+	once sync.Once
+}
+
+// Why(中文): ttl 为每个密钥的最长存活时间，idle 为最长空闲时间；任一为 0 表示不启用该限制。
+// Why(English): ttl caps each key's lifetime and idle caps time since last use; zero disables either limit.
+func New(ttl time.Duration, idle time.Duration) *Agent {
+	return &Agent{keys: map[string]*entry{}, ttl: ttl, idle: idle, now: time.Now, done: make(chan struct{})}
+}
+
+// Why(中文): 账户密钥复制进锁定内存后由 agent 独占，调用方可立即清零自己的副本；ttl 为 0 时使用 agent 默认值。
+// Why(English): The account key is copied into locked memory owned by the agent so callers can wipe their copy; a zero ttl uses the agent default.
+func (a *Agent) Add(account []byte, ttl time.Duration) (string, error) {
+	id, ok := derive.AccountID(account)
+	if !ok {
+		return "", derive.ErrDerivation
+	}
+	if ttl == 0 {
+		ttl = a.ttl
+	}
+	buf := make([]byte, len(account))
+	lockMemory(buf)
+	copy(buf, account)
+	d, err := derive.NewDeriverFromAccount(buf)
+	if err != nil {
+		for i := range buf {
+			buf[i] = 0
+		}
+		unlockMemory(buf)
+		return "", err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	e := &entry{account: buf, deriver: d, added: now, lastUsed: now}
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+	if old, ok := a.keys[id]; ok {
+		wipeEntry(old)
+	}
+	a.keys[id] = e
+	return id, nil
+}
+
+// Why(中文): 列表只返回标识与时间，不含任何密钥材料；顺序按标识排序以便输出稳定。
+// Why(English): Listing returns only ids and timestamps, never key material, sorted by id for stable output.
+func (a *Agent) List() []KeyInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireLocked()
+	out := make([]KeyInfo, 0, len(a.keys))
+	for id, e := range a.keys {
+		out = append(out, KeyInfo{ID: id, Added: e.added, Expires: e.expires})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Why(中文): 只有一个密钥时 id 可省略；多个密钥时必须指明，避免用错账户加密。
+// Why(English): The id may be omitted when exactly one key is loaded; with several it is required so the wrong account is never used.
+func (a *Agent) Key(id string, index string) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireLocked()
+	var e *entry
+	switch {
+	case id != "":
+		e = a.keys[id]
+		if e == nil {
+			return nil, ErrUnknownKey
+		}
+	case len(a.keys) == 0:
+		return nil, ErrNoKey
+	case len(a.keys) > 1:
+		return nil, ErrAmbiguous
+	default:
+		for _, only := range a.keys {
+			e = only
+		}
+	}
+	sk, err := e.deriver.DeriveSK(index)
+	if err != nil {
+		return nil, err
+	}
+	e.lastUsed = a.now()
+	return sk, nil
+}
+
+// Why(中文): lock 立即清零并丢弃全部密钥，agent 继续运行，之后可重新 add。
+// Why(English): lock wipes and drops every key at once while the agent keeps running for a later add.
+func (a *Agent) Lock() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, e := range a.keys {
+		wipeEntry(e)
+		delete(a.keys, id)
+	}
+}
+
+// Why(中文): 先清零密钥再通知 Serve 退出，保证进程结束前内存中不留账户密钥。
+// Why(English): Wipe keys before signalling Serve to stop so no account key survives until process exit.
+func (a *Agent) Kill() {
+	a.Lock()
+	a.once.Do(func() { close(a.done) })
+}
+
+// Why(中文): 到期检查在每次请求时惰性执行，并由 Serve 的定时器兜底，空闲的 agent 也会按时清除密钥。
+// Why(English): Expiry runs lazily on each request and from Serve's ticker, so an idle agent still drops keys on time.
+func (a *Agent) expireLocked() {
+	now := a.now()
+	for id, e := range a.keys {
+		if (!e.expires.IsZero() && !now.Before(e.expires)) || (a.idle > 0 && now.Sub(e.lastUsed) >= a.idle) {
+			wipeEntry(e)
+			delete(a.keys, id)
+		}
+	}
+}
+
+// Why(中文): Deriver 持有的正是锁定内存里的账户缓冲，Close 清零后再解除内存锁定，避免明文密钥在解锁后被换出到磁盘。
+// Why(English): The Deriver holds the very account buffer in locked memory; Close zeroes it before unlocking so key bytes are never swapped to disk after the lock is released.
+func wipeEntry(e *entry) {
+	e.deriver.Close()
+	unlockMemory(e.account)
+}
+
+// Why(中文): socket 在 Listen 与 Chmod 之间按 umask 权限存在，只有所在目录为调用者独占的 0700 目录时，其他本地用户才无法在这段窗口内连接；已有同名文件时拒绝覆盖，防止劫持他人的 agent 路径。
+// Why(English): Between Listen and Chmod the socket exists with umask permissions, so it is only safe inside a 0700 directory owned by the caller, which keeps other local users from connecting in that window; an existing path is refused so another agent's socket is never hijacked.
+func Listen(path string) (net.Listener, error) {
+	if err := CheckSocketDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return nil, os.ErrExist
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Why(中文): 每个连接只处理一个请求/响应，协议无状态；收到 kill 或 Kill 被调用后关闭监听并返回。
+// Why(English): Each connection carries one request and one response so the protocol stays stateless; kill closes the listener and returns.
+func (a *Agent) Serve(ln net.Listener) error {
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	go func() {
+		for {
+			select {
+			case <-a.done:
+				ln.Close()
+				return
+			case <-tick.C:
+				a.mu.Lock()
+				a.expireLocked()
+				a.mu.Unlock()
+			}
+		}
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-a.done:
+				return nil
+			default:
+				return err
+			}
+		}
+		go a.handle(conn)
+	}
+}
+
+// Why(中文): 请求体有上限且带超时，异常客户端无法占住 agent 或让其分配大量内存。
+// Why(English): Requests are size-capped and time-limited so a misbehaving client cannot stall the agent or make it allocate heavily.
+func (a *Agent) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	var req request
+	if err := json.NewDecoder(ioLimit(conn)).Decode(&req); err != nil {
+		return
+	}
+	resp := a.dispatch(req)
+	for i := range req.Account {
+		req.Account[i] = 0
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+	for i := range resp.SK {
+		resp.SK[i] = 0
+	}
+}
+
+// Why(中文): 操作名与 CLI 子命令一一对应，未知操作按协议错误返回。
+// Why(English): Op names map one-to-one to CLI subcommands; unknown ops are protocol errors.
+func (a *Agent) dispatch(req request) response {
+	switch req.Op {
+	case "add":
+		id, err := a.Add(req.Account, time.Duration(req.TTL)*time.Second)
+		if err != nil {
+			return response{Error: err.Error()}
+		}
+		return response{ID: id}
+	case "list":
+		return response{Keys: a.List()}
+	case "key":
+		sk, err := a.Key(req.ID, req.Index)
+		if err != nil {
+			return response{Error: err.Error()}
+		}
+		return response{SK: sk}
+	case "lock":
+		a.Lock()
+		return response{}
+	case "kill":
+		a.Kill()
+		return response{}
+	}
+	return response{Error: ErrProtocol.Error()}
+}
@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"TXLOCK/internal/derive"
+)
+
+const fixtureMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// Why(中文): 在短路径临时目录下启动 agent，避开 unix socket 路径长度上限；测试结束时 kill 并等待 Serve 返回。
+// Why(English): Start an agent under a short temp dir to stay within the unix socket path limit; the cleanup kills it and waits for Serve.
+func startTestAgent(t *testing.T, a *Agent) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "txa")
+	if err != nil {
+		t.Fatalf("mkdir temp: %v", err)
+	}
+	path := filepath.Join(dir, "agent.sock")
+	ln, err := Listen(path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- a.Serve(ln) }()
+	t.Cleanup(func() {
+		a.Kill()
+		<-served
+		os.RemoveAll(dir)
+	})
+	return path
+}
+
+// Why(中文): -sock 指向的目录若组或其他用户有权限、或是符号链接，Listen 必须拒绝且不创建 socket；收紧为 0700 后才允许监听。
+// Why(English): Listen must refuse, without creating the socket, when the -sock directory grants group or other access or is a symlink; only after tightening it to 0700 may it listen.
+func TestListenRequiresPrivateDirectory(t *testing.T) {
+	dir, err := os.MkdirTemp("", "txa")
+	if err != nil {
+		t.Fatalf("mkdir temp: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	shared := filepath.Join(dir, "shared")
+	if err := os.Mkdir(shared, 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Chmod(shared, 0o755); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	path := filepath.Join(shared, "agent.sock")
+	if _, err := Listen(path); err != ErrSocketDir {
+		t.Fatalf("expected ErrSocketDir for a 0755 directory, got %v", err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Fatalf("refused listen must not create the socket: %v", err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(shared, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	if err := os.Chmod(shared, 0o700); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	if _, err := Listen(filepath.Join(link, "agent.sock")); err != ErrSocketDir {
+		t.Fatalf("expected ErrSocketDir for a symlinked directory, got %v", err)
+	}
+	ln, err := Listen(path)
+	if err != nil {
+		t.Fatalf("listen in a 0700 directory: %v", err)
+	}
+	ln.Close()
+}
+
+// Why(中文): 通过 socket 走完 add/list/key/lock/kill 全流程，并确认 agent 派生的 SK 与直接用助记词派生一致。
+// Why(English): Drive add/list/key/lock/kill over the socket and confirm the agent's SK equals direct mnemonic derivation.
+func TestAgentRoundTripOverSocket(t *testing.T) {
+	path := startTestAgent(t, New(0, 0))
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("socket must be 0600, got %v err=%v", fi.Mode().Perm(), err)
+	}
+	if _, err := Listen(path); err == nil {
+		t.Fatalf("second listen on the same path must fail")
+	}
+	c := Client{Path: path}
+	if _, err := c.Key("", "777"); err != ErrNoKey {
+		t.Fatalf("expected ErrNoKey, got %v", err)
+	}
+	account, err := derive.DeriveAccountKey(fixtureMnemonic)
+	if err != nil {
+		t.Fatalf("derive account: %v", err)
+	}
+	id, err := c.Add(account, 0)
+	if err != nil || id == "" {
+		t.Fatalf("add failed: id=%q err=%v", id, err)
+	}
+	keys, err := c.List()
+	if err != nil || len(keys) != 1 || keys[0].ID != id || !keys[0].Expires.IsZero() {
+		t.Fatalf("unexpected list: %#v err=%v", keys, err)
+	}
+	want, _ := derive.DeriveSK(fixtureMnemonic, "777")
+	got, err := c.Key("", "777")
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("agent sk mismatch: err=%v", err)
+	}
+	if _, err := c.Key("", "0777"); err != derive.ErrInvalidIndex {
+		t.Fatalf("expected ErrInvalidIndex, got %v", err)
+	}
+	if _, err := c.Key("ffffffffffffffff", "777"); err != ErrUnknownKey {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+	if err := c.Lock(); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if keys, _ := c.List(); len(keys) != 0 {
+		t.Fatalf("lock must drop all keys, got %d", len(keys))
+	}
+	if err := c.Kill(); err != nil {
+		t.Fatalf("kill: %v", err)
+	}
+}
+
+// Why(中文): 用可控时钟验证 TTL 与空闲超时，并确认被清除的密钥缓冲已清零。
+// Why(English): Use a controllable clock to check TTL and idle timeouts and that dropped key buffers are zeroed.
+func TestAgentExpiresKeys(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	a := New(time.Hour, 10*time.Minute)
+	a.now = func() time.Time { return now }
+	account, _ := derive.DeriveAccountKey(fixtureMnemonic)
+	id, err := a.Add(account, 0)
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	held := a.keys[id].account
+	now = now.Add(9 * time.Minute)
+	if _, err := a.Key("", "777"); err != nil {
+		t.Fatalf("key within idle window: %v", err)
+	}
+	now = now.Add(9 * time.Minute)
+	if _, err := a.Key(id, "777"); err != nil {
+		t.Fatalf("use must reset idle timer: %v", err)
+	}
+	now = now.Add(10 * time.Minute)
+	if _, err := a.Key(id, "777"); err != ErrUnknownKey {
+		t.Fatalf("expected idle expiry, got %v", err)
+	}
+	if !bytes.Equal(held, make([]byte, len(held))) {
+		t.Fatalf("expired key buffer must be zeroed")
+	}
+	a = New(time.Hour, 0)
+	a.now = func() time.Time { return now }
+	if _, err := a.Add(account, 30*time.Minute); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	other, _ := derive.DeriveAccountKey("legal winner thank year wave sausage worth useful legal winner thank yellow")
+	if _, err := a.Add(other, 0); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := a.Key("", "777"); err != ErrAmbiguous {
+		t.Fatalf("expected ErrAmbiguous, got %v", err)
+	}
+	now = now.Add(30 * time.Minute)
+	if keys := a.List(); len(keys) != 1 {
+		t.Fatalf("per-key ttl must expire first key only, got %d", len(keys))
+	}
+	if _, err := a.Key("", "777"); err != nil {
+		t.Fatalf("remaining key must be used without id: %v", err)
+	}
+}
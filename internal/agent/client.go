@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+// Why(中文): 请求只含账户密钥或索引，16 KiB 足够并能挡住异常大的输入。
+// Why(English): Requests carry at most an account key or an index; 16 KiB is ample and blocks oversized input.
+const maxRequestBytes = 16 << 10
+
+// Why(中文): 服务端解码前包一层上限，单个连接最多读取 maxRequestBytes。
+// Why(English): Wrap the server-side decoder so one connection reads at most maxRequestBytes.
+func ioLimit(r io.Reader) io.Reader {
+	return io.LimitReader(r, maxRequestBytes)
+}
+
+// Why(中文): 客户端只保存 socket 路径，不缓存连接，与 agent 的一次一请求协议对应。
+// Why(English): The client holds only the socket path and caches no connection, matching the agent's one-request-per-connection protocol.
+type Client struct {
+	Path string
+}
+
+// Why(中文): 每次调用独立拨号并设置超时，agent 挂起时 CLI 不会无限等待。
+// Why(English): Each call dials fresh with a deadline so a hung agent never blocks the CLI forever.
+func (c Client) call(req request) (response, error) {
+	conn, err := net.DialTimeout("unix", c.Path, 5*time.Second)
+	if err != nil {
+		return response{}, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return response{}, err
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return response{}, ErrProtocol
+	}
+	if resp.Error != "" {
+		if known, ok := wireErrors[resp.Error]; ok {
+			return response{}, known
+		}
+		return response{}, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// Why(中文): ttl 按秒传输；返回账户标识供 list 对照。
+// Why(English): ttl travels in whole seconds; the returned account id matches list output.
+func (c Client) Add(account []byte, ttl time.Duration) (string, error) {
+	resp, err := c.call(request{Op: "add", Account: account, TTL: int64(ttl / time.Second)})
+	return resp.ID, err
+}
+
+// Why(中文): 只取回标识与时间，供 `txlock agent list` 展示。
+// Why(English): Fetch ids and timestamps only, for `txlock agent list` to print.
+func (c Client) List() ([]KeyInfo, error) {
+	resp, err := c.call(request{Op: "list"})
+	return resp.Keys, err
+}
+
+// Why(中文): 返回单个索引的 32 字节 SK，账户级密钥始终留在 agent 内。
+// Why(English): Returns the 32-byte SK for one index; the account-level key always stays inside the agent.
+func (c Client) Key(id string, index string) ([]byte, error) {
+	resp, err := c.call(request{Op: "key", ID: id, Index: index})
+	if err == nil && len(resp.SK) != 32 {
+		return nil, ErrProtocol
+	}
+	return resp.SK, err
+}
+
+// Why(中文): 让 agent 清空密钥但继续运行，离开工位时使用。
+// Why(English): Ask the agent to drop all keys but keep running, e.g. when stepping away.
+func (c Client) Lock() error {
+	_, err := c.call(request{Op: "lock"})
+	return err
+}
+
+// Why(中文): 清空密钥并结束 agent 进程。
+// Why(English): Drop all keys and stop the agent process.
+func (c Client) Kill() error {
+	_, err := c.call(request{Op: "kill"})
+	return err
+}
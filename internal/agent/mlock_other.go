@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package agent
+
+// Why(中文): 不支持 mlock 的平台仍依赖清零语义，只是无法阻止换出。
+// Why(English): Platforms without mlock still rely on wiping; they just cannot prevent swapping.
+func lockMemory(b []byte) {}
+
+// Why(中文): 与 lockMemory 对称的空实现。
+// Why(English): No-op counterpart of lockMemory.
+func unlockMemory(b []byte) {}
@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package agent
+
+import "syscall"
+
+// Why(中文): 锁定内存防止账户密钥被换出到磁盘；RLIMIT_MEMLOCK 不足时静默降级，不影响功能。
+// Why(English): Locking memory keeps account keys out of swap; when RLIMIT_MEMLOCK is too low it silently degrades without affecting behavior.
+func lockMemory(b []byte) {
+	if len(b) > 0 {
+		_ = syscall.Mlock(b)
+	}
+}
+
+// Why(中文): 密钥清零后才调用，解除锁定不会让明文进入交换区。
+// Why(English): Called only after the key is zeroed, so unlocking never lets plaintext reach swap.
+func unlockMemory(b []byte) {
+	if len(b) > 0 {
+		_ = syscall.Munlock(b)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package agent
+
+import "os"
+
+// Why(中文): 没有 unix 属主与权限位的平台只能确认目录存在，访问控制交给系统 ACL。
+// Why(English): Platforms without unix ownership and permission bits can only confirm the directory exists; access control is left to the system ACLs.
+func CheckSocketDir(dir string) error {
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return ErrSocketDir
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package agent
+
+import (
+	"os"
+	"syscall"
+)
+
+// Why(中文): 用 Lstat 检查目录本身而不是符号链接目标，要求属主为当前用户且组与其他用户没有任何权限位。
+// Why(English): Lstat the directory itself rather than a symlink target and require the caller as owner with no group or other permission bits.
+func CheckSocketDir(dir string) error {
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !fi.IsDir() || fi.Mode().Perm()&0o077 != 0 || !ok || int(st.Uid) != os.Getuid() {
+		return ErrSocketDir
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var (
+	ErrUnsupportedEntry = errors.New("unsupported archive entry")
+	ErrUnsafePath       = errors.New("unsafe archive path")
+)
+
+type Entry struct {
+	Name    string
+	Mode    fs.FileMode
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+}
+
+// Why(中文): 只打包普通文件与目录并清空属主信息，归档内容只保留恢复所需的名称、权限与时间，避免额外元数据外泄。
+// Why(English): Pack only regular files and directories with owner fields cleared so the archive carries just names, modes and mtimes.
+func Pack(dir string, w io.Writer) error {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return ErrUnsupportedEntry
+	}
+	tw := tar.NewWriter(w)
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{
+			Name:    filepath.ToSlash(rel),
+			Mode:    int64(fi.Mode().Perm()),
+			ModTime: fi.ModTime().Truncate(time.Second),
+			Format:  tar.FormatPAX,
+		}
+		switch {
+		case fi.IsDir():
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+			return tw.WriteHeader(hdr)
+		case fi.Mode().IsRegular():
+			hdr.Typeflag = tar.TypeReg
+			hdr.Size = fi.Size()
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		default:
+			return ErrUnsupportedEntry
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Why(中文): 列表与解包共用同一条目校验，保证 -list 显示的内容正是 -extract 会写出的内容。
+// Why(English): Listing and extraction share one entry check so what -list shows is exactly what -extract would write.
+func nextEntry(tr *tar.Reader) (*tar.Header, string, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, "", err
+	}
+	if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeDir {
+		return nil, "", ErrUnsupportedEntry
+	}
+	name := path.Clean(hdr.Name)
+	if hdr.Name == "" || !filepath.IsLocal(filepath.FromSlash(name)) {
+		return nil, "", ErrUnsafePath
+	}
+	return hdr, name, nil
+}
+
+// Why(中文): 列出条目无需落盘，用于在解包前审查归档内容。
+// Why(English): Listing entries touches no disk, so archives can be reviewed before anything is extracted.
+func List(r io.Reader) ([]Entry, error) {
+	tr := tar.NewReader(r)
+	var out []Entry
+	for {
+		hdr, name, err := nextEntry(tr)
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Entry{
+			Name:    name,
+			Mode:    fs.FileMode(hdr.Mode).Perm(),
+			Size:    hdr.Size,
+			ModTime: hdr.ModTime,
+			IsDir:   hdr.Typeflag == tar.TypeDir,
+		})
+	}
+}
+
+// Why(中文): 解包拒绝绝对路径、".." 逃逸与已存在文件，且不跟随目标目录内的符号链接，防止归档写出 dest 之外。目录先以 0700 创建，子项全部写完后再自深向浅还原归档权限与 mtime，只读目录（如 0555）因此也能被非 root 用户解包。
+// Why(English): Extraction rejects absolute paths, ".." escapes and existing files, and never follows symlinks under dest, so nothing lands outside it. Directories are created 0700 and get their archived modes and mtimes deepest-first only after every child is written, so read-only directories such as 0555 extract without root.
+func Extract(r io.Reader, dest string) error {
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return err
+	}
+	root, err := os.OpenRoot(dest)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	tr := tar.NewReader(r)
+	dirMeta := map[string]*tar.Header{}
+	for {
+		hdr, name, err := nextEntry(tr)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		mode := fs.FileMode(hdr.Mode).Perm()
+		local := filepath.FromSlash(name)
+		if dir := filepath.Dir(local); dir != "." {
+			if err := root.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			if err := root.MkdirAll(local, 0o700); err != nil {
+				return err
+			}
+			dirMeta[local] = hdr
+			continue
+		}
+		f, err := root.OpenFile(local, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		if err := root.Chmod(local, mode); err != nil {
+			return err
+		}
+		if err := root.Chtimes(local, hdr.ModTime, hdr.ModTime); err != nil {
+			return err
+		}
+	}
+	dirs := make([]string, 0, len(dirMeta))
+	for d := range dirMeta {
+		dirs = append(dirs, d)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, d := range dirs {
+		hdr := dirMeta[d]
+		if err := root.Chmod(d, fs.FileMode(hdr.Mode).Perm()); err != nil {
+			return err
+		}
+		if err := root.Chtimes(d, hdr.ModTime, hdr.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
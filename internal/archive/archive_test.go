@@ -0,0 +1,124 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Why(中文): 归档往返必须同时还原内容、权限与 mtime，否则 -archive/-extract 不能替代逐文件加密。
+// Why(English): An archive round-trip must restore content, modes and mtimes or -archive/-extract cannot replace per-file locking.
+func TestPackExtractRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("alpha"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(src, "sub", "a.txt"), mtime, mtime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := Pack(src, &buf); err != nil {
+		t.Fatalf("pack: %v", err)
+	}
+	entries, err := List(bytes.NewReader(buf.Bytes()))
+	if err != nil || len(entries) != 2 || entries[0].Name != "sub" || !entries[0].IsDir || entries[1].Name != "sub/a.txt" {
+		t.Fatalf("unexpected entries: %#v err=%v", entries, err)
+	}
+	dst := t.TempDir()
+	if err := Extract(bytes.NewReader(buf.Bytes()), dst); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	out := filepath.Join(dst, "sub", "a.txt")
+	got, err := os.ReadFile(out)
+	if err != nil || string(got) != "alpha" {
+		t.Fatalf("unexpected content: %q err=%v", got, err)
+	}
+	fi, err := os.Stat(out)
+	if err != nil || fi.Mode().Perm() != 0o600 || !fi.ModTime().Equal(mtime) {
+		t.Fatalf("unexpected metadata: mode=%v mtime=%v err=%v", fi.Mode().Perm(), fi.ModTime(), err)
+	}
+}
+
+// Why(中文): 归档中的只读目录（0555）含有文件与子目录时，必须先写完子项再还原目录权限，否则非 root 用户解包时在该目录下创建文件会被拒绝。
+// Why(English): A read-only (0555) directory holding files and subdirectories must get its mode only after its children are written, or a non-root extract is refused when creating files inside it.
+func TestExtractReadOnlyDirectory(t *testing.T) {
+	src := t.TempDir()
+	ro := filepath.Join(src, "ro")
+	if err := os.MkdirAll(filepath.Join(ro, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, body := range map[string]string{"f": "file", "sub/g": "nested"} {
+		if err := os.WriteFile(filepath.Join(ro, filepath.FromSlash(name)), []byte(body), 0o444); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	mtime := time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	for _, d := range []string{filepath.Join(ro, "sub"), ro} {
+		if err := os.Chmod(d, 0o555); err != nil {
+			t.Fatalf("chmod: %v", err)
+		}
+		if err := os.Chtimes(d, mtime, mtime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	dst := t.TempDir()
+	t.Cleanup(func() {
+		for _, base := range []string{src, dst} {
+			_ = os.Chmod(filepath.Join(base, "ro", "sub"), 0o755)
+			_ = os.Chmod(filepath.Join(base, "ro"), 0o755)
+		}
+	})
+	var buf bytes.Buffer
+	if err := Pack(src, &buf); err != nil {
+		t.Fatalf("pack: %v", err)
+	}
+	if err := Extract(bytes.NewReader(buf.Bytes()), dst); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	for name, body := range map[string]string{"ro/f": "file", "ro/sub/g": "nested"} {
+		if got, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name))); err != nil || string(got) != body {
+			t.Fatalf("%s: unexpected content %q err=%v", name, got, err)
+		}
+	}
+	for _, d := range []string{"ro", "ro/sub"} {
+		fi, err := os.Stat(filepath.Join(dst, filepath.FromSlash(d)))
+		if err != nil || fi.Mode().Perm() != 0o555 || !fi.ModTime().Equal(mtime) {
+			t.Fatalf("%s: unexpected metadata: %v", d, err)
+		}
+	}
+}
+
+// Why(中文): 路径穿越是解包最常见的攻击面，".." 与绝对路径条目必须在写盘前被拒绝。
+// Why(English): Path traversal is the classic extraction attack; ".." and absolute entries must be rejected before touching disk.
+func TestExtractRejectsTraversal(t *testing.T) {
+	for _, name := range []string{"../evil", "/etc/evil", "a/../../evil"} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		_ = tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+		_, _ = tw.Write([]byte("x"))
+		_ = tw.Close()
+		dst := t.TempDir()
+		if err := Extract(bytes.NewReader(buf.Bytes()), dst); err != ErrUnsafePath {
+			t.Fatalf("%s: expected ErrUnsafePath, got %v", name, err)
+		}
+	}
+}
+
+// Why(中文): 符号链接条目可能把后续写入引向目录外，归档层直接拒绝。
+// Why(English): Symlink entries could redirect later writes outside dest, so the archive layer rejects them outright.
+func TestExtractRejectsSymlinkEntry(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc"})
+	_ = tw.Close()
+	if err := Extract(bytes.NewReader(buf.Bytes()), t.TempDir()); err != ErrUnsupportedEntry {
+		t.Fatalf("expected ErrUnsupportedEntry, got %v", err)
+	}
+}
@@ -0,0 +1,366 @@
+package bench
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"testing"
+
+	"TXLOCK/internal/derive"
+	"TXLOCK/internal/lockcore"
+)
+
+var (
+	ErrInvalidSize = errors.New("invalid size")
+	ErrUnknownOp   = errors.New("unknown benchmark op")
+)
+
+const (
+	benchMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	benchIndex    = "777"
+	benchPath     = "m/44'/60'/0'/0/777"
+)
+
+// Why(中文): 从 1KB 到 1GB 按 64 倍递进，覆盖配置片段、普通文档与 DefaultMaxPlaintext 上限；默认集合止于 16MB，大尺寸需显式要求。
+// Why(English): Sizes step by 64x from 1KB to 1GB, covering config snippets, ordinary documents and the DefaultMaxPlaintext ceiling; the default set stops at 16MB so large sizes are opt-in.
+var (
+	AllSizes     = []int{1 << 10, 64 << 10, 1 << 20, 16 << 20, 256 << 20, 1 << 30}
+	DefaultSizes = []int{1 << 10, 64 << 10, 1 << 20, 16 << 20}
+)
+
+// Why(中文): setup 在计时外准备输入并返回单次操作与可选的清理函数；同一张表同时驱动 go test -bench 与 txlock bench，两边的数字可以直接对照。
+// Why(English): setup prepares inputs outside the timer and returns one operation plus an optional cleanup; the same table drives both go test -bench and txlock bench so their numbers compare directly.
+type Op struct {
+	Name  string
+	Sized bool
+	setup func(size int) (func() error, func(), error)
+}
+
+type Result struct {
+	Op          string  `json:"op"`
+	Size        int     `json:"size"`
+	N           int     `json:"n"`
+	NsPerOp     int64   `json:"ns_per_op"`
+	MBPerSec    float64 `json:"mb_per_sec"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+}
+
+// Why(中文): 预算是回归护栏而非硬件标称：吞吐下限约为参考机实测的四分之一，分配次数上限只留少量余量，结构性回退（逐行分配、多余拷贝）会直接越线。
+// Why(English): Budgets are regression guards, not hardware ratings: throughput floors sit near a quarter of the reference machine and allocation caps leave little slack, so structural regressions (per-line allocations, extra copies) cross the line.
+type Target struct {
+	Op         string
+	MinMBPerS  float64
+	MaxNsPerOp int64
+	MaxAllocs  int64
+}
+
+var Targets = []Target{
+	{Op: "seal-v1", MinMBPerS: 600, MaxAllocs: 30},
+	{Op: "open-v1", MinMBPerS: 600, MaxAllocs: 30},
+	{Op: "envelope-build-v1", MinMBPerS: 300, MaxAllocs: 4},
+	{Op: "envelope-parse-v1", MinMBPerS: 60, MaxAllocs: 20},
+	{Op: "b64-encode", MinMBPerS: 200, MaxAllocs: 3},
+	{Op: "b64-decode", MinMBPerS: 100, MaxAllocs: 4},
+	{Op: "derive-sk", MaxNsPerOp: 10_000_000, MaxAllocs: 300},
+	{Op: "derive-sk-cached", MaxNsPerOp: 500_000, MaxAllocs: 48},
+}
+
+// Why(中文): 吞吐预算只在 64KB 及以上检查，1KB 时固定开销（HKDF、AES 密钥扩展）占主导，数字不反映流式性能。
+// Why(English): Throughput budgets apply from 64KB up; at 1KB fixed costs (HKDF, AES key schedule) dominate and the number says nothing about streaming speed.
+const minBudgetSize = 64 << 10
+
+// Why(中文): 顺序即输出顺序：先整体加解密，再拆开信封与 base64 两层，最后是与大小无关的派生。
+// Why(English): Order is output order: whole seal/open first, then the envelope and base64 layers, then size-independent derivation.
+var Ops = []Op{
+	{Name: "seal-v1", Sized: true, setup: setupSealV1},
+	{Name: "open-v1", Sized: true, setup: setupOpenV1},
+	{Name: "envelope-build-v1", Sized: true, setup: setupBuildV1},
+	{Name: "envelope-parse-v1", Sized: true, setup: setupParseV1},
+	{Name: "b64-encode", Sized: true, setup: setupB64Encode},
+	{Name: "b64-decode", Sized: true, setup: setupB64Decode},
+	{Name: "derive-sk", setup: setupDeriveSK},
+	{Name: "derive-sk-cached", setup: setupDeriveSKCached},
+}
+
+// Why(中文): 接受 1KB/64KB/1MB/1GB 这类二进制单位（1KB=1024），也接受纯字节数；零与负数拒绝。
+// Why(English): Accept binary units such as 1KB/64KB/1MB/1GB (1KB = 1024) or a plain byte count; zero and negatives are rejected.
+func ParseSize(s string) (int, error) {
+	mult := 1
+	for _, u := range []struct {
+		suffix string
+		mult   int
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, mult = strings.TrimSuffix(s, u.suffix), u.mult
+			break
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 || n > (1<<30)/mult {
+		return 0, ErrInvalidSize
+	}
+	return n * mult, nil
+}
+
+// Why(中文): 输出与表格共用同一种写法，1048576 显示为 1MB，非整单位时退回字节数。
+// Why(English): Output and tables share one spelling: 1048576 prints as 1MB, falling back to bytes when not a whole unit.
+func FormatSize(n int) string {
+	switch {
+	case n == 0:
+		return "-"
+	case n%(1<<30) == 0:
+		return strconv.Itoa(n>>30) + "GB"
+	case n%(1<<20) == 0:
+		return strconv.Itoa(n>>20) + "MB"
+	case n%(1<<10) == 0:
+		return strconv.Itoa(n>>10) + "KB"
+	}
+	return strconv.Itoa(n) + "B"
+}
+
+// Why(中文): 按名称挑选操作，空列表表示全部；未知名称整体报错，避免拼错后静默少测。
+// Why(English): Select ops by name with an empty list meaning all; an unknown name fails outright so a typo never silently skips a measurement.
+func SelectOps(names []string) ([]Op, error) {
+	if len(names) == 0 {
+		return Ops, nil
+	}
+	out := make([]Op, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, op := range Ops {
+			if op.Name == name {
+				out = append(out, op)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownOp, name)
+		}
+	}
+	return out, nil
+}
+
+// Why(中文): 与大小无关的操作只测一次；每项之间强制 GC 并把空闲内存还给系统，1GB 的输入不会叠加到下一项的内存峰值上。
+// Why(English): Size-independent ops run once; a forced GC that returns free memory to the OS between entries keeps a 1GB input from stacking onto the next entry's peak memory.
+func Run(ops []Op, sizes []int, progress func(Result)) ([]Result, error) {
+	var out []Result
+	for _, op := range ops {
+		opSizes := sizes
+		if !op.Sized {
+			opSizes = []int{0}
+		}
+		for _, size := range opSizes {
+			r, err := Measure(op, size)
+			debug.FreeOSMemory()
+			if err != nil {
+				return out, err
+			}
+			out = append(out, r)
+			if progress != nil {
+				progress(r)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Why(中文): 用 testing.Benchmark 复用 go test 的 b.N 自适应与分配统计；操作返回错误视为整项失败，不报告一个测错了东西的数字。
+// Why(English): testing.Benchmark reuses go test's b.N ramp-up and allocation accounting; an op error fails the entry rather than reporting a number for the wrong thing.
+func Measure(op Op, size int) (Result, error) {
+	fn, done, err := op.setup(size)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s %s: %w", op.Name, FormatSize(size), err)
+	}
+	if done != nil {
+		defer done()
+	}
+	var opErr error
+	br := testing.Benchmark(func(b *testing.B) {
+		drive(b, fn, size, &opErr)
+	})
+	if opErr != nil {
+		return Result{}, fmt.Errorf("%s %s: %w", op.Name, FormatSize(size), opErr)
+	}
+	r := Result{
+		Op:          op.Name,
+		Size:        size,
+		N:           br.N,
+		NsPerOp:     br.NsPerOp(),
+		AllocsPerOp: br.AllocsPerOp(),
+		BytesPerOp:  br.AllocedBytesPerOp(),
+	}
+	if size > 0 && br.T > 0 {
+		r.MBPerSec = float64(size) * float64(br.N) / 1e6 / br.T.Seconds()
+	}
+	return r, nil
+}
+
+// Why(中文): go test -bench 与 txlock bench 共用的计时循环，保证 SetBytes、ReportAllocs 与计时起点一致。
+// Why(English): The timing loop shared by go test -bench and txlock bench so SetBytes, ReportAllocs and the timer start match.
+func drive(b *testing.B, fn func() error, size int, opErr *error) {
+	if size > 0 {
+		b.SetBytes(int64(size))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := fn(); err != nil {
+			*opErr = err
+			b.Fatal(err)
+		}
+	}
+}
+
+// Why(中文): 返回每条越线的说明；找不到预算的操作不检查，新增操作时需同时补预算。
+// Why(English): Return one message per budget miss; ops without a target are not checked, so a new op needs its budget added alongside.
+func Check(results []Result) []string {
+	var misses []string
+	for _, r := range results {
+		for _, t := range Targets {
+			if t.Op != r.Op {
+				continue
+			}
+			label := r.Op + " " + FormatSize(r.Size)
+			if t.MinMBPerS > 0 && r.Size >= minBudgetSize && r.MBPerSec < t.MinMBPerS {
+				misses = append(misses, fmt.Sprintf("%s: %.1f MB/s below budget %.0f MB/s", label, r.MBPerSec, t.MinMBPerS))
+			}
+			if t.MaxNsPerOp > 0 && r.NsPerOp > t.MaxNsPerOp {
+				misses = append(misses, fmt.Sprintf("%s: %d ns/op above budget %d ns/op", label, r.NsPerOp, t.MaxNsPerOp))
+			}
+			if t.MaxAllocs > 0 && r.AllocsPerOp > t.MaxAllocs {
+				misses = append(misses, fmt.Sprintf("%s: %d allocs/op above budget %d", label, r.AllocsPerOp, t.MaxAllocs))
+			}
+		}
+	}
+	return misses
+}
+
+// Why(中文): 基准只关心吞吐，固定 SK 省去每项重复 PBKDF2；明文内容不影响 AES-GCM 速度，零值即可。
+// Why(English): Benchmarks care only about throughput, so a fixed SK skips a PBKDF2 per entry; plaintext content does not affect AES-GCM speed, so zeros suffice.
+func fixtureSK() ([]byte, error) {
+	return derive.DeriveSK(benchMnemonic, benchIndex)
+}
+
+// Why(中文): 计时内包含 salt/nonce 的随机读取与 HKDF，反映一次真实封装的完整成本。
+// Why(English): The timed op includes the salt/nonce reads and HKDF, reflecting the full cost of one real seal.
+func setupSealV1(size int) (func() error, func(), error) {
+	sk, err := fixtureSK()
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext := make([]byte, size)
+	return func() error {
+		_, err := lockcore.SealV1(sk, benchPath, plaintext, rand.Reader)
+		return err
+	}, nil, nil
+}
+
+// Why(中文): 预先封装一次，计时只覆盖 OpenV1（规范 base64 校验、HKDF、GCM 认证解密）。
+// Why(English): Seal once up front so the timer covers only OpenV1 (canonical base64 checks, HKDF, GCM authenticated decryption).
+func setupOpenV1(size int) (func() error, func(), error) {
+	sk, err := fixtureSK()
+	if err != nil {
+		return nil, nil, err
+	}
+	sealed, err := lockcore.SealV1(sk, benchPath, make([]byte, size), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return func() error {
+		_, err := lockcore.OpenV1(sk, benchPath, sealed.SaltB64, sealed.NonceB64, sealed.Ciphertext)
+		return err
+	}, nil, nil
+}
+
+// Why(中文): 同一尺寸的密文 base64 预先算好，计时只覆盖 strings.Builder 拼接与 wrapB64Lines76 分行。
+// Why(English): Precompute the ciphertext base64 so the timer covers only the strings.Builder assembly and wrapB64Lines76 wrapping.
+func setupBuildV1(size int) (func() error, func(), error) {
+	sealed, ctB64, err := sealedFixture(size)
+	if err != nil {
+		return nil, nil, err
+	}
+	return func() error {
+		if lockcore.BuildEnvelopeV1("", sealed.SaltB64, sealed.NonceB64, ctB64) == "" {
+			return errors.New("empty envelope")
+		}
+		return nil
+	}, nil, nil
+}
+
+// Why(中文): 解析覆盖边界检查、按行切分、头字段校验与密文区一次性规范解码。
+// Why(English): Parsing covers the boundary check, line splitting, header validation and the one-shot canonical ciphertext decode.
+func setupParseV1(size int) (func() error, func(), error) {
+	sealed, ctB64, err := sealedFixture(size)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw := lockcore.BuildEnvelopeV1("", sealed.SaltB64, sealed.NonceB64, ctB64)
+	return func() error {
+		if _, _, _, _, ok := lockcore.ParseEnvelopeV1(raw); !ok {
+			return errors.New("envelope rejected")
+		}
+		return nil
+	}, nil, nil
+}
+
+// Why(中文): 单独测 RawStdEncoding 编码，便于判断信封构建的开销有多少来自 base64 本身。
+// Why(English): Measure RawStdEncoding alone to tell how much of envelope building is base64 itself.
+func setupB64Encode(size int) (func() error, func(), error) {
+	data := make([]byte, size)
+	return func() error {
+		base64.RawStdEncoding.EncodeToString(data)
+		return nil
+	}, nil, nil
+}
+
+// Why(中文): 解码走 DecodeCanonicalB64，包含重新编码比对，这正是解析路径上的真实成本。
+// Why(English): Decoding goes through DecodeCanonicalB64, re-encode comparison included, which is the real cost on the parse path.
+func setupB64Decode(size int) (func() error, func(), error) {
+	s := base64.RawStdEncoding.EncodeToString(make([]byte, size))
+	return func() error {
+		_, err := lockcore.DecodeCanonicalB64(s)
+		return err
+	}, nil, nil
+}
+
+// Why(中文): 每次都从助记词走完 PBKDF2 与全路径，即 txlock-enc/dec 单次调用的派生成本。
+// Why(English): Each call runs PBKDF2 and the full path from the mnemonic, the derivation cost of one txlock-enc/dec run.
+func setupDeriveSK(int) (func() error, func(), error) {
+	return func() error {
+		_, err := derive.DeriveSK(benchMnemonic, benchIndex)
+		return err
+	}, nil, nil
+}
+
+// Why(中文): Deriver 构造一次后的单索引成本，即 agent 与批量场景的派生成本；测完由清理函数 Close。
+// Why(English): The per-index cost once a Deriver exists, which is what the agent and batch workloads pay; the cleanup closes it afterwards.
+func setupDeriveSKCached(int) (func() error, func(), error) {
+	d, err := derive.NewDeriver(benchMnemonic)
+	if err != nil {
+		return nil, nil, err
+	}
+	return func() error {
+		_, err := d.DeriveSK(benchIndex)
+		return err
+	}, d.Close, nil
+}
+
+// Why(中文): 构建与解析共用同一份密文夹具，两项数字描述的是同一个信封。
+// Why(English): Building and parsing share one ciphertext fixture so both numbers describe the same envelope.
+func sealedFixture(size int) (*lockcore.SealResult, string, error) {
+	sk, err := fixtureSK()
+	if err != nil {
+		return nil, "", err
+	}
+	sealed, err := lockcore.SealV1(sk, benchPath, make([]byte, size), rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	return sealed, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext), nil
+}
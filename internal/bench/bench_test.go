@@ -0,0 +1,114 @@
+package bench
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Why(中文): 每个操作在 1KB 上真实执行一次，确认基准测的是成功路径而不是一个早早失败的调用。
+// Why(English): Run every op once at 1KB to confirm benchmarks measure the success path rather than a call that fails early.
+func TestOpsSucceedOnce(t *testing.T) {
+	for _, op := range Ops {
+		fn, done, err := op.setup(1 << 10)
+		if err != nil {
+			t.Fatalf("%s setup: %v", op.Name, err)
+		}
+		if err := fn(); err != nil {
+			t.Fatalf("%s: %v", op.Name, err)
+		}
+		if done != nil {
+			done()
+		}
+	}
+	for _, target := range Targets {
+		if _, err := SelectOps([]string{target.Op}); err != nil {
+			t.Fatalf("target for unknown op %s", target.Op)
+		}
+	}
+}
+
+// Why(中文): 尺寸写法在命令行与输出之间往返一致，上限为 1GB，拼错的操作名必须报错。
+// Why(English): Size spellings round-trip between flags and output, the cap is 1GB, and a misspelled op name must fail.
+func TestSizesAndSelection(t *testing.T) {
+	for _, size := range AllSizes {
+		got, err := ParseSize(FormatSize(size))
+		if err != nil || got != size {
+			t.Fatalf("round trip %d: %d %v", size, got, err)
+		}
+	}
+	if n, err := ParseSize("1000"); err != nil || n != 1000 || FormatSize(n) != "1000B" {
+		t.Fatalf("plain bytes: %d %v", n, err)
+	}
+	for _, bad := range []string{"", "0KB", "-1MB", "2GB", "1TB", "KB"} {
+		if _, err := ParseSize(bad); err != ErrInvalidSize {
+			t.Fatalf("%q: expected ErrInvalidSize, got %v", bad, err)
+		}
+	}
+	if _, err := SelectOps([]string{"seal-v1", "seal"}); !errors.Is(err, ErrUnknownOp) {
+		t.Fatalf("expected ErrUnknownOp, got %v", err)
+	}
+}
+
+// Why(中文): 预算检查按操作分别判断吞吐、耗时与分配次数；1KB 的吞吐不参与比较。
+// Why(English): Budget checks judge throughput, time and allocations per op; throughput at 1KB is not compared.
+func TestCheckReportsBudgetMisses(t *testing.T) {
+	results := []Result{
+		{Op: "seal-v1", Size: 1 << 10, MBPerSec: 1, AllocsPerOp: 10},
+		{Op: "seal-v1", Size: 1 << 20, MBPerSec: 1, AllocsPerOp: 10},
+		{Op: "b64-encode", Size: 1 << 20, MBPerSec: 1000, AllocsPerOp: 50},
+		{Op: "derive-sk-cached", NsPerOp: 1 << 30, AllocsPerOp: 10},
+		{Op: "open-v1", Size: 1 << 20, MBPerSec: 1000, AllocsPerOp: 10},
+	}
+	misses := Check(results)
+	if len(misses) != 3 {
+		t.Fatalf("expected 3 misses, got %v", misses)
+	}
+	for i, want := range []string{"seal-v1 1MB: ", "b64-encode 1MB: ", "derive-sk-cached -: "} {
+		if !strings.HasPrefix(misses[i], want) {
+			t.Fatalf("miss %d = %q, want prefix %q", i, misses[i], want)
+		}
+	}
+}
+
+// Why(中文): 与 txlock bench 使用同一张操作表；默认只跑到 16MB，设置 TXLOCK_BENCH_MAX=1GB 才覆盖大尺寸，避免随手的 -bench . 占满内存。
+// Why(English): Uses the same op table as txlock bench; by default it stops at 16MB and TXLOCK_BENCH_MAX=1GB opts into large sizes so a casual -bench . does not exhaust memory.
+func BenchmarkOps(b *testing.B) {
+	limit := DefaultSizes[len(DefaultSizes)-1]
+	if v := os.Getenv("TXLOCK_BENCH_MAX"); v != "" {
+		n, err := ParseSize(v)
+		if err != nil {
+			b.Fatalf("TXLOCK_BENCH_MAX: %v", err)
+		}
+		limit = n
+	}
+	for _, op := range Ops {
+		sizes := []int{0}
+		if op.Sized {
+			sizes = nil
+			for _, size := range AllSizes {
+				if size <= limit {
+					sizes = append(sizes, size)
+				}
+			}
+		}
+		for _, size := range sizes {
+			name := op.Name
+			if size > 0 {
+				name += "/" + FormatSize(size)
+			}
+			b.Run(name, func(b *testing.B) {
+				fn, done, err := op.setup(size)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if done != nil {
+					defer done()
+				}
+				var opErr error
+				drive(b, fn, size, &opErr)
+			})
+		}
+	}
+}
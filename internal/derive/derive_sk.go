@@ -1,7 +1,10 @@
 package derive
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"strconv"
 
 	bip32 "github.com/vcvvvc/go-wallet-sdk/crypto/go-bip32"
@@ -14,35 +17,114 @@ var (
 	ErrDerivation      = errors.New("derivation failed")
 )
 
+// Why(中文): 校验和错误是 ErrInvalidMnemonic 的细分，包装后 errors.Is(err, ErrInvalidMnemonic) 照常成立，既有调用方的判断不受影响。
+// Why(English): A checksum failure refines ErrInvalidMnemonic and wraps it, so errors.Is(err, ErrInvalidMnemonic) still holds and existing callers keep matching.
+var ErrMnemonicChecksum = fmt.Errorf("mnemonic checksum mismatch: %w", ErrInvalidMnemonic)
+
+// Why(中文): 账户级密钥为 m/44'/60'/0'/0 节点的 chain code 与私钥（各 32 字节），末级索引为非硬化，可在不接触助记词的情况下继续派生。
+// Why(English): An account key is the chain code and private key (32 bytes each) of m/44'/60'/0'/0; the last index is non-hardened, so children derive without the mnemonic.
+const AccountKeySize = 64
+
 // Why(中文): 先冻结派生入口与错误边界，让后续接入 BIP39/BIP32 时不需要反复改调用方契约。
 // Why(English): Freeze the derivation entrypoint and error boundaries first so BIP39/BIP32 wiring can evolve without call-site churn.
 func DeriveSK(mnemonicCanonical string, index string) ([]byte, error) {
 	if mnemonicCanonical == "" {
 		return nil, ErrInvalidMnemonic
 	}
-	if len(index) == 0 || (len(index) > 1 && index[0] == '0') {
+	if _, ok := parseIndex(index); !ok {
 		return nil, ErrInvalidIndex
 	}
-	n, err := strconv.ParseInt(index, 10, 64)
-	if err != nil || n < 0 || n > 2147483647 {
-		return nil, ErrInvalidIndex
+	account, err := DeriveAccountKey(mnemonicCanonical)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(account)
+	return DeriveSKFromAccount(account, index)
+}
+
+// Why(中文): PBKDF2 与三层硬化派生只在这里做一次，agent 缓存其结果后按索引派生，无需长期持有助记词。词都在词表里但校验和不符单独报 ErrMnemonicChecksum（仍满足 errors.Is(err, ErrInvalidMnemonic)），多半是抄错了一个词，与词数或拼写错误区分开。
+// Why(English): PBKDF2 and the hardened levels run once here; the agent caches the result and derives per index without holding the mnemonic. Known words with a bad checksum get ErrMnemonicChecksum (still errors.Is ErrInvalidMnemonic), usually one miscopied word, apart from wrong word counts or spellings.
+func DeriveAccountKey(mnemonicCanonical string) ([]byte, error) {
+	if mnemonicCanonical == "" {
+		return nil, ErrInvalidMnemonic
 	}
 	seed, err := bip39.NewSeedWithErrorChecking(mnemonicCanonical, "")
+	if err == bip39.ErrChecksumIncorrect {
+		return nil, ErrMnemonicChecksum
+	}
 	if err != nil {
 		return nil, ErrInvalidMnemonic
 	}
+	defer wipe(seed)
 	master, err := bip32.NewMasterKey(seed)
 	if err != nil {
 		return nil, ErrDerivation
 	}
-	child, err := master.NewChildKeyByPathString("m/44'/60'/0'/0/" + index)
-	if err != nil {
+	node, err := master.NewChildKeyByPathString("m/44'/60'/0'/0")
+	if err != nil || len(node.Key) != 32 || len(node.ChainCode) != 32 {
+		return nil, ErrDerivation
+	}
+	account := make([]byte, 0, AccountKeySize)
+	account = append(account, node.ChainCode...)
+	return append(account, node.Key...), nil
+}
+
+// Why(中文): 从账户级密钥做一次非硬化 CKDpriv，结果与 DeriveSK 对同一索引逐字节一致。
+// Why(English): One non-hardened CKDpriv step from the account key, byte-identical to DeriveSK for the same index.
+func DeriveSKFromAccount(account []byte, index string) ([]byte, error) {
+	if len(account) != AccountKeySize {
 		return nil, ErrDerivation
 	}
-	if len(child.Key) != 32 {
+	n, ok := parseIndex(index)
+	if !ok {
+		return nil, ErrInvalidIndex
+	}
+	node := &bip32.Key{
+		Version:     bip32.PrivateWalletVersion,
+		Depth:       4,
+		ChainCode:   account[:32],
+		Key:         account[32:],
+		FingerPrint: make([]byte, 4),
+		ChildNumber: make([]byte, 4),
+		IsPrivate:   true,
+	}
+	child, err := node.NewChildKey(n)
+	if err != nil || len(child.Key) != 32 {
 		return nil, ErrDerivation
 	}
 	sk := make([]byte, 32)
 	copy(sk, child.Key)
 	return sk, nil
 }
+
+// Why(中文): 以账户公钥摘要作为标识，agent 列表可区分多个账户而不暴露任何私密材料。
+// Why(English): Identify an account by a digest of its public key so agent listings distinguish accounts without exposing secret material.
+func AccountID(account []byte) (string, bool) {
+	if len(account) != AccountKeySize {
+		return "", false
+	}
+	node := &bip32.Key{ChainCode: account[:32], Key: account[32:], IsPrivate: true}
+	sum := sha256.Sum256(node.PublicKey().Key)
+	return hex.EncodeToString(sum[:8]), true
+}
+
+// Why(中文): 索引契约为无前导零的十进制非硬化值，DeriveSK 与账户派生共用同一判断。
+// Why(English): The index contract is a non-hardened decimal without leading zeros; DeriveSK and account derivation share this check.
+func parseIndex(index string) (uint32, bool) {
+	if len(index) == 0 || (len(index) > 1 && index[0] == '0') {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(index, 10, 64)
+	if err != nil || n < 0 || n > 2147483647 {
+		return 0, false
+	}
+	return uint32(n), true
+}
+
+// Why(中文): 中间 seed 与账户密钥用完即清零，缩短私密材料在内存中的停留时间。
+// Why(English): Zero intermediate seeds and account keys after use to shorten how long secrets linger in memory.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
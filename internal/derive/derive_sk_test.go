@@ -3,6 +3,7 @@ package derive
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"testing"
 )
 
@@ -45,17 +46,54 @@ func TestDeriveSKValidInputDeterministic(t *testing.T) {
 	}
 }
 
-// Why(中文): 助记词词数正确但 checksum 错误时，必须在 seed 阶段被拒绝，防止无效输入进入后续派生。
-// Why(English): A mnemonic with correct word count but invalid checksum must be rejected at seed stage to block invalid derivation input.
+// Why(中文): 助记词词数正确但 checksum 错误时，必须在 seed 阶段被拒绝，防止无效输入进入后续派生；校验和错误与词表外单词分别报告。
+// Why(English): A mnemonic with correct word count but invalid checksum must be rejected at seed stage to block invalid derivation input; a bad checksum and an unknown word are reported apart.
 func TestDeriveSKInvalidMnemonicChecksum(t *testing.T) {
 	_, err := DeriveSK("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "777")
 	if !errorsIs(err, ErrInvalidMnemonic) {
 		t.Fatalf("expected ErrInvalidMnemonic, got %v", err)
 	}
+	if !errorsIs(err, ErrMnemonicChecksum) {
+		t.Fatalf("expected ErrMnemonicChecksum, got %v", err)
+	}
+	_, err = DeriveSK("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandonx", "777")
+	if !errorsIs(err, ErrInvalidMnemonic) {
+		t.Fatalf("expected ErrInvalidMnemonic, got %v", err)
+	}
 }
 
 // Why(中文): 测试只关心错误语义，不关心 error 包装细节，后续接入真实派生时可保持断言稳定。
 // Why(English): Tests should bind to error semantics, not wrapping details, so assertions stay stable when real derivation is wired.
 func errorsIs(got error, want error) bool {
-	return got == want
+	return errors.Is(got, want)
+}
+
+// Why(中文): agent 依赖账户级派生与 DeriveSK 完全一致，否则经 agent 加密的文件无法用助记词解开。
+// Why(English): The agent relies on account-level derivation matching DeriveSK exactly, or agent-sealed files would not open with the mnemonic.
+func TestDeriveSKFromAccountMatchesDeriveSK(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	account, err := DeriveAccountKey(mnemonic)
+	if err != nil || len(account) != AccountKeySize {
+		t.Fatalf("unexpected account key: len=%d err=%v", len(account), err)
+	}
+	for _, index := range []string{"0", "777", "2147483647"} {
+		want, err := DeriveSK(mnemonic, index)
+		if err != nil {
+			t.Fatalf("derive %s: %v", index, err)
+		}
+		got, err := DeriveSKFromAccount(account, index)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("index %s mismatch: err=%v", index, err)
+		}
+	}
+	if _, err := DeriveSKFromAccount(account, "2147483648"); !errorsIs(err, ErrInvalidIndex) {
+		t.Fatalf("expected ErrInvalidIndex for hardened index, got %v", err)
+	}
+	if _, err := DeriveSKFromAccount(account[:32], "777"); !errorsIs(err, ErrDerivation) {
+		t.Fatalf("expected ErrDerivation for short account key, got %v", err)
+	}
+	id, ok := AccountID(account)
+	if !ok || len(id) != 16 {
+		t.Fatalf("unexpected account id %q", id)
+	}
 }
@@ -0,0 +1,86 @@
+package derive
+
+import (
+	"errors"
+	"sync"
+
+	bip32 "github.com/vcvvvc/go-wallet-sdk/crypto/go-bip32"
+)
+
+var ErrClosed = errors.New("deriver closed")
+
+// Why(中文): PBKDF2 与三层硬化派生在构造时只做一次，seed 用完即清零，只保留 m/44'/60'/0'/0 节点；每个索引经 bip32 库的 NewChildKey 派生，与 DeriveSKFromAccount 同一实现，不在密钥材料上自写非常数时间的模运算；读锁允许多个 goroutine 并发派生。
+// Why(English): PBKDF2 and the hardened levels run once at construction and the seed is wiped right after; only the m/44'/60'/0'/0 node stays, and each index goes through the bip32 library's NewChildKey, the same code as DeriveSKFromAccount, instead of hand-written non-constant-time arithmetic on key material; a read lock lets goroutines derive concurrently.
+type Deriver struct {
+	mu      sync.RWMutex
+	account []byte
+	node    *bip32.Key
+}
+
+// Why(中文): 从助记词构造，错误语义与 DeriveSK 相同；调用方负责在用完后 Close。
+// Why(English): Construct from a mnemonic with the same error semantics as DeriveSK; the caller must Close when done.
+func NewDeriver(mnemonicCanonical string) (*Deriver, error) {
+	account, err := DeriveAccountKey(mnemonicCanonical)
+	if err != nil {
+		return nil, err
+	}
+	d, err := NewDeriverFromAccount(account)
+	if err != nil {
+		wipe(account)
+		return nil, err
+	}
+	return d, nil
+}
+
+// Why(中文): 直接接管传入的账户密钥缓冲而不复制，节点的 ChainCode 与 Key 只是它的切片，agent 交出锁定内存中的那一份后，Close 清零的就是它。
+// Why(English): Take ownership of the account buffer without copying; the node's ChainCode and Key are slices of it, so when the agent hands over its locked-memory copy, that is exactly what Close zeroes.
+func NewDeriverFromAccount(account []byte) (*Deriver, error) {
+	if len(account) != AccountKeySize {
+		return nil, ErrDerivation
+	}
+	node := &bip32.Key{
+		Version:     bip32.PrivateWalletVersion,
+		Depth:       4,
+		ChainCode:   account[:32],
+		Key:         account[32:],
+		FingerPrint: make([]byte, 4),
+		ChildNumber: make([]byte, 4),
+		IsPrivate:   true,
+	}
+	return &Deriver{account: account, node: node}, nil
+}
+
+// Why(中文): 与 DeriveSK、DeriveSKFromAccount 对同一索引逐字节一致；省下的是每次调用的 PBKDF2 与硬化派生，单索引的 CKDpriv 仍由库完成。
+// Why(English): Byte-identical to DeriveSK and DeriveSKFromAccount for the same index; what it saves is PBKDF2 and the hardened levels per call, while the per-index CKDpriv is still done by the library.
+func (d *Deriver) DeriveSK(index string) ([]byte, error) {
+	n, ok := parseIndex(index)
+	if !ok {
+		return nil, ErrInvalidIndex
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.account == nil {
+		return nil, ErrClosed
+	}
+	child, err := d.node.NewChildKey(n)
+	if err != nil || len(child.Key) != 32 {
+		return nil, ErrDerivation
+	}
+	sk := make([]byte, 32)
+	copy(sk, child.Key)
+	wipe(child.Key)
+	return sk, nil
+}
+
+// Why(中文): Close 等待进行中的派生结束后清零账户密钥，之后的调用返回 ErrClosed；重复调用无副作用。
+// Why(English): Close waits for in-flight derivations, zeroes the account key, and makes later calls return ErrClosed; calling it twice is harmless.
+func (d *Deriver) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.account == nil {
+		return
+	}
+	wipe(d.account)
+	d.account = nil
+	d.node = nil
+}
@@ -0,0 +1,201 @@
+package derive
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+const deriverMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// Why(中文): 缓存路径必须与 DeriveSK 在边界索引上、与 DeriveSKFromAccount 在一千个连续索引上逐字节一致，否则经缓存加密的文件无法用旧入口解开。
+// Why(English): The cached path must equal DeriveSK on boundary indexes and DeriveSKFromAccount across a thousand consecutive indexes byte for byte, or files sealed through it would not open via the old entry point.
+func TestDeriverMatchesDeriveSK(t *testing.T) {
+	d, err := NewDeriver(deriverMnemonic)
+	if err != nil {
+		t.Fatalf("NewDeriver: %v", err)
+	}
+	defer d.Close()
+	indexes := []string{"777", "2147483647"}
+	for i := 0; i < 32; i++ {
+		indexes = append(indexes, strconv.Itoa(i))
+	}
+	for _, index := range indexes {
+		want, err := DeriveSK(deriverMnemonic, index)
+		if err != nil {
+			t.Fatalf("DeriveSK %s: %v", index, err)
+		}
+		got, err := d.DeriveSK(index)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("index %s mismatch: err=%v", index, err)
+		}
+	}
+	account, err := DeriveAccountKey(deriverMnemonic)
+	if err != nil {
+		t.Fatalf("DeriveAccountKey: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		index := strconv.Itoa(i)
+		want, err := DeriveSKFromAccount(account, index)
+		if err != nil {
+			t.Fatalf("DeriveSKFromAccount %s: %v", index, err)
+		}
+		got, err := d.DeriveSK(index)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("index %s mismatch with DeriveSKFromAccount: err=%v", index, err)
+		}
+	}
+	if _, err := d.DeriveSK("001"); err != ErrInvalidIndex {
+		t.Fatalf("expected ErrInvalidIndex, got %v", err)
+	}
+	if _, err := NewDeriver(""); err != ErrInvalidMnemonic {
+		t.Fatalf("expected ErrInvalidMnemonic, got %v", err)
+	}
+	if _, err := NewDeriverFromAccount(make([]byte, 32)); err != ErrDerivation {
+		t.Fatalf("expected ErrDerivation, got %v", err)
+	}
+}
+
+// Why(中文): 多个 goroutine 共用一个 Deriver 时结果必须与串行一致；配合 -race 可发现共享状态被写入。
+// Why(English): Goroutines sharing one Deriver must get the serial results; under -race this also catches writes to shared state.
+func TestDeriverConcurrent(t *testing.T) {
+	d, err := NewDeriver(deriverMnemonic)
+	if err != nil {
+		t.Fatalf("NewDeriver: %v", err)
+	}
+	defer d.Close()
+	want, _ := DeriveSK(deriverMnemonic, "777")
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				got, err := d.DeriveSK("777")
+				if err != nil || !bytes.Equal(got, want) {
+					errs <- "concurrent derive mismatch"
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for msg := range errs {
+		t.Fatal(msg)
+	}
+}
+
+// Why(中文): Close 必须清零构造时交出的账户缓冲，之后派生返回 ErrClosed，重复 Close 不 panic。
+// Why(English): Close must zero the account buffer handed over at construction, later derivations return ErrClosed, and a second Close must not panic.
+func TestDeriverCloseWipes(t *testing.T) {
+	account, err := DeriveAccountKey(deriverMnemonic)
+	if err != nil {
+		t.Fatalf("DeriveAccountKey: %v", err)
+	}
+	d, err := NewDeriverFromAccount(account)
+	if err != nil {
+		t.Fatalf("NewDeriverFromAccount: %v", err)
+	}
+	d.Close()
+	if !bytes.Equal(account, make([]byte, AccountKeySize)) {
+		t.Fatalf("account buffer must be zeroed by Close")
+	}
+	if _, err := d.DeriveSK("777"); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	d.Close()
+}
+
+// Why(中文): 批量场景：一次处理 100 个索引，对比每次都走 PBKDF2 的 DeriveSK 与只构造一次的 Deriver。
+// Why(English): Batch workload: 100 indexes per operation, comparing DeriveSK redoing PBKDF2 each time with a Deriver built once.
+func BenchmarkDeriveBatch(b *testing.B) {
+	const batch = 100
+	b.Run("DeriveSK", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < batch; j++ {
+				if _, err := DeriveSK(deriverMnemonic, strconv.Itoa(j)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("Deriver", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			d, err := NewDeriver(deriverMnemonic)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for j := 0; j < batch; j++ {
+				if _, err := d.DeriveSK(strconv.Itoa(j)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			d.Close()
+		}
+	})
+}
+
+// Why(中文): 扫描场景：账户已就绪，按连续索引逐个派生；对比 DeriveSKFromAccount 与 Deriver，两者共用库的单索引派生。
+// Why(English): Scan workload: the account is ready and indexes are derived in sequence; compares DeriveSKFromAccount with the Deriver, which share the per-index library step.
+func BenchmarkDeriveScan(b *testing.B) {
+	account, err := DeriveAccountKey(deriverMnemonic)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("DeriveSKFromAccount", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := DeriveSKFromAccount(account, strconv.Itoa(i%1000000)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Deriver", func(b *testing.B) {
+		d, err := NewDeriverFromAccount(append([]byte(nil), account...))
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer d.Close()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := d.DeriveSK(strconv.Itoa(i % 1000000)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// Why(中文): agent 场景：多个客户端并发请求同一账户的单个索引，Deriver 在读锁下并行派生。
+// Why(English): Agent workload: concurrent clients request single indexes of one account, and the Deriver derives in parallel under its read lock.
+func BenchmarkDeriveAgent(b *testing.B) {
+	account, err := DeriveAccountKey(deriverMnemonic)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("DeriveSKFromAccount", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := DeriveSKFromAccount(account, "777"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+	b.Run("Deriver", func(b *testing.B) {
+		d, err := NewDeriverFromAccount(append([]byte(nil), account...))
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer d.Close()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := d.DeriveSK("777"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+}
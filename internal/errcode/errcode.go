@@ -0,0 +1,223 @@
+package errcode
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"TXLOCK/internal/agent"
+	"TXLOCK/internal/archive"
+	"TXLOCK/internal/derive"
+	"TXLOCK/internal/fieldlock"
+	"TXLOCK/internal/lockcore"
+	"TXLOCK/internal/seqstate"
+)
+
+// Why(中文): 错误码是给脚本的稳定契约，只增不改；人读的 message 可以随版本调整，code 不行。
+// Why(English): Codes are a stable contract for scripts and are only ever added, never renamed; the human message may change between releases, the code may not.
+type Code string
+
+const (
+	OK               Code = "OK"
+	Usage            Code = "USAGE"
+	IO               Code = "IO_ERROR"
+	EnvelopeBoundary Code = "ENVELOPE_BOUNDARY"
+	NonCanonical     Code = "NON_CANONICAL"
+	FieldDocument    Code = "FIELD_DOCUMENT"
+	PayloadInvalid   Code = "PAYLOAD_INVALID"
+	AuthFailed       Code = "AUTH_FAILED"
+	LabelMismatch    Code = "LABEL_MISMATCH"
+	MnemonicInvalid  Code = "MNEMONIC_INVALID"
+	MnemonicChecksum Code = "MNEMONIC_CHECKSUM"
+	IndexInvalid     Code = "INDEX_INVALID"
+	Derivation       Code = "DERIVATION_FAILED"
+	Agent            Code = "AGENT_ERROR"
+	WeakKDF          Code = "WEAK_KDF"
+	TooLarge         Code = "TOO_LARGE"
+	Rollback         Code = "ROLLBACK"
+	ArchiveUnsafe    Code = "ARCHIVE_UNSAFE"
+	StateCorrupt     Code = "STATE_CORRUPT"
+	KDFLimit         Code = "KDF_LIMIT"
+	Internal         Code = "INTERNAL"
+)
+
+// Why(中文): 细分退出码按类别而不是逐个错误码分配，类别数少、脚本好写；全部不小于 2，只判断“非 0”或“大于 1”的旧脚本照常工作。
+// Why(English): Detailed exit codes are assigned per class rather than per code so scripts stay short; all are at least 2, so old scripts testing non-zero or greater than 1 keep working.
+const (
+	ExitOK      = 0
+	ExitUsage   = 1
+	ExitFailure = 2
+	ExitInput   = 3
+	ExitAuth    = 4
+	ExitKey     = 5
+	ExitPolicy  = 6
+)
+
+// Why(中文): 按哨兵错误归类，errors.Is 兼容包装过的错误；未识别的错误归为 INTERNAL 而不是猜测成认证失败。
+// Why(English): Classify by sentinel error, with errors.Is so wrapped errors still match; anything unrecognised is INTERNAL rather than guessed to be an auth failure.
+func Classify(err error) Code {
+	switch {
+	case err == nil:
+		return OK
+	case errors.Is(err, lockcore.ErrDecrypt), errors.Is(err, lockcore.ErrFieldMAC):
+		return AuthFailed
+	case errors.Is(err, lockcore.ErrLabelMismatch):
+		return LabelMismatch
+	case errors.Is(err, lockcore.ErrNonCanonical):
+		return NonCanonical
+	case errors.Is(err, lockcore.ErrFieldToken), errors.Is(err, fieldlock.ErrFormat), errors.Is(err, fieldlock.ErrParse),
+		errors.Is(err, fieldlock.ErrAlreadyEncrypted), errors.Is(err, fieldlock.ErrNotEncrypted), errors.Is(err, fieldlock.ErrTokenLikeValue):
+		return FieldDocument
+	case errors.Is(err, lockcore.ErrPadding), errors.Is(err, lockcore.ErrMetadata), errors.Is(err, lockcore.ErrCompression):
+		return PayloadInvalid
+	case errors.Is(err, lockcore.ErrWeakKDF):
+		return WeakKDF
+	case errors.Is(err, lockcore.ErrTooLarge):
+		return TooLarge
+	case errors.Is(err, lockcore.ErrKDFLimit):
+		return KDFLimit
+	case errors.Is(err, lockcore.ErrInvalidLabel), errors.Is(err, lockcore.ErrInvalidSequence):
+		return Usage
+	case errors.Is(err, derive.ErrMnemonicChecksum):
+		return MnemonicChecksum
+	case errors.Is(err, derive.ErrInvalidMnemonic):
+		return MnemonicInvalid
+	case errors.Is(err, derive.ErrInvalidIndex):
+		return IndexInvalid
+	case errors.Is(err, derive.ErrDerivation), errors.Is(err, lockcore.ErrInvalidSK), errors.Is(err, lockcore.ErrInvalidPath):
+		return Derivation
+	case errors.Is(err, agent.ErrNoKey), errors.Is(err, agent.ErrAmbiguous), errors.Is(err, agent.ErrUnknownKey), errors.Is(err, agent.ErrProtocol):
+		return Agent
+	case errors.Is(err, seqstate.ErrRollback):
+		return Rollback
+	case errors.Is(err, seqstate.ErrCorrupt):
+		return StateCorrupt
+	case errors.Is(err, archive.ErrUnsafePath), errors.Is(err, archive.ErrUnsupportedEntry):
+		return ArchiveUnsafe
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrPermission), errors.Is(err, fs.ErrExist):
+		return IO
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return IO
+	}
+	return Internal
+}
+
+// Why(中文): 细分退出码：格式类 3、认证类 4、密钥材料类 5、策略拒绝类 6，其余处理失败仍为 2。
+// Why(English): Detailed exit codes: input format 3, authentication 4, key material 5, policy refusals 6, and every other processing failure stays 2.
+func Exit(code Code) int {
+	switch code {
+	case OK:
+		return ExitOK
+	case Usage:
+		return ExitUsage
+	case EnvelopeBoundary, NonCanonical, FieldDocument, PayloadInvalid:
+		return ExitInput
+	case AuthFailed, LabelMismatch:
+		return ExitAuth
+	case MnemonicInvalid, MnemonicChecksum, IndexInvalid, Derivation, Agent:
+		return ExitKey
+	case WeakKDF, TooLarge, KDFLimit, Rollback, ArchiveUnsafe:
+		return ExitPolicy
+	}
+	return ExitFailure
+}
+
+// Why(中文): 旧退出码只有 0/1/2，默认文本模式继续使用，已有脚本不受影响。
+// Why(English): Legacy exit codes are only 0/1/2 and stay the default in text mode so existing scripts are unaffected.
+func LegacyExit(code Code) int {
+	if e := Exit(code); e <= ExitUsage {
+		return e
+	}
+	return ExitFailure
+}
+
+type Result struct {
+	Status  string `json:"status"`
+	Code    Code   `json:"code"`
+	Exit    int    `json:"exit"`
+	Tool    string `json:"tool"`
+	Message string `json:"message,omitempty"`
+	File    string `json:"file,omitempty"`
+	Output  string `json:"output,omitempty"`
+	Index   string `json:"index,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// Why(中文): 每次运行只输出一个结果：文本模式失败时打印 "<tool>: <message>" 并返回旧退出码；-json 模式成功与失败都打印一行 JSON 并返回细分退出码。File/Index/Version 由调用方在得知后填入。
+// Why(English): Each run reports exactly one result: text mode prints "<tool>: <message>" on failure and returns the legacy exit code; -json mode prints one JSON line for success and failure alike and returns the detailed exit code. Callers fill File/Index/Version as they learn them.
+type Reporter struct {
+	Tool    string
+	JSON    bool
+	W       io.Writer
+	File    string
+	Output  string
+	Index   string
+	Version string
+}
+
+// Why(中文): flag 解析失败时 -json 的值尚未读出，只能在原始参数里查找；"--" 之后是位置参数，不再识别。
+// Why(English): When flag parsing fails the -json value has not been read yet, so look for it in the raw arguments; anything after "--" is positional and ignored.
+func WantsJSON(args []string) bool {
+	for _, a := range args {
+		if a == "--" {
+			return false
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(a, "-"), "-"), "=")
+		if !strings.HasPrefix(a, "-") || name != "json" {
+			continue
+		}
+		if !hasValue {
+			return true
+		}
+		on, err := strconv.ParseBool(value)
+		return err == nil && on
+	}
+	return false
+}
+
+// Why(中文): 用法错误固定为 USAGE，两种模式的退出码都是 1。
+// Why(English): Usage errors are always USAGE and exit 1 in both modes.
+func (r *Reporter) Usage(msg string) int {
+	return r.Fail(Usage, msg)
+}
+
+// Why(中文): 已知错误码的失败；message 为空时文本模式保持静默，与旧行为一致。
+// Why(English): A failure with a known code; an empty message keeps text mode silent, as before.
+func (r *Reporter) Fail(code Code, msg string) int {
+	if !r.JSON {
+		if msg != "" {
+			_, _ = io.WriteString(r.W, r.Tool+": "+msg+"\n")
+		}
+		return LegacyExit(code)
+	}
+	return r.emit("error", code, msg)
+}
+
+// Why(中文): 由哨兵错误推出错误码，调用方只需给出人读的说明。
+// Why(English): Derive the code from the sentinel error so callers only supply the human-readable text.
+func (r *Reporter) FailErr(err error, msg string) int {
+	return r.Fail(Classify(err), msg)
+}
+
+// Why(中文): 成功时文本模式不额外输出，避免混入既有的标准输出内容；-json 模式输出 status ok。
+// Why(English): On success text mode prints nothing extra so existing stdout content stays clean; -json mode prints status ok.
+func (r *Reporter) Done() int {
+	if !r.JSON {
+		return ExitOK
+	}
+	return r.emit("ok", OK, "")
+}
+
+// Why(中文): 一行一个对象，便于按行读取；编码失败不可能发生（字段都是字符串与整数），忽略写错误与文本模式一致。
+// Why(English): One object per line for line-oriented readers; encoding cannot fail for string and int fields, and write errors are ignored as in text mode.
+func (r *Reporter) emit(status string, code Code, msg string) int {
+	res := Result{Status: status, Code: code, Exit: Exit(code), Tool: r.Tool, Message: msg, File: r.File, Output: r.Output, Index: r.Index, Version: r.Version}
+	raw, _ := json.Marshal(res)
+	_, _ = r.W.Write(append(raw, '\n'))
+	return res.Exit
+}
@@ -0,0 +1,119 @@
+package errcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"TXLOCK/internal/derive"
+	"TXLOCK/internal/fieldlock"
+	"TXLOCK/internal/lockcore"
+	"TXLOCK/internal/seqstate"
+)
+
+// Why(中文): 锁定哨兵错误到错误码的映射；包装过的错误与文件系统错误同样识别，未知错误归为 INTERNAL。
+// Why(English): Pin the sentinel-to-code mapping; wrapped errors and filesystem errors are recognised too, and unknown errors are INTERNAL.
+func TestClassify(t *testing.T) {
+	_, statErr := os.Stat("/nonexistent/txlock")
+	for err, want := range map[error]Code{
+		lockcore.ErrDecrypt:                         AuthFailed,
+		lockcore.ErrFieldMAC:                        AuthFailed,
+		lockcore.ErrLabelMismatch:                   LabelMismatch,
+		lockcore.ErrNonCanonical:                    NonCanonical,
+		lockcore.ErrPadding:                         PayloadInvalid,
+		lockcore.ErrWeakKDF:                         WeakKDF,
+		lockcore.ErrTooLarge:                        TooLarge,
+		lockcore.ErrKDFLimit:                        KDFLimit,
+		fieldlock.ErrTokenLikeValue:                 FieldDocument,
+		derive.ErrMnemonicChecksum:                  MnemonicChecksum,
+		derive.ErrInvalidMnemonic:                   MnemonicInvalid,
+		derive.ErrInvalidIndex:                      IndexInvalid,
+		seqstate.ErrRollback:                        Rollback,
+		seqstate.ErrCorrupt:                         StateCorrupt,
+		fmt.Errorf("open: %w", lockcore.ErrDecrypt): AuthFailed,
+		statErr:                      IO,
+		errors.New("something else"): Internal,
+	} {
+		if got := Classify(err); got != want {
+			t.Fatalf("%v: expected %s, got %s", err, want, got)
+		}
+	}
+	if Classify(nil) != OK {
+		t.Fatalf("nil must classify as OK")
+	}
+}
+
+// Why(中文): 旧退出码只有 0/1/2；细分退出码按类别分配且失败类全部不小于 2。
+// Why(English): Legacy exit codes are only 0/1/2; detailed ones follow the classes and every failure is at least 2.
+func TestExitCodes(t *testing.T) {
+	for code, want := range map[Code][2]int{
+		OK:               {0, 0},
+		Usage:            {1, 1},
+		IO:               {2, 2},
+		EnvelopeBoundary: {2, 3},
+		AuthFailed:       {2, 4},
+		MnemonicChecksum: {2, 5},
+		Rollback:         {2, 6},
+		KDFLimit:         {2, 6},
+		Internal:         {2, 2},
+	} {
+		if got := [2]int{LegacyExit(code), Exit(code)}; got != want {
+			t.Fatalf("%s: expected %v, got %v", code, want, got)
+		}
+	}
+}
+
+// Why(中文): 文本模式只在失败时打印 "<tool>: <message>" 并返回旧退出码；JSON 模式成功与失败都输出一行对象。
+// Why(English): Text mode prints "<tool>: <message>" only on failure and returns the legacy exit code; JSON mode prints one object line for success and failure alike.
+func TestReporter(t *testing.T) {
+	var buf bytes.Buffer
+	text := &Reporter{Tool: "txlock-dec", W: &buf}
+	if code := text.FailErr(lockcore.ErrDecrypt, "decrypt failed"); code != 2 || buf.String() != "txlock-dec: decrypt failed\n" {
+		t.Fatalf("text failure: %d %q", code, buf.String())
+	}
+	buf.Reset()
+	if code := text.Done(); code != 0 || buf.Len() != 0 {
+		t.Fatalf("text success: %d %q", code, buf.String())
+	}
+	js := &Reporter{Tool: "txlock-dec", JSON: true, W: &buf, File: "a.lock", Index: "777", Version: "v2"}
+	if code := js.FailErr(lockcore.ErrDecrypt, "decrypt failed"); code != ExitAuth {
+		t.Fatalf("json failure: expected %d, got %d", ExitAuth, code)
+	}
+	var res Result
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := Result{Status: "error", Code: AuthFailed, Exit: ExitAuth, Tool: "txlock-dec", Message: "decrypt failed", File: "a.lock", Index: "777", Version: "v2"}
+	if res != want {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	buf.Reset()
+	if code := js.Done(); code != 0 || buf.String() != `{"status":"ok","code":"OK","exit":0,"tool":"txlock-dec","file":"a.lock","index":"777","version":"v2"}`+"\n" {
+		t.Fatalf("json success: %d %q", code, buf.String())
+	}
+}
+
+// Why(中文): -json 可能以 -json、--json 或 -json=BOOL 出现；"--" 之后的参数以及 -json=false 都不算。
+// Why(English): -json may appear as -json, --json or -json=BOOL; arguments after "--" and -json=false do not count.
+func TestWantsJSON(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"-json", "-bogus"}, true},
+		{[]string{"-bogus", "--json"}, true},
+		{[]string{"-json=true"}, true},
+		{[]string{"-json=false"}, false},
+		{[]string{"-json=maybe"}, false},
+		{[]string{"-in", "a.lock"}, false},
+		{[]string{"--", "-json"}, false},
+		{[]string{"-jsonx"}, false},
+	} {
+		if got := WantsJSON(tc.args); got != tc.want {
+			t.Fatalf("%v: expected %v, got %v", tc.args, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package ethsig
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// Why(中文): 签名块沿用 envelope 的 HTML 注释边界，内嵌时追加在 envelope 之后，独立时即为 .sig 文件全文，两种形态一种语法。
+// Why(English): The signature block reuses the envelope's HTML comment boundaries; embedded it follows the envelope, detached it is the whole .sig file, one grammar for both.
+const blockMagic = "txlock-sig:v1"
+
+// Why(中文): 块内固定三行：magic、签名者地址、0x 前缀的 65 字节签名；signer 仅作提示，验证以恢复出的地址为准。
+// Why(English): The block holds exactly three lines: magic, signer address and the 0x-prefixed 65-byte signature; signer is a hint, verification trusts the recovered address.
+func BuildBlock(signer Address, sig []byte) string {
+	return "<!--\n" + blockMagic + "\nsigner:" + signer.String() + "\nsig:0x" + hex.EncodeToString(sig) + "\n-->\n"
+}
+
+// Why(中文): 严格解析，任何多余空白、行或大小写变化都拒绝；块内 signer 必须与签名恢复出的地址一致。
+// Why(English): Parse strictly, refusing any extra whitespace, lines or case changes; the block's signer must equal the address recovered from the signature.
+func ParseBlock(block string) (Address, []byte, bool) {
+	lines := strings.Split(block, "\n")
+	if len(lines) != 6 || lines[0] != "<!--" || lines[1] != blockMagic || lines[4] != "-->" || lines[5] != "" {
+		return Address{}, nil, false
+	}
+	rawSigner, ok := strings.CutPrefix(lines[2], "signer:")
+	if !ok {
+		return Address{}, nil, false
+	}
+	signer, ok := ParseAddress(rawSigner)
+	if !ok {
+		return Address{}, nil, false
+	}
+	rawSig, ok := strings.CutPrefix(lines[3], "sig:0x")
+	if !ok || len(rawSig) != 130 || rawSig != strings.ToLower(rawSig) {
+		return Address{}, nil, false
+	}
+	sig, err := hex.DecodeString(rawSig)
+	if err != nil {
+		return Address{}, nil, false
+	}
+	return signer, sig, true
+}
+
+// Why(中文): 内嵌签名覆盖其之前的全部字节；没有签名块时原样返回，解密侧可对签名与未签名文件走同一路径。
+// Why(English): An embedded signature covers every byte before it; without a block the input is returned unchanged so decryption treats signed and unsigned files alike.
+func SplitEmbedded(raw string) (string, string, bool) {
+	i := strings.LastIndex(raw, "<!--\n"+blockMagic+"\n")
+	if i <= 0 || !strings.HasSuffix(raw[:i], "-->\n") {
+		return raw, "", false
+	}
+	return raw[:i], raw[i:], true
+}
+
+// Why(中文): 统一的验证入口：解析块、恢复地址、核对块内 signer 与期望签名者。
+// Why(English): One verification entry: parse the block, recover the address, and check both the block's signer and the expected signer.
+func VerifyBlock(msg []byte, block string, signer Address) error {
+	claimed, sig, ok := ParseBlock(block)
+	if !ok {
+		return ErrInvalidSignature
+	}
+	got, err := Recover(msg, sig)
+	if err != nil {
+		return err
+	}
+	if got != claimed {
+		return ErrInvalidSignature
+	}
+	if got != signer {
+		return ErrSignerMismatch
+	}
+	return nil
+}
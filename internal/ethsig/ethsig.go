@@ -0,0 +1,150 @@
+package ethsig
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/vcvvvc/go-wallet-sdk/crypto/btcd/btcec"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	ErrInvalidKey       = errors.New("invalid signing key")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrSignerMismatch   = errors.New("signature does not match signer")
+)
+
+// Why(中文): 地址为 20 字节，与以太坊账户一致，便于直接与钱包、区块浏览器中的地址比对。
+// Why(English): Addresses are the 20-byte Ethereum account form so they compare directly with wallets and block explorers.
+type Address [20]byte
+
+// Why(中文): 以太坊使用原始 Keccak-256（非 FIPS SHA3），签名哈希与地址派生都必须用它。
+// Why(English): Ethereum uses original Keccak-256 (not FIPS SHA3) for both the signing hash and address derivation.
+func keccak256(parts ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// Why(中文): EIP-191 personal_sign（版本 0x45）给消息加前缀，签名无法被当作交易或其他结构化数据重放。
+// Why(English): EIP-191 personal_sign (version 0x45) prefixes the message so a signature can never be replayed as a transaction or other typed data.
+func PersonalHash(msg []byte) []byte {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(msg))
+	return keccak256([]byte(prefix), msg)
+}
+
+// Why(中文): 地址取未压缩公钥（去掉 0x04）的 Keccak-256 后 20 字节，与钱包显示的地址一致。
+// Why(English): The address is the last 20 bytes of Keccak-256 over the uncompressed public key without 0x04, matching what wallets show.
+func AddressOf(sk []byte) (Address, error) {
+	if len(sk) != 32 {
+		return Address{}, ErrInvalidKey
+	}
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), sk)
+	if priv.D.Sign() == 0 || priv.D.Cmp(btcec.S256().N) >= 0 {
+		return Address{}, ErrInvalidKey
+	}
+	return addressOfPub(priv.PubKey()), nil
+}
+
+// Why(中文): 签名与恢复两侧共用同一公钥到地址的转换。
+// Why(English): Signing and recovery share one public-key-to-address conversion.
+func addressOfPub(pub *btcec.PublicKey) Address {
+	var a Address
+	copy(a[:], keccak256(pub.SerializeUncompressed()[1:])[12:])
+	return a
+}
+
+// Why(中文): 签名为 RFC 6979 确定性、low-S 的 65 字节 r||s||v（v=27/28），与 personal_sign 返回格式一致，可用任意以太坊工具验证。
+// Why(English): Signatures are RFC 6979 deterministic, low-S, 65-byte r||s||v (v=27/28), the personal_sign format any Ethereum tool can verify.
+func Sign(sk []byte, msg []byte) ([]byte, error) {
+	if _, err := AddressOf(sk); err != nil {
+		return nil, err
+	}
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), sk)
+	compact, err := btcec.SignCompact(btcec.S256(), priv, PersonalHash(msg), false)
+	if err != nil || len(compact) != 65 || compact[0] > 28 {
+		return nil, ErrInvalidSignature
+	}
+	sig := make([]byte, 0, 65)
+	sig = append(sig, compact[1:]...)
+	return append(sig, compact[0]), nil
+}
+
+// Why(中文): 恢复前先拒绝越界的 r/s、高 S 与非 27/28/0/1 的 v，同一签名只有一种有效编码，不存在可延展的变体。
+// Why(English): Reject out-of-range r/s, high S and any v outside 27/28/0/1 before recovery so each signature has exactly one valid encoding.
+func Recover(msg []byte, sig []byte) (Address, error) {
+	if len(sig) != 65 {
+		return Address{}, ErrInvalidSignature
+	}
+	v := sig[64]
+	if v < 27 {
+		v += 27
+	}
+	if v != 27 && v != 28 {
+		return Address{}, ErrInvalidSignature
+	}
+	curve := btcec.S256()
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	half := new(big.Int).Rsh(curve.N, 1)
+	if r.Sign() == 0 || r.Cmp(curve.N) >= 0 || s.Sign() == 0 || s.Cmp(half) > 0 {
+		return Address{}, ErrInvalidSignature
+	}
+	compact := make([]byte, 0, 65)
+	compact = append(compact, v)
+	compact = append(compact, sig[:64]...)
+	pub, _, err := btcec.RecoverCompact(curve, compact, PersonalHash(msg))
+	if err != nil {
+		return Address{}, ErrInvalidSignature
+	}
+	return addressOfPub(pub), nil
+}
+
+// Why(中文): 验证即“恢复地址并与期望签名者比对”，调用方只需要已知地址，不需要公钥或助记词。
+// Why(English): Verification recovers the address and compares it with the expected signer, so callers need only a known address, never a public key or mnemonic.
+func Verify(msg []byte, sig []byte, signer Address) error {
+	got, err := Recover(msg, sig)
+	if err != nil {
+		return err
+	}
+	if got != signer {
+		return ErrSignerMismatch
+	}
+	return nil
+}
+
+// Why(中文): 输出 EIP-55 校验和格式，用户肉眼比对或粘贴到钱包时都能发现输错的字符。
+// Why(English): Emit the EIP-55 checksummed form so typos are caught whether the user compares by eye or pastes into a wallet.
+func (a Address) String() string {
+	lower := hex.EncodeToString(a[:])
+	sum := keccak256([]byte(lower))
+	out := []byte(lower)
+	for i, c := range out {
+		if c >= 'a' && (sum[i/2]>>(4*(1-uint(i%2))))&0xf >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
+
+// Why(中文): 全小写或全大写按无校验和地址接受；大小写混合时必须满足 EIP-55，防止一个字符输错仍被当作另一个合法地址。
+// Why(English): All-lower or all-upper input is accepted as un-checksummed; mixed case must satisfy EIP-55 so a single typo is not taken as another valid address.
+func ParseAddress(s string) (Address, bool) {
+	raw, ok := strings.CutPrefix(s, "0x")
+	if !ok || len(raw) != 40 {
+		return Address{}, false
+	}
+	var a Address
+	if _, err := hex.Decode(a[:], []byte(raw)); err != nil {
+		return Address{}, false
+	}
+	if raw == strings.ToLower(raw) || raw == strings.ToUpper(raw) {
+		return a, true
+	}
+	return a, a.String() == s
+}
@@ -0,0 +1,138 @@
+package ethsig
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"TXLOCK/internal/derive"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("bad hex %q: %v", s, err)
+	}
+	return b
+}
+
+// Why(中文): 锁定 web3.js 文档中的公开 personal_sign 向量，前缀、Keccak、RFC 6979 与 v 编码任何偏差都会暴露。
+// Why(English): Lock the public personal_sign vector from the web3.js docs so any drift in prefix, Keccak, RFC 6979 or v encoding shows up.
+func TestSignKnownVector(t *testing.T) {
+	sk := mustHex(t, "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	addr, err := AddressOf(sk)
+	if err != nil || addr.String() != "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23" {
+		t.Fatalf("unexpected address %s err=%v", addr, err)
+	}
+	sig, err := Sign(sk, []byte("Some data"))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	want := "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c"
+	if got := hex.EncodeToString(sig); got != want {
+		t.Fatalf("unexpected signature %s", got)
+	}
+	if err := Verify([]byte("Some data"), sig, addr); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := Verify([]byte("Some datA"), sig, addr); err != ErrSignerMismatch {
+		t.Fatalf("expected ErrSignerMismatch for altered message, got %v", err)
+	}
+}
+
+// Why(中文): 加密路径上的 SK 对应钱包中同一索引的地址；索引 0 与 MetaMask 等钱包显示的首个地址一致。
+// Why(English): The SK at the encryption path maps to the wallet address at that index; index 0 matches the first address wallets like MetaMask show.
+func TestAddressMatchesWallet(t *testing.T) {
+	sk, err := derive.DeriveSK("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "0")
+	if err != nil {
+		t.Fatalf("derive: %v", err)
+	}
+	addr, err := AddressOf(sk)
+	if err != nil || addr.String() != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Fatalf("unexpected address %s err=%v", addr, err)
+	}
+	if _, err := AddressOf(make([]byte, 32)); err != ErrInvalidKey {
+		t.Fatalf("expected ErrInvalidKey for zero key, got %v", err)
+	}
+}
+
+// Why(中文): 高 S、非法 v 与越界 r 都必须拒绝，签名不可延展。
+// Why(English): High S, bad v and out-of-range r are all refused so signatures are not malleable.
+func TestRecoverRejectsMalleable(t *testing.T) {
+	sk := bytes.Repeat([]byte{0x11}, 32)
+	msg := []byte("envelope")
+	sig, err := Sign(sk, msg)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	addr, _ := AddressOf(sk)
+	zeroV := append([]byte(nil), sig...)
+	zeroV[64] -= 27
+	if err := Verify(msg, zeroV, addr); err != nil {
+		t.Fatalf("v in {0,1} must be accepted: %v", err)
+	}
+	badV := append([]byte(nil), sig...)
+	badV[64] = 29
+	if _, err := Recover(msg, badV); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature for v=29, got %v", err)
+	}
+	highS := append([]byte(nil), sig...)
+	copy(highS[32:64], mustHex(t, "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140"))
+	if _, err := Recover(msg, highS); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature for high S, got %v", err)
+	}
+	zeroR := append([]byte(nil), sig...)
+	copy(zeroR[:32], make([]byte, 32))
+	if _, err := Recover(msg, zeroR); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature for r=0, got %v", err)
+	}
+}
+
+// Why(中文): 混合大小写地址必须满足 EIP-55；全小写/全大写按无校验和接受。
+// Why(English): Mixed-case addresses must satisfy EIP-55; all-lower and all-upper are accepted as un-checksummed.
+func TestParseAddress(t *testing.T) {
+	for _, ok := range []string{"0x9858EfFD232B4033E47d90003D41EC34EcaEda94", "0x9858effd232b4033e47d90003d41ec34ecaeda94", "0x9858EFFD232B4033E47D90003D41EC34ECAEDA94"} {
+		if _, valid := ParseAddress(ok); !valid {
+			t.Fatalf("expected accept for %q", ok)
+		}
+	}
+	for _, bad := range []string{"0x9858efFD232B4033E47d90003D41EC34EcaEda94", "9858effd232b4033e47d90003d41ec34ecaeda94", "0x9858effd", "0xzz58effd232b4033e47d90003d41ec34ecaeda94"} {
+		if _, valid := ParseAddress(bad); valid {
+			t.Fatalf("expected reject for %q", bad)
+		}
+	}
+}
+
+// Why(中文): 内嵌块可被拆出并验证；改动 envelope 任一字节、换签名者或改写块内 signer 都会失败。
+// Why(English): An embedded block splits off and verifies; changing any envelope byte, the expected signer or the block's signer all fail.
+func TestEmbeddedBlockRoundTrip(t *testing.T) {
+	sk := bytes.Repeat([]byte{0x22}, 32)
+	envelope := "<!--\ntxlock:v1\nct_b64:\nAAAA\n-->\n"
+	sig, err := Sign(sk, []byte(envelope))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	addr, _ := AddressOf(sk)
+	signed := envelope + BuildBlock(addr, sig)
+	body, block, ok := SplitEmbedded(signed)
+	if !ok || body != envelope {
+		t.Fatalf("split failed: ok=%v body=%q", ok, body)
+	}
+	if err := VerifyBlock([]byte(body), block, addr); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := VerifyBlock([]byte(body+" "), block, addr); err == nil {
+		t.Fatalf("tampered body must fail")
+	}
+	other, _ := AddressOf(bytes.Repeat([]byte{0x33}, 32))
+	if err := VerifyBlock([]byte(body), block, other); err != ErrSignerMismatch {
+		t.Fatalf("expected ErrSignerMismatch, got %v", err)
+	}
+	if err := VerifyBlock([]byte(body), BuildBlock(other, sig), other); err != ErrInvalidSignature {
+		t.Fatalf("forged signer line must fail, got %v", err)
+	}
+	if _, _, ok := SplitEmbedded(envelope); ok {
+		t.Fatalf("unsigned envelope must not split")
+	}
+}
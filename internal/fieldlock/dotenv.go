@@ -0,0 +1,131 @@
+package fieldlock
+
+import (
+	"strings"
+)
+
+const (
+	dotenvVersionKey = "TXLOCK_VERSION"
+	dotenvSaltKey    = "TXLOCK_SALT_B64"
+	dotenvMACKey     = "TXLOCK_MAC_B64"
+)
+
+type dotenvLine struct {
+	raw    string
+	key    string
+	prefix string
+	value  string
+}
+
+type dotenvDocument struct {
+	lines    []*dotenvLine
+	trailing bool
+}
+
+// Why(中文): dotenv 没有统一规范，按行保留注释、空行与 "export " 前缀，只把 '=' 右侧原样视为值，解密后可逐字节还原。
+// Why(English): dotenv has no single spec, so keep comments, blanks and "export " verbatim and treat the raw right-hand side as the value for byte-exact restore.
+func parseDotenvDocument(data []byte) (document, error) {
+	text := string(data)
+	d := &dotenvDocument{trailing: strings.HasSuffix(text, "\n")}
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return d, nil
+	}
+	seen := map[string]bool{}
+	for _, raw := range strings.Split(text, "\n") {
+		line := &dotenvLine{raw: raw}
+		trimmed := strings.TrimSpace(raw)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			eq := strings.IndexByte(raw, '=')
+			if eq <= 0 {
+				return nil, ErrParse
+			}
+			left := raw[:eq]
+			key := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(left), "export "))
+			if key == "" || strings.ContainsAny(key, " \t") || seen[key] {
+				return nil, ErrParse
+			}
+			seen[key] = true
+			line.key, line.prefix, line.value = key, left+"=", raw[eq+1:]
+		}
+		d.lines = append(d.lines, line)
+	}
+	return d, nil
+}
+
+// Why(中文): dotenv 值一律视为字符串，元数据行不参与叶子集合，保证 MAC 覆盖与加密时一致。
+// Why(English): dotenv values are always strings and metadata lines are excluded from leaves so MAC coverage matches sealing.
+func (d *dotenvDocument) leaves() []*leaf {
+	var out []*leaf
+	for _, l := range d.lines {
+		if l.key == "" || isDotenvMetaKey(l.key) {
+			continue
+		}
+		line := l
+		out = append(out, &leaf{path: pointerJoin("", l.key), typ: "str", value: l.value, set: func(_ string, value string) {
+			line.value = value
+			line.raw = line.prefix + value
+		}})
+	}
+	return out
+}
+
+// Why(中文): 元数据键使用固定 TXLOCK_ 前缀，集中判定避免各处硬编码。
+// Why(English): Metadata keys share a fixed TXLOCK_ prefix; one predicate avoids scattering the literals.
+func isDotenvMetaKey(key string) bool {
+	return key == dotenvVersionKey || key == dotenvSaltKey || key == dotenvMACKey
+}
+
+// Why(中文): dotenv 无嵌套结构，元数据以三行 TXLOCK_* 键存放，仍能被普通 dotenv 加载器读取而不报错。
+// Why(English): dotenv has no nesting, so metadata is three TXLOCK_* lines that ordinary dotenv loaders still read without errors.
+func (d *dotenvDocument) meta() (map[string]string, bool) {
+	m := map[string]string{}
+	found := false
+	for _, l := range d.lines {
+		switch l.key {
+		case dotenvVersionKey:
+			m["version"], found = l.value, true
+		case dotenvSaltKey:
+			m["salt_b64"], found = l.value, true
+		case dotenvMACKey:
+			m["mac_b64"], found = l.value, true
+		}
+	}
+	return m, found
+}
+
+// Why(中文): 元数据追加在文件末尾，原有行号保持不变，diff 只会出现在被加密的值上；末尾换行状态沿用原文件，解密后才能逐字节还原。
+// Why(English): Append metadata at the end so existing line numbers stay put and diffs show only on encrypted values; the trailing-newline state is kept from the original so decrypt restores it byte for byte.
+func (d *dotenvDocument) setMeta(m map[string]string) {
+	for _, kv := range [][2]string{{dotenvVersionKey, m["version"]}, {dotenvSaltKey, m["salt_b64"]}, {dotenvMACKey, m["mac_b64"]}} {
+		d.lines = append(d.lines, &dotenvLine{raw: kv[0] + "=" + kv[1], key: kv[0], prefix: kv[0] + "=", value: kv[1]})
+	}
+}
+
+// Why(中文): 解密输出中不应残留元数据行，否则再次加密会被误判为已加密文件。
+// Why(English): Decrypted output must not keep metadata lines, or re-encrypting would be rejected as already encrypted.
+func (d *dotenvDocument) removeMeta() {
+	kept := d.lines[:0]
+	for _, l := range d.lines {
+		if !isDotenvMetaKey(l.key) {
+			kept = append(kept, l)
+		}
+	}
+	d.lines = kept
+}
+
+// Why(中文): 按原始行写回并保留末尾换行状态，未加密的 dotenv 文件可逐字节往返。
+// Why(English): Write lines back verbatim and keep the trailing-newline state so dotenv files round-trip byte for byte.
+func (d *dotenvDocument) encode() ([]byte, error) {
+	var b strings.Builder
+	for i, l := range d.lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(l.raw)
+	}
+	if d.trailing && len(d.lines) > 0 {
+		b.WriteString("\n")
+	}
+	return []byte(b.String()), nil
+}
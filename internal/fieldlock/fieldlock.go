@@ -0,0 +1,173 @@
+package fieldlock
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"TXLOCK/internal/lockcore"
+)
+
+const versionV1 = "f1"
+
+var (
+	ErrFormat           = errors.New("unsupported structured format")
+	ErrParse            = errors.New("structured document parse failed")
+	ErrAlreadyEncrypted = errors.New("document already encrypted")
+	ErrNotEncrypted     = errors.New("document has no txlock metadata")
+	ErrTokenLikeValue   = errors.New("unencrypted value looks like a field token")
+)
+
+// Why(中文): 每个标量值按 RFC 6901 JSON Pointer 定位，三种格式共用同一寻址方式，AAD 与 MAC 覆盖才能一致。
+// Why(English): Every scalar is addressed by its RFC 6901 JSON Pointer so all three formats share one addressing scheme and AAD and MAC coverage stay consistent.
+type leaf struct {
+	path  string
+	typ   string
+	value string
+	set   func(typ string, value string)
+}
+
+// Why(中文): 格式相关的解析与写回收敛到一个接口，Encrypt/Decrypt 只遍历叶子与元数据，不关心具体语法。
+// Why(English): Format-specific parsing and writing sit behind one interface so Encrypt/Decrypt only walk leaves and metadata without knowing the syntax.
+type document interface {
+	leaves() []*leaf
+	meta() (map[string]string, bool)
+	setMeta(m map[string]string)
+	removeMeta()
+	encode() ([]byte, error)
+}
+
+// Why(中文): 格式名在 CLI 与库之间只有一处映射，新增格式时调用方无需改动。
+// Why(English): Keep the format-name mapping in one place so adding a format never touches call sites.
+func parseDocument(format string, data []byte) (document, error) {
+	switch format {
+	case "json":
+		return parseJSONDocument(data)
+	case "yaml":
+		return parseYAMLDocument(data)
+	case "dotenv":
+		return parseDotenvDocument(data)
+	default:
+		return nil, ErrFormat
+	}
+}
+
+// Why(中文): 按扩展名推断格式只是便利层，无法识别时返回空串交给调用方报用法错误。
+// Why(English): Extension-based detection is a convenience; unknown names return "" so callers can report a usage error.
+func DetectFormat(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".json"):
+		return "json"
+	case strings.HasSuffix(lower, ".yaml"), strings.HasSuffix(lower, ".yml"):
+		return "yaml"
+	case strings.HasSuffix(lower, ".env"), strings.HasPrefix(lower, ".env"):
+		return "dotenv"
+	default:
+		return ""
+	}
+}
+
+// Why(中文): MAC 视图统一从叶子生成，保证加密写出与解密校验看到的是完全相同的叶子集合。
+// Why(English): Build the MAC view from leaves in one place so sealing and verification always see the identical leaf set.
+func macLeaves(ls []*leaf) []lockcore.FieldLeaf {
+	out := make([]lockcore.FieldLeaf, 0, len(ls))
+	for _, l := range ls {
+		out = append(out, lockcore.FieldLeaf{Path: l.path, Type: l.typ, Value: l.value})
+	}
+	return out
+}
+
+// Why(中文): 只替换叶子值而保留键与结构，使加密后的配置仍可 diff；match 为 nil 时加密全部叶子。解密会打开每个形如 token 的字符串，所以未被选中却形如 token 的明文值直接拒绝，否则写出的文档永远无法解密。
+// Why(English): Replace only leaf values and keep keys/structure so encrypted configs stay diffable; a nil match encrypts every leaf. Decrypt opens every token-shaped string, so an unselected plaintext value that looks like a token is refused, or the output could never be decrypted.
+func Encrypt(format string, data []byte, sk []byte, path string, match *regexp.Regexp, random io.Reader) ([]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	doc, err := parseDocument(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := doc.meta(); exists {
+		return nil, ErrAlreadyEncrypted
+	}
+	salt := make([]byte, 32)
+	if _, err := io.ReadFull(random, salt); err != nil {
+		return nil, lockcore.ErrRandomRead
+	}
+	ls := doc.leaves()
+	for _, l := range ls {
+		if match != nil && !match.MatchString(l.path) {
+			if l.typ == "str" && lockcore.IsFieldTokenV1(l.value) {
+				return nil, ErrTokenLikeValue
+			}
+			continue
+		}
+		token, err := lockcore.SealFieldV1(sk, path, salt, l.path, l.typ, []byte(l.value), random)
+		if err != nil {
+			return nil, err
+		}
+		l.set("str", token)
+		l.typ, l.value = "str", token
+	}
+	mac, err := lockcore.FieldMACV1(sk, path, salt, macLeaves(ls))
+	if err != nil {
+		return nil, err
+	}
+	doc.setMeta(map[string]string{
+		"version":  versionV1,
+		"salt_b64": base64.RawStdEncoding.EncodeToString(salt),
+		"mac_b64":  mac,
+	})
+	return doc.encode()
+}
+
+// Why(中文): 先校验整文档 MAC 再逐个解密，任何增删或挪动字段都会在触碰密文前被拒绝。
+// Why(English): Verify the whole-document MAC before opening any token so added, removed or moved fields fail before decryption.
+func Decrypt(format string, data []byte, sk []byte, path string) ([]byte, error) {
+	doc, err := parseDocument(format, data)
+	if err != nil {
+		return nil, err
+	}
+	m, exists := doc.meta()
+	if !exists {
+		return nil, ErrNotEncrypted
+	}
+	if m["version"] != versionV1 {
+		return nil, ErrParse
+	}
+	salt, err := lockcore.DecodeCanonicalB64(m["salt_b64"])
+	if err == lockcore.ErrNonCanonical {
+		return nil, lockcore.ErrNonCanonical
+	}
+	if err != nil || len(salt) != 32 {
+		return nil, ErrParse
+	}
+	doc.removeMeta()
+	ls := doc.leaves()
+	if err := lockcore.VerifyFieldMACV1(sk, path, salt, macLeaves(ls), m["mac_b64"]); err != nil {
+		return nil, err
+	}
+	for _, l := range ls {
+		if l.typ != "str" || !lockcore.IsFieldTokenV1(l.value) {
+			continue
+		}
+		typ, pt, err := lockcore.OpenFieldV1(sk, path, salt, l.path, l.value)
+		if err != nil {
+			return nil, err
+		}
+		l.set(typ, string(pt))
+	}
+	return doc.encode()
+}
+
+// Why(中文): JSON Pointer 转义规则固定（~ 与 /），所有格式共用同一路径语法，正则过滤才有一致语义。
+// Why(English): JSON Pointer escaping (~ and /) is fixed and shared by all formats so regex filters mean the same thing everywhere.
+func pointerJoin(parent string, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return parent + "/" + token
+}
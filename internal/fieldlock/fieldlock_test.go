@@ -0,0 +1,134 @@
+package fieldlock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const fixturePath = "m/44'/60'/0'/0/777"
+
+func fixtureSK(t *testing.T) []byte {
+	t.Helper()
+	sk, _ := hex.DecodeString("b1ec885280602151c894fb7c17d076a2469ae59161d3b418c08e2ce0b2f2ef21")
+	return sk
+}
+
+// Why(中文): 三种格式都要保证“加密隐藏值、解密逐字节还原”，这是结构化模式对用户的核心承诺。
+// Why(English): All three formats must hide values on encrypt and restore bytes exactly on decrypt; that is the mode's core promise.
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	cases := []struct {
+		format string
+		in     string
+	}{
+		{"json", "{\n  \"db\": {\n    \"user\": \"admin\",\n    \"password\": \"s3cret<&>\",\n    \"port\": 5432,\n    \"tls\": true,\n    \"note\": null\n  },\n  \"hosts\": [\n    \"a\",\n    \"b\"\n  ]\n}\n"},
+		{"yaml", "# service config\ndb:\n  user: admin\n  password: s3cret\n  port: 5432\nhosts:\n  - a\n  - b\n"},
+		{"dotenv", "# comment\nexport DB_USER=admin\nDB_PASSWORD=\"s3cret value\"\n\nPORT=5432\n"},
+	}
+	sk := fixtureSK(t)
+	for _, tc := range cases {
+		enc, err := Encrypt(tc.format, []byte(tc.in), sk, fixturePath, nil, rand.Reader)
+		if err != nil {
+			t.Fatalf("%s: unexpected encrypt error: %v", tc.format, err)
+		}
+		if strings.Contains(string(enc), "s3cret") || strings.Contains(string(enc), "admin") {
+			t.Fatalf("%s: plaintext leaked: %s", tc.format, enc)
+		}
+		dec, err := Decrypt(tc.format, enc, sk, fixturePath)
+		if err != nil {
+			t.Fatalf("%s: unexpected decrypt error: %v", tc.format, err)
+		}
+		if string(dec) != tc.in {
+			t.Fatalf("%s: round-trip mismatch:\n%s\n---\n%s", tc.format, dec, tc.in)
+		}
+	}
+}
+
+// Why(中文): 没有末尾换行的 dotenv 文件加密后不能凭空多出换行，解密结果必须与原文逐字节一致。
+// Why(English): A dotenv file without a final newline must not gain one when encrypted, and decrypt must return the original bytes exactly.
+func TestDotenvRoundTripWithoutFinalNewline(t *testing.T) {
+	in := "DB_USER=admin\nDB_PASSWORD=s3cret"
+	sk := fixtureSK(t)
+	enc, err := Encrypt("dotenv", []byte(in), sk, fixturePath, nil, rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected encrypt error: %v", err)
+	}
+	if strings.HasSuffix(string(enc), "\n") {
+		t.Fatalf("encrypted output gained a final newline: %q", enc)
+	}
+	dec, err := Decrypt("dotenv", enc, sk, fixturePath)
+	if err != nil {
+		t.Fatalf("unexpected decrypt error: %v", err)
+	}
+	if string(dec) != in {
+		t.Fatalf("round-trip mismatch: %q != %q", dec, in)
+	}
+}
+
+// Why(中文): 正则只选择部分键时，其余值必须保持明文，保证配置仍可审阅。
+// Why(English): With a regex only matching keys are sealed and the rest stay readable for review.
+func TestEncryptRegexSelectsLeaves(t *testing.T) {
+	in := "{\n  \"user\": \"admin\",\n  \"password\": \"s3cret\"\n}\n"
+	enc, err := Encrypt("json", []byte(in), fixtureSK(t), fixturePath, regexp.MustCompile(`password$`), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected encrypt error: %v", err)
+	}
+	if !strings.Contains(string(enc), "\"admin\"") || strings.Contains(string(enc), "s3cret") {
+		t.Fatalf("unexpected selective encryption: %s", enc)
+	}
+}
+
+// Why(中文): 解密会打开所有形如 token 的字符串，未被正则选中却以 txlock:f1: 开头的明文值必须在加密时被拒绝；选中它时则正常加密并逐字节还原。
+// Why(English): Decrypt opens every token-shaped string, so an unselected plaintext value starting with txlock:f1: must be refused on encrypt; once selected it is sealed and restored byte for byte.
+func TestEncryptRejectsUnselectedTokenLikeValue(t *testing.T) {
+	in := "{\n  \"note\": \"txlock:f1:str:not-a-real-token\",\n  \"password\": \"s3cret\"\n}\n"
+	sk := fixtureSK(t)
+	if _, err := Encrypt("json", []byte(in), sk, fixturePath, regexp.MustCompile(`password$`), rand.Reader); !errors.Is(err, ErrTokenLikeValue) {
+		t.Fatalf("expected ErrTokenLikeValue, got %v", err)
+	}
+	enc, err := Encrypt("json", []byte(in), sk, fixturePath, regexp.MustCompile(`(note|password)$`), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected encrypt error: %v", err)
+	}
+	dec, err := Decrypt("json", enc, sk, fixturePath)
+	if err != nil {
+		t.Fatalf("unexpected decrypt error: %v", err)
+	}
+	if string(dec) != in {
+		t.Fatalf("round-trip mismatch:\n%s\n---\n%s", dec, in)
+	}
+}
+
+// Why(中文): 整文档 MAC 必须拦截字段删除与 token 跨键搬运，单值 AEAD 无法发现前者。
+// Why(English): The document MAC must catch field removal and token moves; per-value AEAD alone cannot detect removal.
+func TestDecryptRejectsStructuralTampering(t *testing.T) {
+	sk := fixtureSK(t)
+	in := "A=1\nB=2\n"
+	enc, err := Encrypt("dotenv", []byte(in), sk, fixturePath, nil, rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected encrypt error: %v", err)
+	}
+	lines := strings.Split(string(enc), "\n")
+	removed := strings.Join(append([]string{lines[1]}, lines[2:]...), "\n")
+	if _, err := Decrypt("dotenv", []byte(removed), sk, fixturePath); err == nil {
+		t.Fatalf("expected failure for removed field")
+	}
+	swapped := strings.Replace(string(enc), lines[0], "A="+strings.TrimPrefix(lines[1], "B="), 1)
+	swapped = strings.Replace(swapped, "\n"+lines[1]+"\n", "\nB="+strings.TrimPrefix(lines[0], "A=")+"\n", 1)
+	if _, err := Decrypt("dotenv", []byte(swapped), sk, fixturePath); err == nil {
+		t.Fatalf("expected failure for swapped values")
+	}
+	added := string(enc) + "C=3\n"
+	if _, err := Decrypt("dotenv", []byte(added), sk, fixturePath); err == nil {
+		t.Fatalf("expected failure for added field")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	if DetectFormat("a.JSON") != "json" || DetectFormat("a.yml") != "yaml" || DetectFormat(".env.prod") != "dotenv" || DetectFormat("a.txt") != "" {
+		t.Fatalf("unexpected format detection")
+	}
+}
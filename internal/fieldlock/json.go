@@ -0,0 +1,236 @@
+package fieldlock
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+type jsonNode struct {
+	kind  byte // 'o' object, 'a' array, 's' scalar
+	keys  []string
+	items []*jsonNode
+	typ   string
+	raw   string
+}
+
+type jsonDocument struct {
+	root *jsonNode
+}
+
+// Why(中文): 标准库 map 解码会打乱键顺序，自建有序树才能让加密前后的 JSON 保持同序、可 diff。
+// Why(English): Decoding into Go maps reorders keys; an ordered tree keeps JSON key order stable across encryption so diffs stay small.
+func parseJSONDocument(data []byte) (document, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root, err := readJSONNode(dec)
+	if err != nil || root.kind != 'o' {
+		return nil, ErrParse
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, ErrParse
+	}
+	return &jsonDocument{root: root}, nil
+}
+
+// Why(中文): 重复键在不同解析器中取值不一致，直接拒绝可避免 MAC 与实际生效值不一致。
+// Why(English): Duplicate keys resolve differently across parsers; rejecting them keeps the MAC aligned with the effective values.
+func readJSONNode(dec *json.Decoder) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			n := &jsonNode{kind: 'o'}
+			seen := map[string]bool{}
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := kt.(string)
+				if !ok || seen[key] {
+					return nil, ErrParse
+				}
+				seen[key] = true
+				child, err := readJSONNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key)
+				n.items = append(n.items, child)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case '[':
+			n := &jsonNode{kind: 'a'}
+			for dec.More() {
+				child, err := readJSONNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				n.items = append(n.items, child)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+		return nil, ErrParse
+	case string:
+		return &jsonNode{kind: 's', typ: "str", raw: v}, nil
+	case json.Number:
+		return &jsonNode{kind: 's', typ: "num", raw: v.String()}, nil
+	case bool:
+		return &jsonNode{kind: 's', typ: "bool", raw: strconv.FormatBool(v)}, nil
+	case nil:
+		return &jsonNode{kind: 's', typ: "null", raw: "null"}, nil
+	}
+	return nil, ErrParse
+}
+
+// Why(中文): 叶子按文档顺序收集并带回写闭包，格式无关的加解密逻辑无需了解 JSON 树结构。
+// Why(English): Collect leaves in document order with write-back closures so format-agnostic code never touches the JSON tree.
+func (d *jsonDocument) leaves() []*leaf {
+	var out []*leaf
+	var walk func(n *jsonNode, p string)
+	walk = func(n *jsonNode, p string) {
+		switch n.kind {
+		case 'o':
+			for i, k := range n.keys {
+				walk(n.items[i], pointerJoin(p, k))
+			}
+		case 'a':
+			for i, c := range n.items {
+				walk(c, pointerJoin(p, strconv.Itoa(i)))
+			}
+		default:
+			node := n
+			out = append(out, &leaf{path: p, typ: n.typ, value: n.raw, set: func(typ string, value string) {
+				node.typ, node.raw = typ, value
+			}})
+		}
+	}
+	walk(d.root, "")
+	return out
+}
+
+// Why(中文): 元数据固定放在顶层 "txlock" 键下，和 sops 的做法一致，便于人工识别文件已加密。
+// Why(English): Metadata lives under the top-level "txlock" key, sops-style, so humans can see the file is encrypted.
+func (d *jsonDocument) meta() (map[string]string, bool) {
+	for i, k := range d.root.keys {
+		if k != "txlock" {
+			continue
+		}
+		n := d.root.items[i]
+		if n.kind != 'o' {
+			return nil, true
+		}
+		m := map[string]string{}
+		for j, mk := range n.keys {
+			if n.items[j].kind == 's' {
+				m[mk] = n.items[j].raw
+			}
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// Why(中文): 元数据键按固定顺序写出，同一输入重复加密时只有随机值变化，diff 噪声最小。
+// Why(English): Write metadata keys in fixed order so re-encrypting changes only random values and keeps diff noise minimal.
+func (d *jsonDocument) setMeta(m map[string]string) {
+	n := &jsonNode{kind: 'o'}
+	for _, k := range []string{"version", "salt_b64", "mac_b64"} {
+		n.keys = append(n.keys, k)
+		n.items = append(n.items, &jsonNode{kind: 's', typ: "str", raw: m[k]})
+	}
+	d.root.keys = append(d.root.keys, "txlock")
+	d.root.items = append(d.root.items, n)
+}
+
+// Why(中文): 校验 MAC 前先移除元数据，使 MAC 覆盖范围与加密时完全一致。
+// Why(English): Strip metadata before MAC verification so the covered leaf set matches exactly what sealing saw.
+func (d *jsonDocument) removeMeta() {
+	for i, k := range d.root.keys {
+		if k == "txlock" {
+			d.root.keys = append(d.root.keys[:i], d.root.keys[i+1:]...)
+			d.root.items = append(d.root.items[:i], d.root.items[i+1:]...)
+			return
+		}
+	}
+}
+
+// Why(中文): 输出统一两空格缩进并以换行结尾，保证同一棵树总是序列化为同样的字节。
+// Why(English): Always emit two-space indentation with a trailing newline so one tree always serializes to the same bytes.
+func (d *jsonDocument) encode() ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeJSONNode(&b, d.root, ""); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// Why(中文): 非字符串叶子按原始字面量写回，必须先校验合法性，防止解密出的类型标签与内容不符时产出坏 JSON。
+// Why(English): Non-string leaves are written back as raw literals, so validate them first to never emit broken JSON on type/content mismatch.
+func writeJSONNode(b *bytes.Buffer, n *jsonNode, indent string) error {
+	switch n.kind {
+	case 'o', 'a':
+		open, closing := byte('{'), byte('}')
+		if n.kind == 'a' {
+			open, closing = '[', ']'
+		}
+		b.WriteByte(open)
+		if len(n.items) == 0 {
+			b.WriteByte(closing)
+			return nil
+		}
+		inner := indent + "  "
+		for i, c := range n.items {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString("\n" + inner)
+			if n.kind == 'o' {
+				if err := writeJSONString(b, n.keys[i]); err != nil {
+					return err
+				}
+				b.WriteString(": ")
+			}
+			if err := writeJSONNode(b, c, inner); err != nil {
+				return err
+			}
+		}
+		b.WriteString("\n" + indent)
+		b.WriteByte(closing)
+		return nil
+	}
+	if n.typ == "str" {
+		return writeJSONString(b, n.raw)
+	}
+	if !json.Valid([]byte(n.raw)) {
+		return ErrParse
+	}
+	b.WriteString(n.raw)
+	return nil
+}
+
+// Why(中文): 关闭 HTML 转义，避免 <、>、& 被改写成 \u 序列而让解密后的文件与原文产生无意义差异。
+// Why(English): Disable HTML escaping so <, > and & are not rewritten as \u escapes, which would add pointless diffs after decryption.
+func writeJSONString(b *bytes.Buffer, s string) error {
+	var tmp bytes.Buffer
+	enc := json.NewEncoder(&tmp)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	b.Write(bytes.TrimSuffix(tmp.Bytes(), []byte("\n")))
+	return nil
+}
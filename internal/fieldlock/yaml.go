@@ -0,0 +1,161 @@
+package fieldlock
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlDocument struct {
+	root *yaml.Node
+}
+
+// Why(中文): 使用 yaml.Node 而非 map 解码，可保留键顺序与注释，让加密后的 YAML 与原文逐行对应。
+// Why(English): Decode into yaml.Node rather than maps to keep key order and comments so encrypted YAML lines up with the source.
+func parseYAMLDocument(data []byte) (document, error) {
+	var root yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&root); err != nil {
+		return nil, ErrParse
+	}
+	var extra yaml.Node
+	if err := dec.Decode(&extra); err == nil {
+		return nil, ErrParse
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) != 1 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, ErrParse
+	}
+	if !yamlTreeSupported(root.Content[0]) {
+		return nil, ErrParse
+	}
+	return &yamlDocument{root: root.Content[0]}, nil
+}
+
+// Why(中文): 锚点/别名与合并键会让同一值出现在多个路径，MAC 与逐键 AAD 无法表达，直接拒绝以免产生误导性的保护。
+// Why(English): Anchors, aliases and merge keys let one value appear under many paths, which per-key AAD cannot express, so reject them.
+func yamlTreeSupported(n *yaml.Node) bool {
+	if n.Kind == yaml.AliasNode || n.Anchor != "" {
+		return false
+	}
+	if n.Kind == yaml.MappingNode {
+		seen := map[string]bool{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i]
+			if k.Kind != yaml.ScalarNode || k.Tag == "!!merge" || seen[k.Value] {
+				return false
+			}
+			seen[k.Value] = true
+		}
+	}
+	for _, c := range n.Content {
+		if !yamlTreeSupported(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Why(中文): 类型标签取 YAML 短标签去掉 "!!"，解密时据此恢复 int/bool/null 等原始类型而非全部变成字符串。
+// Why(English): The type tag is the YAML short tag without "!!" so decryption restores int/bool/null instead of turning everything into strings.
+func (d *yamlDocument) leaves() []*leaf {
+	var out []*leaf
+	var walk func(n *yaml.Node, p string)
+	walk = func(n *yaml.Node, p string) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				walk(n.Content[i+1], pointerJoin(p, n.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				walk(c, pointerJoin(p, strconv.Itoa(i)))
+			}
+		case yaml.ScalarNode:
+			node := n
+			typ := strings.TrimPrefix(n.ShortTag(), "!!")
+			if !isYAMLLeafType(typ) {
+				typ = "str"
+			}
+			out = append(out, &leaf{path: p, typ: typ, value: n.Value, set: func(typ string, value string) {
+				node.Tag = "!!" + typ
+				node.Value = value
+				node.Style = 0
+				if typ == "str" && strings.Contains(value, "\n") {
+					node.Style = yaml.LiteralStyle
+				}
+			}})
+		}
+	}
+	walk(d.root, "")
+	return out
+}
+
+// Why(中文): 只接受核心 schema 的小写标签，自定义标签无法安全写回 token 类型字段。
+// Why(English): Only core-schema lowercase tags fit the token type field; custom tags cannot be round-tripped safely.
+func isYAMLLeafType(typ string) bool {
+	switch typ {
+	case "str", "int", "float", "bool", "null", "timestamp", "binary":
+		return true
+	}
+	return false
+}
+
+// Why(中文): YAML 与 JSON 共用顶层 "txlock" 元数据键，两种格式的加密文件结构一致、易于识别。
+// Why(English): YAML shares the top-level "txlock" metadata key with JSON so encrypted files look the same across formats.
+func (d *yamlDocument) meta() (map[string]string, bool) {
+	for i := 0; i+1 < len(d.root.Content); i += 2 {
+		if d.root.Content[i].Value != "txlock" {
+			continue
+		}
+		n := d.root.Content[i+1]
+		m := map[string]string{}
+		if n.Kind == yaml.MappingNode {
+			for j := 0; j+1 < len(n.Content); j += 2 {
+				m[n.Content[j].Value] = n.Content[j+1].Value
+			}
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// Why(中文): 元数据值显式标为字符串，避免 YAML 把看起来像数字的 base64 解析成其他类型。
+// Why(English): Tag metadata values as strings explicitly so YAML never resolves number-like base64 into other types.
+func (d *yamlDocument) setMeta(m map[string]string) {
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, k := range []string{"version", "salt_b64", "mac_b64"} {
+		n.Content = append(n.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: m[k]},
+		)
+	}
+	d.root.Content = append(d.root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "txlock"}, n)
+}
+
+// Why(中文): 与 JSON 一致，MAC 校验前先剥离元数据。
+// Why(English): As with JSON, strip metadata before MAC verification.
+func (d *yamlDocument) removeMeta() {
+	for i := 0; i+1 < len(d.root.Content); i += 2 {
+		if d.root.Content[i].Value == "txlock" {
+			d.root.Content = append(d.root.Content[:i], d.root.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// Why(中文): 固定两空格缩进，使加密前后缩进风格一致，避免整文件 diff。
+// Why(English): Fix two-space indentation so encryption does not reindent the file and produce whole-file diffs.
+func (d *yamlDocument) encode() ([]byte, error) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(d.root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
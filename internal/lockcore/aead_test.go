@@ -0,0 +1,98 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Why(中文): 每个套件锁定一组确定性 SealV2 向量（固定随机源），任何 KDF、AAD 或套件实现的改动都会在这里暴露。
+// Why(English): Each suite locks a deterministic SealV2 vector (fixed randomness) so any change to KDF, AAD or suite code shows up here.
+func TestSealV2SuiteVectors(t *testing.T) {
+	cases := []struct {
+		aead, nonceB64, ctHex string
+	}{
+		{"aes-256-gcm", "QkJCQkJCQkJCQkJC", "bd36f057918f990d729d2d558c4e795da29591dc72126b25617c1e2b4cbfd683cb19eb"},
+		{"aes-256-gcm-siv", "QkJCQkJCQkJCQkJC", "3a0eed04a5e84f62da1b7e6e30d53341f5a8d45d60f154dc0c1301eaaefb096afc1899"},
+		{"xchacha20-poly1305", "QkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJC", "457bb680bc48753a67335018374279c9b64fa187dae1c109e74036741dbe0768a9ff96"},
+	}
+	pt := []byte("txlock suite vector")
+	for _, tc := range cases {
+		sealed, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", pt, SealOptionsV2{AEAD: tc.aead}, bytes.NewReader(bytes.Repeat([]byte{0x42}, 64)))
+		if err != nil {
+			t.Fatalf("%s: unexpected seal error: %v", tc.aead, err)
+		}
+		if v, _ := HeaderValue(sealed.Header, "aead"); v != tc.aead {
+			t.Fatalf("%s: header aead=%q", tc.aead, v)
+		}
+		if v, _ := HeaderValue(sealed.Header, "nonce_b64"); v != tc.nonceB64 {
+			t.Fatalf("%s: nonce=%q want=%q", tc.aead, v, tc.nonceB64)
+		}
+		if got := hex.EncodeToString(sealed.Ciphertext); got != tc.ctHex {
+			t.Fatalf("%s: ct=%s want=%s", tc.aead, got, tc.ctHex)
+		}
+		raw := BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+		h, ct, ok := ParseEnvelopeV2(raw)
+		if !ok {
+			t.Fatalf("%s: unexpected parse failure", tc.aead)
+		}
+		got, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/777", h, ct, OpenOptionsV2{})
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Fatalf("%s: round-trip mismatch: err=%v", tc.aead, err)
+		}
+	}
+}
+
+// Why(中文): 套件名同时进入 INFO 与 AAD，把头字段换成另一套件（即便 nonce 长度相同）也必须认证失败。
+// Why(English): The suite name feeds both INFO and AAD, so swapping the header to another suite, even one with the same nonce size, must fail auth.
+func TestOpenV2RejectsSuiteSwap(t *testing.T) {
+	sealed, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", []byte("hello"), SealOptionsV2{AEAD: "aes-256-gcm-siv"}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	swapped := append([]HeaderField(nil), sealed.Header...)
+	swapped[1].Value = "aes-256-gcm"
+	if _, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/777", swapped, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+	swapped[1].Value = "xchacha20-poly1305"
+	if _, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/777", swapped, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for nonce-size mismatch, got %v", err)
+	}
+}
+
+// Why(中文): 未登记的套件名在加密与解析两侧都被拒绝，不会回退到默认套件。
+// Why(English): Unregistered suite names are refused on both seal and parse and never fall back to the default.
+func TestAEADV2RejectsUnknownSuite(t *testing.T) {
+	if _, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", []byte("x"), SealOptionsV2{AEAD: "chacha20-poly1305"}, bytes.NewReader(make([]byte, 64))); err != ErrEncrypt {
+		t.Fatalf("expected ErrEncrypt, got %v", err)
+	}
+	if IsAEADV2("AES-256-GCM") || IsAEADV2("") {
+		t.Fatalf("suite names must be exact")
+	}
+	if got := AEADNamesV2(); len(got) != 3 || got[0] != "aes-256-gcm" {
+		t.Fatalf("unexpected suite list: %v", got)
+	}
+}
+
+// Why(中文): XChaCha20-Poly1305 原语用 draft-irtf-cfrg-xchacha A.3.1 向量校验，确认注册表接入的是正确的构造。
+// Why(English): Check the XChaCha20-Poly1305 primitive against draft-irtf-cfrg-xchacha A.3.1 to confirm the registry wires the right construction.
+func TestXChaCha20Poly1305DraftVector(t *testing.T) {
+	suite := aeadSuitesV2["xchacha20-poly1305"]
+	a, err := suite.newAEAD(mustHex(t, "808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.NonceSize() != chacha20poly1305.NonceSizeX {
+		t.Fatalf("unexpected nonce size %d", a.NonceSize())
+	}
+	pt := mustHex(t, "4c616469657320616e642047656e746c656d656e206f662074686520636c617373206f66202739393a204966204920636f756c64206f6666657220796f75206f6e6c79206f6e652074697020666f7220746865206675747572652c2073756e73637265656e20776f756c642062652069742e")
+	got := a.Seal(nil, mustHex(t, "404142434445464748494a4b4c4d4e4f5051525354555657"), pt, mustHex(t, "50515253c0c1c2c3c4c5c6c7"))
+	want := "bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b4522f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff921f9664c97637da9768812f615c68b13b52ec0875924c1c7987947deafd8780acf49"
+	if hex.EncodeToString(got) != want {
+		t.Fatalf("xchacha vector mismatch: %x", got)
+	}
+}
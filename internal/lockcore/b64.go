@@ -0,0 +1,66 @@
+package lockcore
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+var ErrNonCanonical = errors.New("non-canonical base64 encoding")
+
+// Why(中文): RawStdEncoding 会忽略换行并接受末字符尾部比特非零的写法，同一字节串因此有多种拼写；这里要求“解码后再编码相等”（dec-plan §7），把可塑的写法归为独立错误类而不是笼统的解密失败。
+// Why(English): RawStdEncoding skips newlines and accepts a last character with non-zero trailing bits, so one byte string has several spellings; requiring decode-then-encode equality (dec-plan §7) puts malleable spellings in their own error class instead of a generic decrypt failure.
+func DecodeCanonicalB64(s string) ([]byte, error) {
+	out, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if base64.RawStdEncoding.EncodeToString(out) != s {
+		return nil, ErrNonCanonical
+	}
+	return out, nil
+}
+
+// Why(中文): 解析器只返回 bool；解析失败后 CLI 用它判断原因是否为非规范 base64，从而给出独立诊断。只看 *_b64 头字段与密文区，不做其余语法校验。
+// Why(English): Parsers return only a bool; after a parse failure the CLI uses this to tell whether non-canonical base64 was the cause and report it separately. It inspects only *_b64 header values and the ciphertext block and performs no other syntax checks.
+func CheckCanonicalB64(raw []byte) error {
+	var values []string
+	if IsBinaryEnvelope(raw) {
+		rest := raw
+		if len(rest) >= len(binaryMagic)+2 {
+			rest = rest[len(binaryMagic)+2:]
+		}
+		for len(rest) >= 3 && rest[0] != 0 {
+			n := int(binary.BigEndian.Uint16(rest[1:]))
+			if len(rest) < 3+n {
+				break
+			}
+			if int(rest[0]) < len(binaryTagsV1) && strings.HasSuffix(binaryTagsV1[rest[0]], "_b64") {
+				values = append(values, string(rest[3:3+n]))
+			}
+			rest = rest[3+n:]
+		}
+	} else {
+		lines := strings.Split(string(raw), "\n")
+		for i := 0; i < len(lines); i++ {
+			if lines[i] == "ct_b64:" {
+				var ct strings.Builder
+				for i++; i < len(lines) && lines[i] != "-->"; i++ {
+					ct.WriteString(lines[i])
+				}
+				values = append(values, ct.String())
+				continue
+			}
+			if key, value, ok := strings.Cut(lines[i], ":"); ok && strings.HasSuffix(key, "_b64") && value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	for _, v := range values {
+		if _, err := DecodeCanonicalB64(v); err == ErrNonCanonical {
+			return ErrNonCanonical
+		}
+	}
+	return nil
+}
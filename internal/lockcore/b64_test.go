@@ -0,0 +1,111 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// Why(中文): 把末字符换成只差尾部比特的字符，得到解码结果相同但拼写不同的可塑写法。
+// Why(English): Swap the last character for one that differs only in trailing bits, giving a malleable spelling that decodes to the same bytes.
+func malleateB64(s string) string {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	i := strings.IndexByte(alphabet, s[len(s)-1])
+	return s[:len(s)-1] + string(alphabet[i^1])
+}
+
+// Why(中文): 规范串正常解码；尾部比特非零与夹带 \r 属于非规范（独立错误类）；非法字符仍是普通解码错误。
+// Why(English): Canonical strings decode; non-zero trailing bits and an embedded \r are non-canonical, a separate error class; invalid characters stay ordinary decode errors.
+func TestDecodeB64Canonical(t *testing.T) {
+	s := base64.RawStdEncoding.EncodeToString([]byte("hello txlock"))
+	if got, err := DecodeCanonicalB64(s); err != nil || string(got) != "hello txlock" {
+		t.Fatalf("canonical: %q %v", got, err)
+	}
+	odd := base64.RawStdEncoding.EncodeToString([]byte("hello"))
+	if _, err := DecodeCanonicalB64(malleateB64(odd)); err != ErrNonCanonical {
+		t.Fatalf("trailing bits: expected ErrNonCanonical, got %v", err)
+	}
+	if _, err := DecodeCanonicalB64(s[:4] + "\r" + s[4:]); err != ErrNonCanonical {
+		t.Fatalf("embedded CR: expected ErrNonCanonical, got %v", err)
+	}
+	if _, err := DecodeCanonicalB64("ab=c"); err == nil || err == ErrNonCanonical {
+		t.Fatalf("invalid input: expected a decode error, got %v", err)
+	}
+}
+
+// Why(中文): 可塑的 salt/nonce/commit 必须在任何密钥派生之前以 ErrNonCanonical 拒绝：计数型密钥来源证明 v2/v3 路径从未调用派生。
+// Why(English): Malleable salt, nonce or commit must be rejected with ErrNonCanonical before any key derivation; a counting key source proves the v2/v3 path never derives.
+func TestOpenRejectsNonCanonicalBeforeDerivation(t *testing.T) {
+	sk := fixtureSKV2()
+	path := "m/44'/60'/0'/0/777"
+	rnd := bytes.Repeat([]byte{7}, 44)
+	v1, err := SealV1(sk, path, []byte("hi"), bytes.NewReader(rnd))
+	if err != nil {
+		t.Fatalf("seal v1: %v", err)
+	}
+	if _, err := OpenV1(sk, path, malleateB64(v1.SaltB64), v1.NonceB64, v1.Ciphertext); err != ErrNonCanonical {
+		t.Fatalf("v1 salt: expected ErrNonCanonical, got %v", err)
+	}
+	if _, err := OpenV1(sk, path, v1.SaltB64, v1.NonceB64+"\r", v1.Ciphertext); err != ErrNonCanonical {
+		t.Fatalf("v1 nonce: expected ErrNonCanonical, got %v", err)
+	}
+	v3, err := SealV2(sk, path, []byte("hi"), SealOptionsV2{Commit: true}, bytes.NewReader(rnd))
+	if err != nil {
+		t.Fatalf("seal v3: %v", err)
+	}
+	for _, key := range []string{"salt_b64", "commit_b64"} {
+		h := append([]HeaderField(nil), v3.Header...)
+		for i := range h {
+			if h[i].Key == key {
+				h[i].Value = malleateB64(h[i].Value)
+			}
+		}
+		derivations := 0
+		src := keySourceV2{path: path, ikm: func([]byte) ([]byte, bool) { derivations++; return sk, true }}
+		if _, _, err := openV2(src, h, v3.Ciphertext, OpenOptionsV2{}); err != ErrNonCanonical || derivations != 0 {
+			t.Fatalf("%s: expected ErrNonCanonical without derivation, got %v after %d derivations", key, err, derivations)
+		}
+	}
+}
+
+// Why(中文): 解析器整体拒绝非规范信封，CheckCanonicalB64 则能指出原因；文本 v1、文本 v3 与二进制容器三种形态都要覆盖，规范信封不得误报。
+// Why(English): Parsers reject non-canonical envelopes outright while CheckCanonicalB64 names the cause; text v1, text v3 and the binary container are all covered, and canonical envelopes must not be flagged.
+func TestParsersRejectNonCanonicalAndCheckNamesIt(t *testing.T) {
+	sk := fixtureSKV2()
+	path := "m/44'/60'/0'/0/777"
+	rnd := bytes.Repeat([]byte{7}, 44)
+	v1, _ := SealV1(sk, path, []byte("hey!"), bytes.NewReader(rnd))
+	ct1 := base64.RawStdEncoding.EncodeToString(v1.Ciphertext)
+	text1 := BuildEnvelopeV1(path, v1.SaltB64, v1.NonceB64, ct1)
+	v3, _ := SealV2(sk, path, []byte("hi"), SealOptionsV2{Commit: true}, bytes.NewReader(rnd))
+	text3 := BuildEnvelopeV2(v3.Header, base64.RawStdEncoding.EncodeToString(v3.Ciphertext))
+	commit, _ := HeaderValue(v3.Header, "commit_b64")
+	bin, ok := BuildBinaryEnvelope("v3", v3.Header, v3.Ciphertext)
+	if !ok {
+		t.Fatalf("build binary")
+	}
+	for _, raw := range []string{text1, text3, string(bin)} {
+		if err := CheckCanonicalB64([]byte(raw)); err != nil {
+			t.Fatalf("canonical envelope flagged: %v", err)
+		}
+	}
+	salt3, _ := HeaderValue(v3.Header, "salt_b64")
+	badText := []string{
+		strings.Replace(text1, v1.SaltB64, malleateB64(v1.SaltB64), 1),
+		strings.Replace(text1, v1.NonceB64, v1.NonceB64+"\r", 1),
+		strings.Replace(text1, ct1, malleateB64(ct1), 1),
+		strings.Replace(text3, commit, malleateB64(commit), 1),
+	}
+	for i, raw := range badText {
+		_, _, _, _, ok1 := ParseEnvelopeV1(raw)
+		_, _, ok2 := ParseEnvelopeV2(raw)
+		if ok1 || ok2 || CheckCanonicalB64([]byte(raw)) != ErrNonCanonical {
+			t.Fatalf("case %d: expected parse reject and ErrNonCanonical", i)
+		}
+	}
+	badBin := bytes.Replace(bin, []byte(salt3), []byte(malleateB64(salt3)), 1)
+	if _, _, _, ok := ParseBinaryEnvelope(badBin); ok || CheckCanonicalB64(badBin) != ErrNonCanonical {
+		t.Fatalf("binary: expected parse reject and ErrNonCanonical")
+	}
+}
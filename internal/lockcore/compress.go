@@ -0,0 +1,74 @@
+package lockcore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// Why(中文): 调用方未设上限时使用的默认解压上限，足够覆盖常见文件又能挡住压缩炸弹。
+// Why(English): Default decompression cap when callers set none; large enough for normal files, small enough to stop zip bombs.
+const DefaultMaxPlaintext = 1 << 30
+
+var (
+	ErrCompression = errors.New("compression failed")
+	ErrTooLarge    = errors.New("decompressed size exceeds limit")
+)
+
+// Why(中文): 只提供标准库可实现的 gzip，保证 10 年后仅凭 Go 标准库即可恢复，不引入第三方压缩依赖。
+// Why(English): Offer only stdlib gzip so recovery a decade from now needs nothing beyond the Go standard library.
+func isCompressionV2(name string) bool {
+	return name == "gzip"
+}
+
+// Why(中文): 固定压缩级别且 gzip 头不写文件名与时间，保证同一输入压缩结果稳定、不额外泄露元数据。
+// Why(English): Use a fixed level and leave the gzip name/mtime empty so output is stable and leaks no extra metadata.
+func compressV2(name string, plaintext []byte) ([]byte, error) {
+	if !isCompressionV2(name) {
+		return nil, ErrCompression
+	}
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, ErrCompression
+	}
+	if _, err := zw.Write(plaintext); err != nil {
+		return nil, ErrCompression
+	}
+	if err := zw.Close(); err != nil {
+		return nil, ErrCompression
+	}
+	return buf.Bytes(), nil
+}
+
+// Why(中文): 解压必须带上限并拒绝多成员拼接，防止认证通过的压缩炸弹耗尽内存。Multistream(false) 只会在第一个成员结束处停下，因此读完后还要确认底层数据已耗尽，第二个成员或尾随垃圾都报 ErrCompression。
+// Why(English): Decompression is capped and rejects concatenated members so an authenticated zip bomb cannot exhaust memory. Multistream(false) merely stops at the end of the first member, so the underlying data must also be exhausted afterwards; a second member or trailing garbage is ErrCompression.
+func decompressV2(name string, data []byte, limit int64) ([]byte, error) {
+	if !isCompressionV2(name) {
+		return nil, ErrCompression
+	}
+	if limit <= 0 {
+		limit = DefaultMaxPlaintext
+	}
+	src := bytes.NewReader(data)
+	zr, err := gzip.NewReader(src)
+	if err != nil {
+		return nil, ErrCompression
+	}
+	zr.Multistream(false)
+	out, err := io.ReadAll(io.LimitReader(zr, limit+1))
+	if err != nil {
+		return nil, ErrCompression
+	}
+	if int64(len(out)) > limit {
+		return nil, ErrTooLarge
+	}
+	if err := zr.Close(); err != nil {
+		return nil, ErrCompression
+	}
+	if src.Len() != 0 {
+		return nil, ErrCompression
+	}
+	return out, nil
+}
@@ -0,0 +1,28 @@
+package lockcore
+
+import "testing"
+
+// Why(中文): 单个成员正常解压；拼接的第二个成员与尾随垃圾都必须报 ErrCompression，而不是悄悄丢弃第一个成员之后的字节。
+// Why(English): A single member decompresses; a concatenated second member and trailing garbage must both be ErrCompression instead of silently dropping bytes after the first member.
+func TestDecompressV2RejectsTrailingData(t *testing.T) {
+	first, err := compressV2("gzip", []byte("first member"))
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	second, err := compressV2("gzip", []byte("second member"))
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if got, err := decompressV2("gzip", first, 0); err != nil || string(got) != "first member" {
+		t.Fatalf("single member: %q %v", got, err)
+	}
+	for name, data := range map[string][]byte{
+		"two members":      append(append([]byte(nil), first...), second...),
+		"trailing garbage": append(append([]byte(nil), first...), []byte("junk")...),
+		"trailing zero":    append(append([]byte(nil), first...), 0),
+	} {
+		if _, err := decompressV2("gzip", data, 0); err != ErrCompression {
+			t.Fatalf("%s: expected ErrCompression, got %v", name, err)
+		}
+	}
+}
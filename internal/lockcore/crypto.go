@@ -147,11 +147,17 @@ func OpenV1(sk []byte, path string, saltB64 string, nonceB64 string, ciphertext
 	if !isPathV1(path) {
 		return nil, ErrInvalidPath
 	}
-	salt, err := base64.RawStdEncoding.DecodeString(saltB64)
+	salt, err := DecodeCanonicalB64(saltB64)
+	if err == ErrNonCanonical {
+		return nil, ErrNonCanonical
+	}
 	if err != nil || len(salt) != 32 {
 		return nil, ErrDecrypt
 	}
-	nonce, err := base64.RawStdEncoding.DecodeString(nonceB64)
+	nonce, err := DecodeCanonicalB64(nonceB64)
+	if err == ErrNonCanonical {
+		return nil, ErrNonCanonical
+	}
 	if err != nil || len(nonce) != 12 {
 		return nil, ErrDecrypt
 	}
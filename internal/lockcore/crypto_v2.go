@@ -0,0 +1,385 @@
+package lockcore
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type SealOptionsV2 struct {
+	AEAD          string
+	Commit        bool
+	Meta          *FileMetaV2
+	Compress      string
+	Pad           string
+	Deterministic bool
+	Label         string
+	HideLabel     bool
+	ID            string
+	Seq           uint64
+}
+
+type OpenOptionsV2 struct {
+	MaxPlaintext int64
+	Label        string
+	MaxKDF       Argon2ParamsV2
+}
+
+type SealResultV2 struct {
+	Header     []HeaderField
+	Ciphertext []byte
+}
+
+// Why(中文): 只有含钱包因子的 kdf 才绑定链与路径；纯口令模式没有 BIP44 路径，INFO 与 AAD 中省略这两项而不是填占位值。
+// Why(English): Only kdfs with a wallet factor bind chain and path; pure password mode has no BIP44 path, so INFO and AAD omit both instead of carrying placeholders.
+func bindsWalletPathV2(h []HeaderField) bool {
+	kdf, _ := HeaderValue(h, "kdf")
+	return kdf == "hkdf-sha256" || kdf == KDFHybridV2
+}
+
+// Why(中文): v2 的 INFO 由版本与头字段中的 kdf/aead 组合而成，算法名或版本变化会自动产生不同的 K，实现跨套件域分离。
+// Why(English): The v2 INFO is composed from the version and the header's kdf/aead so a different algorithm name or version always yields a different K.
+func infoV2(version string, h []HeaderField) []byte {
+	kdf, _ := HeaderValue(h, "kdf")
+	aead, _ := HeaderValue(h, "aead")
+	if !bindsWalletPathV2(h) {
+		return []byte("txlock:" + version + "|kdf=" + kdf + "|aead=" + aead)
+	}
+	return []byte("txlock:" + version + "|chain=ethereum|path=bip44|kdf=" + kdf + "|aead=" + aead)
+}
+
+// Why(中文): v2 的 AAD 就是头字段逐行序列化再加上不落盘的 path，任何头字段（含压缩标记）改动都会认证失败。
+// Why(English): The v2 AAD is the header serialized line by line plus the unwritten path, so any header change, compression flag included, fails auth.
+func buildAADV2(version string, path string, h []HeaderField) []byte {
+	var b []byte
+	b = append(b, "txlock:"+version+"\n"...)
+	if bindsWalletPathV2(h) {
+		b = append(b, "chain:ethereum\npath:"+path+"\n"...)
+	}
+	for _, f := range h {
+		b = append(b, f.Key+":"+f.Value+"\n"...)
+	}
+	return b
+}
+
+// Why(中文): 明文变换（压缩等）统一在 AEAD 之内完成，顺序与头字段一一对应，解密侧按相反顺序还原。
+// Why(English): Plaintext transforms such as compression run inside the AEAD in header order, and decryption reverses them in the opposite order.
+func SealV2(sk []byte, path string, plaintext []byte, opts SealOptionsV2, random io.Reader) (*SealResultV2, error) {
+	if len(sk) != 32 {
+		return nil, ErrInvalidSK
+	}
+	if !isPathV1(path) {
+		return nil, ErrInvalidPath
+	}
+	if opts.Label == "" {
+		if opts.HideLabel {
+			return nil, ErrInvalidLabel
+		}
+		return sealV2(walletSourceV2(sk, path), plaintext, opts, random)
+	}
+	subkey, err := LabelKey(sk, opts.Label)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytesV2(subkey)
+	src := walletSourceV2(subkey, path)
+	if !opts.HideLabel {
+		src.kdf = append(src.kdf, HeaderField{Key: "label", Value: opts.Label})
+	}
+	return sealV2(src, plaintext, opts, random)
+}
+
+// Why(中文): 带标签时以子密钥替代 sk 进入同一条钱包流程；标签写入头字段则解密侧无需额外输入，隐藏时必须由调用方提供且须与头字段一致。
+// Why(English): With a label the subkey replaces sk in the same wallet flow; a header label needs no extra input to open, while a hidden one must come from the caller and agree with any header value.
+func labelKeyV2(sk []byte, h []HeaderField, label string) ([]byte, error) {
+	if stored, ok := HeaderValue(h, "label"); ok {
+		if label != "" && label != stored {
+			return nil, ErrLabelMismatch
+		}
+		label = stored
+	}
+	if label == "" {
+		return nil, nil
+	}
+	return LabelKey(sk, label)
+}
+
+// Why(中文): 子密钥只在单次调用内存活，用完即清零，与 CLI 处理 sk 的方式一致。
+// Why(English): A subkey lives only for one call and is zeroed afterwards, matching how the CLI treats sk.
+func wipeBytesV2(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// Why(中文): 密钥来源（钱包 SK 或口令）只决定 kdf 头字段与 HKDF 的输入密钥材料，其余封装、变换与 AEAD 流程完全共用。
+// Why(English): The key source, wallet SK or passphrase, decides only the kdf header fields and the HKDF input keying material; everything else is shared.
+type keySourceV2 struct {
+	path   string
+	kdf    []HeaderField
+	ikm    func(salt []byte) ([]byte, bool)
+	detKey []byte
+}
+
+// Why(中文): 钱包模式下 SK 直接作为 HKDF 输入，保持既有 v2/v3 输出逐字节不变；SK 同时是确定性模式的 HMAC 密钥，口令类来源的 IKM 依赖 salt，因此不提供 detKey。
+// Why(English): In wallet mode the SK feeds HKDF directly, keeping existing v2/v3 output byte-identical; it also keys deterministic mode, which password sources cannot offer because their IKM depends on the salt.
+func walletSourceV2(sk []byte, path string) keySourceV2 {
+	return keySourceV2{
+		path:   path,
+		kdf:    []HeaderField{{Key: "kdf", Value: "hkdf-sha256"}},
+		ikm:    func([]byte) ([]byte, bool) { return sk, true },
+		detKey: sk,
+	}
+}
+
+// Why(中文): 封装核心与密钥来源无关，新增来源时不会复制压缩、填充、承诺等逻辑。
+// Why(English): The sealing core is source-agnostic so new key sources never duplicate compression, padding or commitment logic.
+func sealV2(src keySourceV2, plaintext []byte, opts SealOptionsV2, random io.Reader) (*SealResultV2, error) {
+	if random == nil && !opts.Deterministic {
+		return nil, ErrRandomRead
+	}
+	if opts.Deterministic && src.detKey == nil {
+		return nil, ErrEncrypt
+	}
+	if (opts.ID != "" || opts.Seq != 0) && (!ValidLabel(opts.ID) || opts.Seq == 0) {
+		return nil, ErrInvalidSequence
+	}
+	aeadName := opts.AEAD
+	if aeadName == "" {
+		aeadName = DefaultAEADV2
+	}
+	suite, ok := aeadSuitesV2[aeadName]
+	if !ok {
+		return nil, ErrEncrypt
+	}
+	h := append([]HeaderField(nil), src.kdf...)
+	if opts.ID != "" {
+		h = append(h, HeaderField{Key: "id", Value: opts.ID}, HeaderField{Key: "seq", Value: strconv.FormatUint(opts.Seq, 10)})
+	}
+	h = append(h, HeaderField{Key: "aead", Value: aeadName})
+	payload := plaintext
+	if opts.Meta != nil {
+		framed, err := frameMetaV2(*opts.Meta, payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = framed
+		h = append(h, HeaderField{Key: "meta", Value: "json"})
+	}
+	if opts.Compress != "" {
+		z, err := compressV2(opts.Compress, payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = z
+		h = append(h, HeaderField{Key: "compress", Value: opts.Compress})
+	}
+	if opts.Pad != "" {
+		padded, err := padV2(opts.Pad, payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = padded
+		h = append(h, HeaderField{Key: "pad", Value: opts.Pad})
+	}
+	version := "v2"
+	if opts.Commit {
+		version = "v3"
+	}
+	salt := make([]byte, 32)
+	nonce := make([]byte, suite.nonceSize)
+	if opts.Deterministic {
+		h = append(h, HeaderField{Key: "det", Value: DeterministicV2})
+		salt, nonce = deterministicIVV2(src.detKey, version, src.path, h, payload, suite.nonceSize)
+	} else {
+		if _, err := io.ReadFull(random, salt); err != nil {
+			return nil, ErrRandomRead
+		}
+		if _, err := io.ReadFull(random, nonce); err != nil {
+			return nil, ErrRandomRead
+		}
+	}
+	h = append(h,
+		HeaderField{Key: "salt_b64", Value: base64.RawStdEncoding.EncodeToString(salt)},
+		HeaderField{Key: "nonce_b64", Value: base64.RawStdEncoding.EncodeToString(nonce)},
+	)
+	ikm, ok := src.ikm(salt)
+	if !ok {
+		return nil, ErrEncrypt
+	}
+	key, commitKey := deriveKeysV2(ikm, salt, version, h)
+	if opts.Commit {
+		h = append(h, HeaderField{Key: "commit_b64", Value: base64.RawStdEncoding.EncodeToString(keyCommitmentV3(commitKey, buildAADV2(version, src.path, h)))})
+	}
+	aead, ok := newAEADV2(key, h)
+	if !ok {
+		return nil, ErrEncrypt
+	}
+	return &SealResultV2{Header: h, Ciphertext: aead.Seal(nil, nonce, payload, buildAADV2(version, src.path, h))}, nil
+}
+
+// Why(中文): 不关心元数据的调用方保持原有签名，元数据块（若有）在此被校验后丢弃。
+// Why(English): Callers that ignore metadata keep the original signature; any metadata block is still verified, then dropped.
+func OpenV2(sk []byte, path string, h []HeaderField, ciphertext []byte, opts OpenOptionsV2) ([]byte, error) {
+	pt, _, err := OpenV2Meta(sk, path, h, ciphertext, opts)
+	return pt, err
+}
+
+// Why(中文): 先认证再解压，未通过 AEAD 的数据绝不会进入解压器，避免把解压器暴露给攻击者构造的输入。
+// Why(English): Authenticate before decompressing so unauthenticated bytes never reach the decompressor.
+func OpenV2Meta(sk []byte, path string, h []HeaderField, ciphertext []byte, opts OpenOptionsV2) ([]byte, *FileMetaV2, error) {
+	if len(sk) != 32 {
+		return nil, nil, ErrInvalidSK
+	}
+	if !isPathV1(path) {
+		return nil, nil, ErrInvalidPath
+	}
+	if kdf, _ := HeaderValue(h, "kdf"); kdf != "hkdf-sha256" {
+		return nil, nil, ErrDecrypt
+	}
+	subkey, err := labelKeyV2(sk, h, opts.Label)
+	if err != nil {
+		return nil, nil, err
+	}
+	if subkey != nil {
+		defer wipeBytesV2(subkey)
+		sk = subkey
+	}
+	return openV2(walletSourceV2(sk, path), h, ciphertext, opts)
+}
+
+// Why(中文): 解封核心同样与密钥来源无关；调用方负责先确认头字段 kdf 与所选来源一致。
+// Why(English): The opening core is likewise source-agnostic; callers first confirm the header kdf matches the chosen source.
+func openV2(src keySourceV2, h []HeaderField, ciphertext []byte, opts OpenOptionsV2) ([]byte, *FileMetaV2, error) {
+	if !validHeaderValuesV2(h) {
+		return nil, nil, ErrDecrypt
+	}
+	if !canonicalHeaderB64V2(h) {
+		return nil, nil, ErrNonCanonical
+	}
+	saltB64, _ := HeaderValue(h, "salt_b64")
+	nonceB64, _ := HeaderValue(h, "nonce_b64")
+	salt, err := base64.RawStdEncoding.DecodeString(saltB64)
+	if err != nil || len(salt) != 32 {
+		return nil, nil, ErrDecrypt
+	}
+	version := headerVersionV2(h)
+	ikm, ok := src.ikm(salt)
+	if !ok {
+		return nil, nil, ErrDecrypt
+	}
+	key, commitKey := deriveKeysV2(ikm, salt, version, h)
+	if version == "v3" && !verifyKeyCommitmentV3(commitKey, src.path, h) {
+		return nil, nil, ErrDecrypt
+	}
+	aead, ok := newAEADV2(key, h)
+	if !ok {
+		return nil, nil, ErrDecrypt
+	}
+	nonce, err := base64.RawStdEncoding.DecodeString(nonceB64)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, nil, ErrDecrypt
+	}
+	payload, err := aead.Open(nil, nonce, ciphertext, buildAADV2(version, src.path, h))
+	if err != nil {
+		return nil, nil, ErrDecrypt
+	}
+	if _, det := HeaderValue(h, "det"); det && (src.detKey == nil || !verifyDeterministicIVV2(src.detKey, version, src.path, h, payload)) {
+		return nil, nil, ErrDecrypt
+	}
+	if name, exists := HeaderValue(h, "pad"); exists {
+		if payload, err = unpadV2(name, payload); err != nil {
+			return nil, nil, err
+		}
+	}
+	if name, exists := HeaderValue(h, "compress"); exists {
+		if payload, err = decompressV2(name, payload, opts.MaxPlaintext); err != nil {
+			return nil, nil, err
+		}
+	}
+	if _, exists := HeaderValue(h, "meta"); exists {
+		meta, body, err := unframeMetaV2(payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		return body, meta, nil
+	}
+	return payload, nil, nil
+}
+
+// Why(中文): 在任何密钥派生（HKDF 或 Argon2id）之前检查全部 *_b64 头字段，可塑的写法在消耗派生成本前就以独立错误类拒绝。
+// Why(English): Check every *_b64 header value before any key derivation, HKDF or Argon2id, so malleable spellings are rejected in their own error class before derivation cost is spent.
+func canonicalHeaderB64V2(h []HeaderField) bool {
+	for _, f := range h {
+		if strings.HasSuffix(f.Key, "_b64") {
+			if _, err := DecodeCanonicalB64(f.Value); err == ErrNonCanonical {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Why(中文): v3 从同一次 HKDF 输出中同时取出加密密钥与承诺密钥，两者绑定同一 (SK, salt, INFO)；v2 只取加密密钥，输出不变。
+// Why(English): v3 takes the encryption key and the commitment key from one HKDF output so both bind the same (SK, salt, INFO); v2 takes only the encryption key and is unchanged.
+func deriveKeysV2(ikm []byte, salt []byte, version string, h []HeaderField) ([]byte, []byte) {
+	if version != "v3" {
+		return hkdfSHA256(ikm, salt, infoV2(version, h), 32), nil
+	}
+	okm := hkdfSHA256(ikm, salt, infoV2(version, h), 64)
+	return okm[:32], okm[32:]
+}
+
+// Why(中文): 承诺值是对除 commit_b64 外全部 AAD 的 HMAC；HMAC-SHA256 抗碰撞，攻击者无法构造出两把 SK 都能通过的同一承诺。
+// Why(English): The commitment is an HMAC over the whole AAD except commit_b64; HMAC-SHA256 is collision resistant, so no single commitment can verify under two SKs.
+func keyCommitmentV3(commitKey []byte, aad []byte) []byte {
+	mac := hmac.New(sha256.New, commitKey)
+	_, _ = mac.Write([]byte("txlock:v3|commit\n"))
+	_, _ = mac.Write(aad)
+	return mac.Sum(nil)
+}
+
+// Why(中文): 承诺在调用 AEAD Open 之前以常数时间校验，错误密钥永远到不了非承诺的 GCM 认证，分区预言攻击因此失效。
+// Why(English): Check the commitment in constant time before AEAD Open so a wrong key never reaches the non-committing GCM check, defeating partitioning oracles.
+func verifyKeyCommitmentV3(commitKey []byte, path string, h []HeaderField) bool {
+	var rest []HeaderField
+	var want []byte
+	for _, f := range h {
+		if f.Key != "commit_b64" {
+			rest = append(rest, f)
+			continue
+		}
+		raw, err := base64.RawStdEncoding.DecodeString(f.Value)
+		if err != nil || len(raw) != sha256.Size {
+			return false
+		}
+		want = raw
+	}
+	if want == nil {
+		return false
+	}
+	got := keyCommitmentV3(commitKey, buildAADV2("v3", path, rest))
+	return hmac.Equal(got, want)
+}
+
+// Why(中文): 套件由头字段 aead 从注册表选出，密钥派生已在调用方完成，加解密两侧共享同一构造路径。
+// Why(English): The header's aead picks the suite from the registry; key derivation is done by the caller so sealing and opening share one construction path.
+func newAEADV2(key []byte, h []HeaderField) (cipher.AEAD, bool) {
+	if len(key) != 32 {
+		return nil, false
+	}
+	name, _ := HeaderValue(h, "aead")
+	suite, ok := aeadSuitesV2[name]
+	if !ok {
+		return nil, false
+	}
+	aead, err := suite.newAEAD(key)
+	if err != nil {
+		return nil, false
+	}
+	return aead, true
+}
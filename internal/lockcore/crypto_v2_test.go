@@ -0,0 +1,156 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func fixtureSKV2() []byte {
+	sk, _ := hex.DecodeString("b1ec885280602151c894fb7c17d076a2469ae59161d3b418c08e2ce0b2f2ef21")
+	return sk
+}
+
+// Why(中文): 压缩开启时密文应明显短于明文，且解密后逐字节还原，证明压缩确实发生在 AEAD 之内。
+// Why(English): With compression the ciphertext must be much shorter than the plaintext and open byte-exact, proving compression runs inside the AEAD.
+func TestSealOpenV2Compressed(t *testing.T) {
+	pt := []byte(strings.Repeat("# heading\nsome markdown body text\n", 200))
+	sealed, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", pt, SealOptionsV2{Compress: "gzip"}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	if len(sealed.Ciphertext) >= len(pt)/5 {
+		t.Fatalf("expected compressed ciphertext, got %d bytes for %d", len(sealed.Ciphertext), len(pt))
+	}
+	raw := BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	h, ct, ok := ParseEnvelopeV2(raw)
+	if !ok {
+		t.Fatalf("unexpected parse failure")
+	}
+	got, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/777", h, ct, OpenOptionsV2{})
+	if err != nil || !bytes.Equal(got, pt) {
+		t.Fatalf("round-trip mismatch: err=%v", err)
+	}
+}
+
+// Why(中文): 压缩标记受 AAD 保护，删除该头字段必须认证失败而不是返回压缩字节。
+// Why(English): The compression flag is AAD-bound; stripping it must fail auth instead of returning compressed bytes.
+func TestOpenV2RejectsCompressFlagStrip(t *testing.T) {
+	sealed, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", []byte("hello"), SealOptionsV2{Compress: "gzip"}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	var stripped []HeaderField
+	for _, f := range sealed.Header {
+		if f.Key != "compress" {
+			stripped = append(stripped, f)
+		}
+	}
+	if _, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/777", stripped, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+	if _, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/778", sealed.Header, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for path drift, got %v", err)
+	}
+}
+
+// Why(中文): 解压上限是压缩炸弹的唯一防线，认证通过但超限的数据也必须拒绝。
+// Why(English): The size cap is the only zip-bomb defense; even authenticated data over the limit must be refused.
+func TestOpenV2DecompressionLimit(t *testing.T) {
+	pt := make([]byte, 1<<20)
+	sealed, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", pt, SealOptionsV2{Compress: "gzip"}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	if _, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/777", sealed.Header, sealed.Ciphertext, OpenOptionsV2{MaxPlaintext: 1024}); err != ErrTooLarge {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+// Why(中文): 压缩与填充可叠加，SealV2 按“先压缩后填充”处理，OpenV2 必须按相反顺序还原。
+// Why(English): Compression and padding stack as compress-then-pad in SealV2, and OpenV2 must undo them in reverse order.
+func TestSealOpenV2CompressAndPad(t *testing.T) {
+	pt := []byte(strings.Repeat("padding-test ", 50))
+	sealed, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", pt, SealOptionsV2{Compress: "gzip", Pad: "bucket-1024"}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	if len(sealed.Ciphertext) != 1024+16 {
+		t.Fatalf("expected bucket-sized ciphertext, got %d", len(sealed.Ciphertext))
+	}
+	got, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/777", sealed.Header, sealed.Ciphertext, OpenOptionsV2{})
+	if err != nil || !bytes.Equal(got, pt) {
+		t.Fatalf("round-trip mismatch: err=%v", err)
+	}
+}
+
+// Why(中文): 锁定一组确定性 v3 向量，承诺值与密文同时固定，任何承诺构造的改动都会在这里暴露。
+// Why(English): Lock a deterministic v3 vector pinning both commitment and ciphertext so any change to the commitment construction shows up here.
+func TestSealV2CommitVector(t *testing.T) {
+	pt := []byte("txlock committed vector")
+	sealed, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", pt, SealOptionsV2{Commit: true}, bytes.NewReader(bytes.Repeat([]byte{0x42}, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	if v, _ := HeaderValue(sealed.Header, "commit_b64"); v != "fACpG241yZumWU6JcecgADyI1pmnfL4Yb6/tgijcU84" {
+		t.Fatalf("unexpected commitment: %q", v)
+	}
+	if got := hex.EncodeToString(sealed.Ciphertext); got != "230741232a96d1be2d0a9eb7aead49bafc5e27cfab785653524a45113a1a40504182e892a0daa9" {
+		t.Fatalf("unexpected ciphertext: %s", got)
+	}
+	raw := BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	if DetectEnvelopeVersion(raw) != "v3" {
+		t.Fatalf("expected v3 magic, got %q", raw)
+	}
+	h, ct, ok := ParseEnvelopeV2(raw)
+	if !ok {
+		t.Fatalf("unexpected parse failure")
+	}
+	got, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/777", h, ct, OpenOptionsV2{})
+	if err != nil || !bytes.Equal(got, pt) {
+		t.Fatalf("round-trip mismatch: err=%v", err)
+	}
+}
+
+// Why(中文): 错误 SK 必须在承诺校验阶段就被拒绝（早于 AEAD Open），且承诺、路径被改动同样失败。
+// Why(English): A wrong SK must be refused at the commitment check, before AEAD Open, and tampering with the commitment or path fails too.
+func TestOpenV2CommitRejectsWrongKey(t *testing.T) {
+	path := "m/44'/60'/0'/0/777"
+	sealed, err := SealV2(fixtureSKV2(), path, []byte("hello"), SealOptionsV2{Commit: true}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	otherSK := bytes.Repeat([]byte{0x11}, 32)
+	salt := make([]byte, 32)
+	_, commitKey := deriveKeysV2(otherSK, salt, "v3", sealed.Header)
+	if verifyKeyCommitmentV3(commitKey, path, sealed.Header) {
+		t.Fatalf("commitment must not verify under another SK")
+	}
+	if _, err := OpenV2(otherSK, path, sealed.Header, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+	tampered := append([]HeaderField(nil), sealed.Header...)
+	last := len(tampered) - 1
+	tampered[last].Value = "A" + tampered[last].Value[1:]
+	if _, err := OpenV2(fixtureSKV2(), path, tampered, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for tampered commitment, got %v", err)
+	}
+	if _, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/778", sealed.Header, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for path drift, got %v", err)
+	}
+}
+
+// Why(中文): 去掉 commit_b64 等于降级为 v2，INFO 与 AAD 的版本不同，必须认证失败。
+// Why(English): Stripping commit_b64 downgrades to v2 with a different INFO and AAD version, so it must fail auth.
+func TestOpenV2CommitRejectsDowngrade(t *testing.T) {
+	sealed, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", []byte("hello"), SealOptionsV2{Commit: true}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	stripped := sealed.Header[:len(sealed.Header)-1]
+	if _, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/777", stripped, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package lockcore
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+)
+
+// Why(中文): 头字段值同时是算法名，将来更换构造时旧文件仍能按名识别。
+// Why(English): The header value doubles as the construction name so old files stay identifiable if the construction ever changes.
+const DeterministicV2 = "hmac-sha512"
+
+// Why(中文): 合成 IV：salt 与 nonce 取自 HMAC-SHA512(SK, 域前缀 || 不含 salt/nonce 的 AAD || AEAD 载荷)。AAD 覆盖路径、套件与全部变换标记，
+// 载荷是压缩填充后的最终输入，因此只有“同一 SK、同一路径、同一选项、同一明文”才会得到相同输出，泄露的仅是相等性。
+// Why(English): Synthetic IV: salt and nonce come from HMAC-SHA512(SK, domain prefix || AAD without salt/nonce || AEAD payload). The AAD covers path, suite and every
+// transform flag and the payload is the final post-compression/padding input, so only identical SK, path, options and plaintext repeat an output, leaking equality alone.
+func deterministicIVV2(sk []byte, version string, path string, h []HeaderField, payload []byte, nonceSize int) ([]byte, []byte) {
+	mac := hmac.New(sha512.New, sk)
+	_, _ = mac.Write([]byte("txlock:" + version + "|det\n"))
+	_, _ = mac.Write(buildAADV2(version, path, h))
+	_, _ = mac.Write(payload)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32 : 32+nonceSize]
+}
+
+// Why(中文): 解密后重新计算合成 IV 并与头字段比对，像 SIV 一样把 salt/nonce 也变成认证的一部分；不一致说明不是按确定性模式生成的文件。
+// Why(English): After opening, recompute the synthetic IV and compare it with the header, making salt and nonce part of authentication as in SIV; a mismatch means the file was not produced in deterministic mode.
+func verifyDeterministicIVV2(sk []byte, version string, path string, h []HeaderField, payload []byte) bool {
+	var rest []HeaderField
+	var salt, nonce []byte
+	for _, f := range h {
+		switch f.Key {
+		case "salt_b64":
+			salt, _ = base64.RawStdEncoding.DecodeString(f.Value)
+		case "nonce_b64":
+			nonce, _ = base64.RawStdEncoding.DecodeString(f.Value)
+		case "commit_b64":
+		default:
+			rest = append(rest, f)
+		}
+	}
+	if len(nonce) == 0 || len(nonce) > sha512.Size-32 {
+		return false
+	}
+	wantSalt, wantNonce := deterministicIVV2(sk, version, path, rest, payload, len(nonce))
+	return hmac.Equal(append(wantSalt, wantNonce...), append(salt, nonce...))
+}
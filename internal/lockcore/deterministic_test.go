@@ -0,0 +1,107 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+// Why(中文): 同一 SK、路径、选项与明文必须逐字节重现，且不需要随机源；换明文、路径或套件都会换 salt/nonce。
+// Why(English): Same SK, path, options and plaintext must reproduce byte for byte with no randomness; changing plaintext, path or suite changes salt and nonce.
+func TestSealV2DeterministicReproducible(t *testing.T) {
+	path := "m/44'/60'/0'/0/777"
+	opts := SealOptionsV2{Deterministic: true}
+	a, err := SealV2(fixtureSKV2(), path, []byte("same content"), opts, nil)
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	b, err := SealV2(fixtureSKV2(), path, []byte("same content"), opts, nil)
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	rawA := BuildEnvelopeV2(a.Header, base64.RawStdEncoding.EncodeToString(a.Ciphertext))
+	if rawA != BuildEnvelopeV2(b.Header, base64.RawStdEncoding.EncodeToString(b.Ciphertext)) {
+		t.Fatalf("deterministic output must repeat")
+	}
+	if v, _ := HeaderValue(a.Header, "det"); v != DeterministicV2 {
+		t.Fatalf("missing det header: %#v", a.Header)
+	}
+	salt, _ := HeaderValue(a.Header, "salt_b64")
+	for name, other := range map[string]func() (*SealResultV2, error){
+		"plaintext": func() (*SealResultV2, error) { return SealV2(fixtureSKV2(), path, []byte("same contenT"), opts, nil) },
+		"path": func() (*SealResultV2, error) {
+			return SealV2(fixtureSKV2(), "m/44'/60'/0'/0/778", []byte("same content"), opts, nil)
+		},
+		"suite": func() (*SealResultV2, error) {
+			return SealV2(fixtureSKV2(), path, []byte("same content"), SealOptionsV2{Deterministic: true, AEAD: "xchacha20-poly1305"}, nil)
+		},
+	} {
+		r, err := other()
+		if err != nil {
+			t.Fatalf("%s: unexpected seal error: %v", name, err)
+		}
+		if s, _ := HeaderValue(r.Header, "salt_b64"); s == salt {
+			t.Fatalf("%s change must change the salt", name)
+		}
+	}
+	h, ct, ok := ParseEnvelopeV2(rawA)
+	if !ok {
+		t.Fatalf("unexpected parse failure")
+	}
+	got, err := OpenV2(fixtureSKV2(), path, h, ct, OpenOptionsV2{})
+	if err != nil || string(got) != "same content" {
+		t.Fatalf("round-trip mismatch: err=%v", err)
+	}
+}
+
+// Why(中文): 锁定确定性模式的输出向量，HMAC 输入的任何调整（前缀、AAD、载荷顺序）都会在这里暴露。
+// Why(English): Lock the deterministic output so any change to the HMAC input (prefix, AAD, payload order) shows up here.
+func TestSealV2SyntheticIVVector(t *testing.T) {
+	sealed, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", []byte("txlock det vector"), SealOptionsV2{Deterministic: true, Commit: true}, nil)
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	salt, _ := HeaderValue(sealed.Header, "salt_b64")
+	nonce, _ := HeaderValue(sealed.Header, "nonce_b64")
+	if salt != "R1EYlFvoThdcGPT92TJRU1sjOolprD5GE9PvsDzJaas" || nonce != "CXAWri4EXmGO1gQv" {
+		t.Fatalf("unexpected synthetic iv: salt=%s nonce=%s", salt, nonce)
+	}
+	if got := hex.EncodeToString(sealed.Ciphertext); got != "fe24319a7ebd7368fa79e5ada0b346cc8007b66ea9e335970c6ccf93abfed96c62" {
+		t.Fatalf("unexpected ciphertext: %s", got)
+	}
+}
+
+// Why(中文): 头字段声明 det 但 salt/nonce 并非合成 IV 时必须拒绝；口令模式无法提供确定性密钥，加密与解析两侧都拒绝。
+// Why(English): A header claiming det whose salt and nonce are not the synthetic IV must be refused; password mode has no deterministic key, so both sealing and parsing reject it.
+func TestDeterministicModeRejectsMisuse(t *testing.T) {
+	path := "m/44'/60'/0'/0/777"
+	salt := bytes.Repeat([]byte{0x01}, 32)
+	nonce := bytes.Repeat([]byte{0x02}, 12)
+	h := []HeaderField{
+		{Key: "kdf", Value: "hkdf-sha256"},
+		{Key: "aead", Value: "aes-256-gcm"},
+		{Key: "det", Value: DeterministicV2},
+		{Key: "salt_b64", Value: base64.RawStdEncoding.EncodeToString(salt)},
+		{Key: "nonce_b64", Value: base64.RawStdEncoding.EncodeToString(nonce)},
+	}
+	key, _ := deriveKeysV2(fixtureSKV2(), salt, "v2", h)
+	aead, _ := newAEADV2(key, h)
+	ct := aead.Seal(nil, nonce, []byte("random iv"), buildAADV2("v2", path, h))
+	if _, err := OpenV2(fixtureSKV2(), path, h, ct, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for non-synthetic IV, got %v", err)
+	}
+	if _, err := SealPasswordV2(fixturePasswordV2(), MinArgon2ParamsV2, []byte("x"), SealOptionsV2{Deterministic: true}, bytes.NewReader(make([]byte, 64))); err != ErrEncrypt {
+		t.Fatalf("expected ErrEncrypt for deterministic password mode, got %v", err)
+	}
+	pw, err := SealPasswordV2(fixturePasswordV2(), MinArgon2ParamsV2, []byte("x"), SealOptionsV2{}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	forged := append(append([]HeaderField(nil), pw.Header[:3]...), HeaderField{Key: "det", Value: DeterministicV2})
+	forged = append(forged, pw.Header[3:]...)
+	raw := BuildEnvelopeV2(forged, base64.RawStdEncoding.EncodeToString(pw.Ciphertext))
+	if _, _, ok := ParseEnvelopeV2(raw); ok {
+		t.Fatalf("det must be rejected for argon2id envelopes")
+	}
+}
@@ -0,0 +1,181 @@
+package lockcore
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+)
+
+// Why(中文): 首字节 0x89 不是合法 UTF-8 起始且不同于 "<"，二进制容器永远不会被误认成 Markdown 信封，反之亦然。
+// Why(English): The leading 0x89 is neither valid UTF-8 lead nor "<", so a binary container is never mistaken for a Markdown envelope or vice versa.
+const binaryMagic = "\x89TXL"
+
+// Why(中文): 容器格式版本与信封版本分开编码：前者描述 TLV 布局，后者仍是 AAD 里的 txlock:vN。
+// Why(English): The container format version is separate from the envelope version: the former describes the TLV layout, the latter stays the txlock:vN inside the AAD.
+const binaryFormatV1 = 0x01
+
+// Why(中文): 标签号是线上格式的一部分，按下标固定且永不重排；新增头字段只能追加到末尾，0 保留为头部结束标记。
+// Why(English): Tag numbers are wire format, fixed by index and never reordered; new header keys may only be appended, and 0 is reserved as the end-of-header marker.
+var binaryTagsV1 = []string{
+	"",
+	"kdf",
+	"kdf_params",
+	"aead",
+	"meta",
+	"compress",
+	"pad",
+	"det",
+	"salt_b64",
+	"nonce_b64",
+	"commit_b64",
+	"label",
+	"id",
+	"seq",
+}
+
+// Why(中文): v1 头在文本里是固定四行，二进制里也只接受这四个字段与固定取值，保证两种形态一一对应。
+// Why(English): The v1 header is four fixed lines in text, so the binary form accepts exactly those four fields and values, keeping the two forms one-to-one.
+func headerFieldsV1(saltB64 string, nonceB64 string) []HeaderField {
+	return []HeaderField{
+		{Key: "kdf", Value: "hkdf-sha256"},
+		{Key: "aead", Value: "aes-256-gcm"},
+		{Key: "salt_b64", Value: saltB64},
+		{Key: "nonce_b64", Value: nonceB64},
+	}
+}
+
+// Why(中文): 只看前缀即可分流，调用方在严格解析前就能决定走二进制还是 Markdown 解析器。
+// Why(English): A prefix check is enough to route input, so callers can pick the binary or Markdown parser before strict parsing.
+func IsBinaryEnvelope(raw []byte) bool {
+	return len(raw) >= len(binaryMagic) && string(raw[:len(binaryMagic)]) == binaryMagic
+}
+
+// Why(中文): 头字段值按原样存为字符串（包括 salt/nonce 的 base64 文本），AAD 由同一组字符串逐行生成，换容器不会改变认证输入。
+// Why(English): Header values are stored verbatim as strings, base64 salt/nonce included, so the AAD is built from the same strings and switching containers never changes the authenticated input.
+func BuildBinaryEnvelope(version string, h []HeaderField, ct []byte) ([]byte, bool) {
+	if !validBinaryHeader(version, h) || len(ct) == 0 {
+		return nil, false
+	}
+	out := []byte(binaryMagic)
+	out = append(out, binaryFormatV1, version[1]-'0')
+	for _, f := range h {
+		out = append(out, binaryTagOf(f.Key))
+		out = binary.BigEndian.AppendUint16(out, uint16(len(f.Value)))
+		out = append(out, f.Value...)
+	}
+	out = append(out, 0)
+	return append(out, ct...), true
+}
+
+// Why(中文): 解析与文本侧同样零容忍：未知格式版本、未知标签、越界长度或头字段不合规都整体拒绝；密文为结束标记之后的全部字节。
+// Why(English): Parsing is as zero-tolerance as the text side: unknown format versions, unknown tags, overlong lengths or invalid headers are rejected outright; the ciphertext is every byte after the end marker.
+func ParseBinaryEnvelope(raw []byte) (string, []HeaderField, []byte, bool) {
+	if !IsBinaryEnvelope(raw) || len(raw) < len(binaryMagic)+2 || raw[len(binaryMagic)] != binaryFormatV1 {
+		return "", nil, nil, false
+	}
+	vn := raw[len(binaryMagic)+1]
+	if vn < 1 || vn > 3 {
+		return "", nil, nil, false
+	}
+	version := "v" + string('0'+vn)
+	rest := raw[len(binaryMagic)+2:]
+	var h []HeaderField
+	for {
+		if len(rest) == 0 {
+			return "", nil, nil, false
+		}
+		tag := rest[0]
+		rest = rest[1:]
+		if tag == 0 {
+			break
+		}
+		if int(tag) >= len(binaryTagsV1) || len(rest) < 2 {
+			return "", nil, nil, false
+		}
+		n := int(binary.BigEndian.Uint16(rest))
+		if len(rest) < 2+n {
+			return "", nil, nil, false
+		}
+		h = append(h, HeaderField{Key: binaryTagsV1[tag], Value: string(rest[2 : 2+n])})
+		rest = rest[2+n:]
+	}
+	if len(rest) == 0 || !validBinaryHeader(version, h) {
+		return "", nil, nil, false
+	}
+	return version, h, rest, true
+}
+
+// Why(中文): v1 只允许规范的四个字段，v2/v3 复用文本解析的顺序与取值校验，两种容器的接受范围完全一致。
+// Why(English): v1 allows only the canonical four fields, and v2/v3 reuse the text parser's order and value checks, so both containers accept exactly the same headers.
+func validBinaryHeader(version string, h []HeaderField) bool {
+	for _, f := range h {
+		if len(f.Value) > 0xffff || binaryTagOf(f.Key) == 0 {
+			return false
+		}
+	}
+	switch version {
+	case "v1":
+		if len(h) != 4 {
+			return false
+		}
+		want := headerFieldsV1(h[2].Value, h[3].Value)
+		for i := range want {
+			if h[i] != want[i] {
+				return false
+			}
+		}
+		return validHeaderOrderV2("v2", h)
+	case "v2", "v3":
+		return validHeaderOrderV2(version, h) && validHeaderValuesV2(h)
+	}
+	return false
+}
+
+// Why(中文): 反查标签号；返回 0 表示未知键，调用方据此拒绝。
+// Why(English): Reverse lookup of the tag number; 0 means an unknown key and callers reject on it.
+func binaryTagOf(key string) byte {
+	for i := 1; i < len(binaryTagsV1); i++ {
+		if binaryTagsV1[i] == key {
+			return byte(i)
+		}
+	}
+	return 0
+}
+
+// Why(中文): 只转换规范 Markdown：解析后重建必须逐字节等于输入，否则拒绝，保证 Markdown→二进制→Markdown 往返无损；全程不接触密钥。
+// Why(English): Only canonical Markdown converts: the rebuild after parsing must equal the input byte for byte or it is rejected, so Markdown→binary→Markdown is lossless; no key is ever touched.
+func MarkdownToBinary(raw string) ([]byte, bool) {
+	version := DetectEnvelopeVersion(raw)
+	var h []HeaderField
+	var ct []byte
+	switch version {
+	case "v1":
+		_, saltB64, nonceB64, body, ok := ParseEnvelopeV1(raw)
+		if !ok || BuildEnvelopeV1("", saltB64, nonceB64, base64.RawStdEncoding.EncodeToString(body)) != raw {
+			return nil, false
+		}
+		h, ct = headerFieldsV1(saltB64, nonceB64), body
+	case "v2", "v3":
+		header, body, ok := ParseEnvelopeV2(raw)
+		if !ok || BuildEnvelopeV2(header, base64.RawStdEncoding.EncodeToString(body)) != raw {
+			return nil, false
+		}
+		h, ct = header, body
+	default:
+		return nil, false
+	}
+	return BuildBinaryEnvelope(version, h, ct)
+}
+
+// Why(中文): 二进制容器本身只有一种字节表示，按信封版本选回对应的 Markdown 构建器即可得到规范文本。
+// Why(English): A binary container has exactly one byte form, so picking the matching Markdown builder by envelope version yields the canonical text.
+func BinaryToMarkdown(raw []byte) (string, bool) {
+	version, h, ct, ok := ParseBinaryEnvelope(raw)
+	if !ok {
+		return "", false
+	}
+	ctB64 := base64.RawStdEncoding.EncodeToString(ct)
+	if version == "v1" {
+		return BuildEnvelopeV1("", h[2].Value, h[3].Value, ctB64), true
+	}
+	return BuildEnvelopeV2(h, ctB64), true
+}
@@ -0,0 +1,87 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// Why(中文): 同一份密文在两种容器间往返必须逐字节还原，且从二进制解析出的头字段无需任何转换就能直接解密，证明 AAD 语义未变。
+// Why(English): Round-tripping one ciphertext through both containers must restore every byte, and the header parsed from binary must decrypt as-is, proving the AAD is unchanged.
+func TestBinaryEnvelopeRoundTripV1V3(t *testing.T) {
+	path := "m/44'/60'/0'/0/777"
+	s1, err := SealV1(fixtureSKV2(), path, []byte("hello v1"), bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("SealV1: %v", err)
+	}
+	s3, err := SealV2(fixtureSKV2(), path, []byte("hello v3"), SealOptionsV2{Commit: true, Compress: "gzip"}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("SealV2: %v", err)
+	}
+	texts := []string{
+		BuildEnvelopeV1(path, s1.SaltB64, s1.NonceB64, base64.RawStdEncoding.EncodeToString(s1.Ciphertext)),
+		BuildEnvelopeV2(s3.Header, base64.RawStdEncoding.EncodeToString(s3.Ciphertext)),
+	}
+	for i, text := range texts {
+		bin, ok := MarkdownToBinary(text)
+		if !ok || !IsBinaryEnvelope(bin) || len(bin) >= len(text) {
+			t.Fatalf("case %d: MarkdownToBinary failed or not smaller: %v %d/%d", i, ok, len(bin), len(text))
+		}
+		back, ok := BinaryToMarkdown(bin)
+		if !ok || back != text {
+			t.Fatalf("case %d: round trip mismatch:\n%s\n%s", i, text, back)
+		}
+		version, h, ct, ok := ParseBinaryEnvelope(bin)
+		if !ok {
+			t.Fatalf("case %d: ParseBinaryEnvelope failed", i)
+		}
+		var got []byte
+		if version == "v1" {
+			got, err = OpenV1(fixtureSKV2(), path, h[2].Value, h[3].Value, ct)
+		} else {
+			got, err = OpenV2(fixtureSKV2(), path, h, ct, OpenOptionsV2{})
+		}
+		if err != nil || !strings.HasPrefix(string(got), "hello ") {
+			t.Fatalf("case %d: open from binary: %q %v", i, got, err)
+		}
+	}
+}
+
+// Why(中文): 二进制解析与文本一样零容忍：截断、未知标签、未知格式版本、版本与承诺不符、缺少密文都必须拒绝；非规范 Markdown 也不得“顺手规范化”。
+// Why(English): Binary parsing is as strict as text: truncation, unknown tags, unknown format versions, version/commit mismatch and missing ciphertext all fail, and non-canonical Markdown is never silently normalized.
+func TestBinaryEnvelopeStrict(t *testing.T) {
+	bin, ok := BuildBinaryEnvelope("v2", fixtureHeaderV2(), []byte("abc"))
+	if !ok {
+		t.Fatalf("BuildBinaryEnvelope failed")
+	}
+	end := bytes.IndexByte(bin[6:], 0) + 6
+	bad := [][]byte{
+		bin[:end],
+		bin[:10],
+		append(append([]byte{}, bin[:6]...), append([]byte{0x7f, 0, 1, 'x'}, bin[6:]...)...),
+		append(append([]byte{}, bin[:4]...), append([]byte{0x02}, bin[5:]...)...),
+		append(append([]byte{}, bin[:5]...), append([]byte{3}, bin[6:]...)...),
+		append(append([]byte{}, bin[:5]...), append([]byte{1}, bin[6:]...)...),
+	}
+	for i, b := range bad {
+		if _, _, _, ok := ParseBinaryEnvelope(b); ok {
+			t.Fatalf("case %d: expected reject: %x", i, b)
+		}
+	}
+	if _, ok := BuildBinaryEnvelope("v3", fixtureHeaderV2(), []byte("abc")); ok {
+		t.Fatalf("v3 without commit_b64 must not build")
+	}
+	text := BuildEnvelopeV2(fixtureHeaderV2(), base64.RawStdEncoding.EncodeToString(bytes.Repeat([]byte("x"), 100)))
+	at := strings.Index(text, "ct_b64:\n") + len("ct_b64:\n") + 10
+	rewrapped := text[:at] + "\n" + text[at:]
+	if _, _, ok := ParseEnvelopeV2(rewrapped); !ok {
+		t.Fatalf("rewrapped fixture should still parse")
+	}
+	if _, ok := MarkdownToBinary(rewrapped); ok {
+		t.Fatalf("non-canonical Markdown must not convert")
+	}
+	if _, ok := MarkdownToBinary(text); !ok {
+		t.Fatalf("canonical Markdown must convert")
+	}
+}
@@ -0,0 +1,142 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+const fuzzPathV1 = "m/44'/60'/0'/0/777"
+
+// Why(中文): 所有目标共用同一个真实封装的信封作为种子，变异从合法输入出发才能深入到头字段与密文解码分支。
+// Why(English): Every target seeds from one genuinely sealed envelope so mutations start from valid input and reach the header and ciphertext branches.
+func fuzzSealedEnvelopeV1(tb testing.TB) (string, []byte) {
+	sk, _ := hex.DecodeString("b1ec885280602151c894fb7c17d076a2469ae59161d3b418c08e2ce0b2f2ef21")
+	sealed, err := SealV1(sk, fuzzPathV1, []byte("hello txlock\n"), bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		tb.Fatalf("seal: %v", err)
+	}
+	raw := BuildEnvelopeV1(fuzzPathV1, sealed.SaltB64, sealed.NonceB64, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	return raw, sk
+}
+
+// Why(中文): 解析器对旧文件保留三处宽容（可选 chain/path 行、头字段任意顺序、任意行宽的密文行）；测试侧把这些写法归一化后再与重建结果逐字节比较，宽容范围之外的任何差异都会暴露出来。
+// Why(English): The parser keeps three leniencies for old files (optional chain/path lines, any header order, ciphertext lines of any width); the test normalizes exactly those before a byte-for-byte comparison with the rebuild, so any difference beyond them surfaces.
+func normalizeLegacyV1(raw string) string {
+	body, _ := extractEnvelopeBodyV1(raw)
+	h, ctLines, _ := parseHeaderKVV1(body)
+	return BuildEnvelopeV1("", h["salt_b64"], h["nonce_b64"], strings.Join(ctLines, ""))
+}
+
+// Why(中文): 被接受的输入必须能经 BuildEnvelopeV1 重建为唯一规范形式，且规范形式是不动点；这样“同一信封两种写法”只可能来自文档化的宽容项。
+// Why(English): Any accepted input must rebuild through BuildEnvelopeV1 into a single canonical form that is a fixed point, so "one envelope, two spellings" can only come from the documented leniencies.
+func FuzzParseEnvelopeV1(f *testing.F) {
+	raw, _ := fuzzSealedEnvelopeV1(f)
+	f.Add(raw)
+	f.Add(strings.Replace(raw, "\nkdf:", "\npath:"+fuzzPathV1+"\nchain:ethereum\nkdf:", 1))
+	f.Add(BuildEnvelopeV1("", "c2FsdA", "bm9uY2U", "QUJD"))
+	f.Fuzz(func(t *testing.T, raw string) {
+		path, salt, nonce, ct, ok := ParseEnvelopeV1(raw)
+		if !ok {
+			return
+		}
+		canonical := BuildEnvelopeV1(path, salt, nonce, base64.RawStdEncoding.EncodeToString(ct))
+		path2, salt2, nonce2, ct2, ok := ParseEnvelopeV1(canonical)
+		if !ok || path2 != "" || salt2 != salt || nonce2 != nonce || !bytes.Equal(ct2, ct) {
+			t.Fatalf("rebuild does not parse back to the same values:\n%q\n%q", raw, canonical)
+		}
+		if again := BuildEnvelopeV1(path2, salt2, nonce2, base64.RawStdEncoding.EncodeToString(ct2)); again != canonical {
+			t.Fatalf("canonical form is not a fixed point:\n%q\n%q", canonical, again)
+		}
+		if got := normalizeLegacyV1(raw); got != canonical {
+			t.Fatalf("accepted input differs from its rebuild beyond the legacy leniencies:\n%q\n%q", got, canonical)
+		}
+	})
+}
+
+// Why(中文): 头字段解析只允许固定键集合、单冒号、无空白的值；模糊测试确认任何被接受的结果都满足这些不变量且从不 panic。
+// Why(English): Header parsing allows only the fixed key set, one colon, and whitespace-free values; fuzzing confirms every accepted result keeps those invariants and never panics.
+func FuzzParseHeaderKVV1(f *testing.F) {
+	raw, _ := fuzzSealedEnvelopeV1(f)
+	body, _ := extractEnvelopeBodyV1(raw)
+	f.Add(body)
+	f.Add("txlock:v1\nchain:ethereum\npath:x\nkdf:a\naead:b\nsalt_b64:c\nnonce_b64:d\nct_b64:\nQUJD\n")
+	f.Fuzz(func(t *testing.T, body string) {
+		h, ctLines, ok := parseHeaderKVV1(body)
+		if !ok {
+			return
+		}
+		if len(ctLines) == 0 || !strings.HasPrefix(body, "txlock:v1\n") || !strings.HasSuffix(body, "\n") {
+			t.Fatalf("accepted body without version line, ciphertext lines or trailing newline: %q", body)
+		}
+		for k, v := range h {
+			switch k {
+			case "chain", "path", "kdf", "aead", "salt_b64", "nonce_b64":
+			default:
+				t.Fatalf("unexpected key %q", k)
+			}
+			if strings.ContainsAny(v, ": \t\n") {
+				t.Fatalf("value of %s carries a separator: %q", k, v)
+			}
+		}
+		for _, line := range ctLines {
+			if strings.Contains(line, "\n") {
+				t.Fatalf("ciphertext line spans lines: %q", line)
+			}
+		}
+	})
+}
+
+// Why(中文): 密文解码必须与分行方式无关，且只接受标准字母表；输出必须能由 RawStdEncoding 重新编码为原文，尾部比特非零的写法一律拒绝。
+// Why(English): Ciphertext decoding must be independent of line splitting and accept only the standard alphabet; the output must re-encode to the input under RawStdEncoding, so spellings with non-zero trailing bits are always rejected.
+func FuzzDecodeCTLinesRawB64(f *testing.F) {
+	f.Add("QUJD")
+	f.Add("aGVsbG8t\ndHhsb2Nr")
+	f.Add("QUI=")
+	f.Add("QUJ")
+	f.Fuzz(func(t *testing.T, joined string) {
+		lines := strings.Split(joined, "\n")
+		out, ok := decodeCTLinesRawB64(lines)
+		if !ok {
+			return
+		}
+		flat := strings.Join(lines, "")
+		single, ok := decodeCTLinesRawB64([]string{flat})
+		if !ok || !bytes.Equal(single, out) {
+			t.Fatalf("decode depends on line splitting: %q", joined)
+		}
+		if strings.Trim(flat, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/") != "" {
+			t.Fatalf("accepted bytes outside the base64 alphabet: %q", joined)
+		}
+		reencoded := base64.RawStdEncoding.EncodeToString(out)
+		if reencoded != flat {
+			t.Fatalf("decode is not the inverse of RawStdEncoding: %q -> %q", flat, reencoded)
+		}
+	})
+}
+
+// Why(中文): 对真实信封任意一个字节做非零异或后，解析可以成功也可以失败，但 OpenV1 绝不能解出明文；只改尾部比特的密文末字符也不例外。
+// Why(English): After XOR-ing a non-zero byte into any position of a real envelope, parsing may or may not succeed, but OpenV1 must never return plaintext, including when only the trailing bits of the last ciphertext character change.
+func FuzzOpenV1Mutation(f *testing.F) {
+	raw, sk := fuzzSealedEnvelopeV1(f)
+	f.Add(uint(0), byte(1))
+	f.Add(uint(len(raw)-6), byte(0x20))
+	f.Add(uint(strings.Index(raw, "salt_b64:")+9), byte(2))
+	f.Fuzz(func(t *testing.T, pos uint, x byte) {
+		if x == 0 {
+			return
+		}
+		mutated := []byte(raw)
+		mutated[pos%uint(len(mutated))] ^= x
+		_, salt, nonce, ct, ok := ParseEnvelopeV1(string(mutated))
+		if !ok {
+			return
+		}
+		if _, err := OpenV1(sk, fuzzPathV1, salt, nonce, ct); err != nil {
+			return
+		}
+		t.Fatalf("mutated envelope opened: %q", mutated)
+	})
+}
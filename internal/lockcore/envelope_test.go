@@ -105,9 +105,9 @@ func TestDecodeCTLinesRawB64(t *testing.T) {
 // Why(中文): 入口测试要同时验证成功路径与固定常量校验，防止解析器在版本常量上出现“宽松接受”。
 // Why(English): Entry-point test must verify both success and fixed-constant checks to prevent lax acceptance of protocol constants.
 func TestParseEnvelopeV1(t *testing.T) {
-	raw := BuildEnvelopeV1("m/44'/60'/0'/0/777", "saltx", "noncey", base64.RawStdEncoding.EncodeToString([]byte("abc")))
+	raw := BuildEnvelopeV1("m/44'/60'/0'/0/777", "c2FsdA", "bm9uY2U", base64.RawStdEncoding.EncodeToString([]byte("abc")))
 	path, saltB64, nonceB64, ct, ok := ParseEnvelopeV1(raw)
-	if !ok || path != "" || saltB64 != "saltx" || nonceB64 != "noncey" || string(ct) != "abc" {
+	if !ok || path != "" || saltB64 != "c2FsdA" || nonceB64 != "bm9uY2U" || string(ct) != "abc" {
 		t.Fatalf("unexpected parse envelope result")
 	}
 	bad := strings.Replace(raw, "\nct_b64:\n", "\nchain:eth\nct_b64:\n", 1)
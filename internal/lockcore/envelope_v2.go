@@ -0,0 +1,234 @@
+package lockcore
+
+import (
+	"strings"
+)
+
+type HeaderField struct {
+	Key   string
+	Value string
+}
+
+// Why(中文): v2 头字段顺序固定，解析时强制该顺序，保证同一语义只有一种字节表示，AAD 直接由头字段逐行生成。
+// Why(English): v2 header keys have one fixed order enforced on parse, so each meaning has exactly one byte form and the AAD is the header itself.
+var headerOrderV2 = []string{
+	"kdf",
+	"kdf_params",
+	"label",
+	"id",
+	"seq",
+	"aead",
+	"meta",
+	"compress",
+	"pad",
+	"det",
+	"salt_b64",
+	"nonce_b64",
+	"commit_b64",
+}
+
+// Why(中文): 必填字段集中声明，新增可选字段时只需扩展顺序表而不影响必填校验。
+// Why(English): Declare required keys in one place so adding optional keys only extends the order table.
+func isRequiredHeaderKeyV2(key string) bool {
+	switch key {
+	case "kdf", "aead", "salt_b64", "nonce_b64":
+		return true
+	}
+	return false
+}
+
+// Why(中文): v3 与 v2 共用同一头字段语法，唯一区别是必须携带 commit_b64；版本由该字段是否存在唯一决定。
+// Why(English): v3 shares the v2 header grammar and differs only by the mandatory commit_b64, so the version follows from that key alone.
+func headerVersionV2(h []HeaderField) string {
+	if _, ok := HeaderValue(h, "commit_b64"); ok {
+		return "v3"
+	}
+	return "v2"
+}
+
+// Why(中文): 按键查值是调用方最常见的需求，集中实现避免各处手写线性查找。
+// Why(English): Key lookup is the most common caller need; one helper avoids hand-rolled scans everywhere.
+func HeaderValue(h []HeaderField, key string) (string, bool) {
+	for _, f := range h {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Why(中文): 版本判别只看 magic 行，让 CLI 在严格解析前就能选择 v1 或 v2 解析器。
+// Why(English): Version detection looks only at the magic line so the CLI can pick the v1 or v2 parser before strict parsing.
+func DetectEnvelopeVersion(raw string) string {
+	switch {
+	case strings.HasPrefix(raw, "<!--\ntxlock:v1\n"):
+		return "v1"
+	case strings.HasPrefix(raw, "<!--\ntxlock:v2\n"):
+		return "v2"
+	case strings.HasPrefix(raw, "<!--\ntxlock:v3\n"):
+		return "v3"
+	}
+	return ""
+}
+
+// Why(中文): v2 仍沿用 v1 的注释边界与 76 列密文换行，只把头字段改为有序可扩展列表。
+// Why(English): v2 keeps v1's comment boundaries and 76-column ciphertext wrapping and only makes the header an ordered, extensible list.
+func BuildEnvelopeV2(header []HeaderField, ctB64 string) string {
+	var b strings.Builder
+	b.WriteString("<!--\ntxlock:" + headerVersionV2(header) + "\n")
+	for _, f := range header {
+		b.WriteString(f.Key)
+		b.WriteString(":")
+		b.WriteString(f.Value)
+		b.WriteString("\n")
+	}
+	b.WriteString("ct_b64:\n")
+	for _, line := range wrapB64Lines76(ctB64) {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString("-->\n")
+	return b.String()
+}
+
+// Why(中文): 头字段必须按固定顺序出现且不得重复或未知，任何偏离都视为篡改，与 v1 的零容忍语法保持一致。
+// Why(English): Header keys must appear in fixed order without duplicates or unknowns; any deviation is tampering, matching v1's zero tolerance.
+func parseHeaderKVV2(body string) ([]HeaderField, []string, bool) {
+	lines := strings.Split(body, "\n")
+	if len(lines) < 3 || (lines[0] != "txlock:v2" && lines[0] != "txlock:v3") || lines[len(lines)-1] != "" {
+		return nil, nil, false
+	}
+	var out []HeaderField
+	i := 1
+	for ; i < len(lines)-1; i++ {
+		line := lines[i]
+		if line == "ct_b64:" {
+			i++
+			break
+		}
+		if line == "" || strings.Count(line, ":") != 1 {
+			return nil, nil, false
+		}
+		kv := strings.SplitN(line, ":", 2)
+		out = append(out, HeaderField{Key: kv[0], Value: kv[1]})
+	}
+	if i >= len(lines)-1 || !validHeaderOrderV2(strings.TrimPrefix(lines[0], "txlock:"), out) {
+		return nil, nil, false
+	}
+	return out, lines[i : len(lines)-1], true
+}
+
+// Why(中文): 顺序、必填、非空与字符集规则与具体编码无关，Markdown 与二进制容器共用这一份校验，同一头字段在两种形态下接受范围完全一致。
+// Why(English): Order, required-key, non-empty and charset rules are encoding-independent, so the Markdown and binary containers share this one check and accept exactly the same headers.
+func validHeaderOrderV2(version string, h []HeaderField) bool {
+	next := 0
+	for _, f := range h {
+		if f.Value == "" || strings.ContainsAny(f.Key+f.Value, " \t\r\n:") {
+			return false
+		}
+		if strings.HasSuffix(f.Key, "_b64") {
+			if _, err := DecodeCanonicalB64(f.Value); err != nil {
+				return false
+			}
+		}
+		pos := -1
+		for j := next; j < len(headerOrderV2); j++ {
+			if headerOrderV2[j] == f.Key {
+				pos = j
+				break
+			}
+		}
+		if pos < 0 {
+			return false
+		}
+		for j := next; j < pos; j++ {
+			if isRequiredHeaderKeyV2(headerOrderV2[j]) {
+				return false
+			}
+		}
+		next = pos + 1
+	}
+	for j := next; j < len(headerOrderV2); j++ {
+		if isRequiredHeaderKeyV2(headerOrderV2[j]) {
+			return false
+		}
+	}
+	return version == headerVersionV2(h)
+}
+
+// Why(中文): 字段取值在解析阶段就按白名单校验，不认识的算法名不会流入密钥派生或解压流程。
+// Why(English): Validate field values against allow-lists at parse time so unknown algorithm names never reach key derivation or decompression.
+func validHeaderValuesV2(h []HeaderField) bool {
+	kdf, _ := HeaderValue(h, "kdf")
+	if _, hasParams := HeaderValue(h, "kdf_params"); hasParams != usesArgon2V2(kdf) {
+		return false
+	}
+	_, hasID := HeaderValue(h, "id")
+	_, hasSeq := HeaderValue(h, "seq")
+	if hasID != hasSeq {
+		return false
+	}
+	for _, f := range h {
+		switch f.Key {
+		case "kdf":
+			if f.Value != "hkdf-sha256" && !usesArgon2V2(f.Value) {
+				return false
+			}
+		case "kdf_params":
+			if _, ok := ParseArgon2ParamsV2(f.Value); !ok {
+				return false
+			}
+		case "label":
+			if !ValidLabel(f.Value) || kdf != "hkdf-sha256" {
+				return false
+			}
+		case "id":
+			if !ValidLabel(f.Value) {
+				return false
+			}
+		case "seq":
+			if _, ok := ParseSeq(f.Value); !ok {
+				return false
+			}
+		case "aead":
+			if !IsAEADV2(f.Value) {
+				return false
+			}
+		case "meta":
+			if f.Value != "json" {
+				return false
+			}
+		case "compress":
+			if !isCompressionV2(f.Value) {
+				return false
+			}
+		case "pad":
+			if !IsPaddingV2(f.Value) {
+				return false
+			}
+		case "det":
+			if f.Value != DeterministicV2 || kdf != "hkdf-sha256" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Why(中文): v2/v3 入口与 v1 相同地串联边界、头字段与密文校验，但返回有序头字段供 OpenV2 重建 AAD。
+// Why(English): The v2/v3 entry point chains boundary, header and ciphertext checks like v1 but returns the ordered header for OpenV2's AAD.
+func ParseEnvelopeV2(raw string) ([]HeaderField, []byte, bool) {
+	body, ok := extractEnvelopeBodyV1(raw)
+	if !ok {
+		return nil, nil, false
+	}
+	h, ctLines, ok := parseHeaderKVV2(body)
+	if !ok || !validHeaderValuesV2(h) {
+		return nil, nil, false
+	}
+	ct, ok := decodeCTLinesRawB64(ctLines)
+	if !ok {
+		return nil, nil, false
+	}
+	return h, ct, true
+}
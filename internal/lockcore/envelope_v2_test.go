@@ -0,0 +1,77 @@
+package lockcore
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func fixtureHeaderV2() []HeaderField {
+	return []HeaderField{
+		{Key: "kdf", Value: "hkdf-sha256"},
+		{Key: "aead", Value: "aes-256-gcm"},
+		{Key: "compress", Value: "gzip"},
+		{Key: "salt_b64", Value: "c2FsdA"},
+		{Key: "nonce_b64", Value: "bm9uY2U"},
+	}
+}
+
+// Why(中文): v2 builder 与 parser 必须互逆，头字段顺序与取值原样返回，AAD 才能在解密侧逐字节重建。
+// Why(English): v2 build and parse must be inverses returning header order and values intact so the AAD can be rebuilt byte for byte.
+func TestEnvelopeV2BuildParse(t *testing.T) {
+	raw := BuildEnvelopeV2(fixtureHeaderV2(), base64.RawStdEncoding.EncodeToString([]byte("abc")))
+	if DetectEnvelopeVersion(raw) != "v2" {
+		t.Fatalf("expected v2 detection")
+	}
+	h, ct, ok := ParseEnvelopeV2(raw)
+	if !ok || string(ct) != "abc" || len(h) != 5 {
+		t.Fatalf("unexpected parse result: %#v %q %v", h, ct, ok)
+	}
+	if v, _ := HeaderValue(h, "compress"); v != "gzip" {
+		t.Fatalf("unexpected compress value: %q", v)
+	}
+}
+
+// Why(中文): 顺序错乱、缺少必填、未知字段与未知算法名都必须拒绝，保证每种语义只有一种写法。
+// Why(English): Reordered, missing, unknown keys and unknown algorithm names must all fail so each meaning has one spelling.
+func TestParseEnvelopeV2Strict(t *testing.T) {
+	raw := BuildEnvelopeV2(fixtureHeaderV2(), base64.RawStdEncoding.EncodeToString([]byte("abc")))
+	bad := []string{
+		strings.Replace(raw, "kdf:hkdf-sha256\naead:aes-256-gcm\n", "aead:aes-256-gcm\nkdf:hkdf-sha256\n", 1),
+		strings.Replace(raw, "nonce_b64:bm9uY2U\n", "", 1),
+		strings.Replace(raw, "compress:gzip\n", "compress:zstd\n", 1),
+		strings.Replace(raw, "compress:gzip\n", "extra:1\n", 1),
+		strings.Replace(raw, "compress:gzip\n", "compress:\n", 1),
+		strings.Replace(raw, "txlock:v2", "txlock:v1", 1),
+		strings.Replace(raw, "kdf:hkdf-sha256\n", "kdf:hkdf-sha256\nkdf_params:m=19456,t=2,p=1\n", 1),
+		strings.Replace(raw, "kdf:hkdf-sha256\n", "kdf:argon2id\n", 1),
+	}
+	for i, b := range bad {
+		if _, _, ok := ParseEnvelopeV2(b); ok {
+			t.Fatalf("case %d: expected reject:\n%s", i, b)
+		}
+	}
+}
+
+// Why(中文): magic 行与 commit_b64 必须一致：v3 缺承诺、v2 带承诺都视为篡改。
+// Why(English): The magic line and commit_b64 must agree: v3 without a commitment or v2 with one is tampering.
+func TestParseEnvelopeV3RequiresCommit(t *testing.T) {
+	h := append(fixtureHeaderV2(), HeaderField{Key: "commit_b64", Value: "Yw"})
+	raw := BuildEnvelopeV2(h, base64.RawStdEncoding.EncodeToString([]byte("abc")))
+	if DetectEnvelopeVersion(raw) != "v3" {
+		t.Fatalf("expected v3 detection")
+	}
+	if _, _, ok := ParseEnvelopeV2(raw); !ok {
+		t.Fatalf("expected v3 parse success")
+	}
+	bad := []string{
+		strings.Replace(raw, "txlock:v3", "txlock:v2", 1),
+		strings.Replace(raw, "commit_b64:Yw\n", "", 1),
+		strings.Replace(raw, "nonce_b64:bm9uY2U\ncommit_b64:Yw\n", "commit_b64:Yw\nnonce_b64:bm9uY2U\n", 1),
+	}
+	for i, b := range bad {
+		if _, _, ok := ParseEnvelopeV2(b); ok {
+			t.Fatalf("case %d: expected reject:\n%s", i, b)
+		}
+	}
+}
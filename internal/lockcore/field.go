@@ -0,0 +1,206 @@
+package lockcore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	infoFieldV1    = "txlock:f1|chain=ethereum|path=bip44|kdf=hkdf-sha256|aead=aes-256-gcm"
+	infoFieldMACV1 = "txlock:f1|chain=ethereum|path=bip44|kdf=hkdf-sha256|mac=hmac-sha256"
+	fieldPrefixV1  = "txlock:f1:"
+)
+
+var (
+	ErrFieldToken = errors.New("invalid field token")
+	ErrFieldMAC   = errors.New("document mac mismatch")
+)
+
+// Why(中文): MAC 只关心叶子的路径、类型与当前值，与具体文件格式无关。
+// Why(English): The MAC only sees a leaf's path, type and current value, independent of the file format.
+type FieldLeaf struct {
+	Path  string
+	Type  string
+	Value string
+}
+
+// Why(中文): 字段级模式与整文件模式共用 sk，但用独立 INFO 做域分离，避免同一 salt 下两种模式派生出相同的 K。
+// Why(English): Field mode shares sk with whole-file mode but uses its own INFO so the two modes never derive the same K for one salt.
+func deriveFieldKeysV1(sk []byte, salt []byte) ([]byte, []byte, bool) {
+	if len(sk) != 32 || len(salt) != 32 {
+		return nil, nil, false
+	}
+	return hkdfSHA256(sk, salt, []byte(infoFieldV1), 32), hkdfSHA256(sk, salt, []byte(infoFieldMACV1), 32), true
+}
+
+// Why(中文): 键路径写入 AAD 时用 Go 引号转义，任意字节的键名都只有一种序列化，防止换行注入伪造 AAD 行。
+// Why(English): Quote the key path inside the AAD so any key bytes have exactly one serialization and newlines cannot forge AAD lines.
+func buildFieldAADV1(path string, saltB64 string, keyPath string, typ string, nonceB64 string) []byte {
+	return []byte("txlock:f1\n" +
+		"chain:ethereum\n" +
+		"path:" + path + "\n" +
+		"kdf:hkdf-sha256\n" +
+		"aead:aes-256-gcm\n" +
+		"salt_b64:" + saltB64 + "\n" +
+		"key:" + strconv.Quote(keyPath) + "\n" +
+		"type:" + typ + "\n" +
+		"nonce_b64:" + nonceB64 + "\n")
+}
+
+// Why(中文): 类型标签出现在单行 token 内且以 ':' 分隔，只允许小写字母可保证 token 切分无歧义。
+// Why(English): The type tag lives inside a ':'-separated single-line token, so lowercase letters only keep splitting unambiguous.
+func isFieldTypeV1(typ string) bool {
+	if typ == "" || len(typ) > 16 {
+		return false
+	}
+	for i := 0; i < len(typ); i++ {
+		if typ[i] < 'a' || typ[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Why(中文): 字段模式会为同一文档反复构造 AEAD，集中构造可保证错误语义统一。
+// Why(English): Field mode builds the AEAD repeatedly per document; one constructor keeps its failure semantics uniform.
+func newFieldGCMV1(key []byte) (cipher.AEAD, bool) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, false
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, false
+	}
+	return gcm, true
+}
+
+// Why(中文): 解密侧只需用前缀判断叶子是否为 token，完整格式校验留给 OpenFieldV1 统一处理。
+// Why(English): Decrypt side only needs a prefix check to spot tokens; full format validation stays in OpenFieldV1.
+func IsFieldTokenV1(s string) bool {
+	return strings.HasPrefix(s, fieldPrefixV1)
+}
+
+// Why(中文): 每个叶子值独立随机 nonce，并把键路径与类型绑定进 AAD，使密文无法在不同键之间互换。
+// Why(English): Each leaf gets a fresh nonce and binds its key path and type into the AAD so tokens cannot be swapped between keys.
+func SealFieldV1(sk []byte, path string, salt []byte, keyPath string, typ string, plaintext []byte, random io.Reader) (string, error) {
+	if len(sk) != 32 {
+		return "", ErrInvalidSK
+	}
+	if !isPathV1(path) {
+		return "", ErrInvalidPath
+	}
+	if !isFieldTypeV1(typ) {
+		return "", ErrFieldToken
+	}
+	if random == nil {
+		return "", ErrRandomRead
+	}
+	key, _, ok := deriveFieldKeysV1(sk, salt)
+	if !ok {
+		return "", ErrInvalidSK
+	}
+	nonce := make([]byte, 12)
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return "", ErrRandomRead
+	}
+	gcm, ok := newFieldGCMV1(key)
+	if !ok {
+		return "", ErrEncrypt
+	}
+	saltB64 := base64.RawStdEncoding.EncodeToString(salt)
+	nonceB64 := base64.RawStdEncoding.EncodeToString(nonce)
+	ct := gcm.Seal(nil, nonce, plaintext, buildFieldAADV1(path, saltB64, keyPath, typ, nonceB64))
+	return fieldPrefixV1 + typ + ":" + nonceB64 + ":" + base64.RawStdEncoding.EncodeToString(ct), nil
+}
+
+// Why(中文): token 解析与认证同在一处完成，调用方拿到的类型标签一定是经过 AAD 认证的值。
+// Why(English): Parse and authenticate the token in one place so the returned type tag is always an AAD-authenticated value.
+func OpenFieldV1(sk []byte, path string, salt []byte, keyPath string, token string) (string, []byte, error) {
+	if len(sk) != 32 {
+		return "", nil, ErrInvalidSK
+	}
+	if !isPathV1(path) {
+		return "", nil, ErrInvalidPath
+	}
+	if !IsFieldTokenV1(token) {
+		return "", nil, ErrFieldToken
+	}
+	parts := strings.Split(token[len(fieldPrefixV1):], ":")
+	if len(parts) != 3 || !isFieldTypeV1(parts[0]) {
+		return "", nil, ErrFieldToken
+	}
+	nonce, err := DecodeCanonicalB64(parts[1])
+	if err == ErrNonCanonical {
+		return "", nil, ErrNonCanonical
+	}
+	if err != nil || len(nonce) != 12 {
+		return "", nil, ErrFieldToken
+	}
+	ct, err := DecodeCanonicalB64(parts[2])
+	if err == ErrNonCanonical {
+		return "", nil, ErrNonCanonical
+	}
+	if err != nil {
+		return "", nil, ErrFieldToken
+	}
+	key, _, ok := deriveFieldKeysV1(sk, salt)
+	if !ok {
+		return "", nil, ErrInvalidSK
+	}
+	gcm, ok := newFieldGCMV1(key)
+	if !ok {
+		return "", nil, ErrDecrypt
+	}
+	saltB64 := base64.RawStdEncoding.EncodeToString(salt)
+	pt, err := gcm.Open(nil, nonce, ct, buildFieldAADV1(path, saltB64, keyPath, parts[0], parts[1]))
+	if err != nil {
+		return "", nil, ErrDecrypt
+	}
+	return parts[0], pt, nil
+}
+
+// Why(中文): 整文档 MAC 覆盖所有叶子（含明文叶子）并按路径排序，增删字段或把 token 挪到别的键都会改变 MAC。
+// Why(English): The document MAC covers every leaf, plaintext ones included, sorted by path, so added, removed or moved fields all change it.
+func FieldMACV1(sk []byte, path string, salt []byte, leaves []FieldLeaf) (string, error) {
+	if len(sk) != 32 {
+		return "", ErrInvalidSK
+	}
+	if !isPathV1(path) {
+		return "", ErrInvalidPath
+	}
+	_, macKey, ok := deriveFieldKeysV1(sk, salt)
+	if !ok {
+		return "", ErrInvalidSK
+	}
+	sorted := make([]FieldLeaf, len(leaves))
+	copy(sorted, leaves)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+	m := hmac.New(sha256.New, macKey)
+	_, _ = m.Write([]byte("txlock:f1\npath:" + path + "\n"))
+	for _, leaf := range sorted {
+		_, _ = m.Write([]byte(strconv.Quote(leaf.Path) + " " + leaf.Type + " " + strconv.Quote(leaf.Value) + "\n"))
+	}
+	return base64.RawStdEncoding.EncodeToString(m.Sum(nil)), nil
+}
+
+// Why(中文): MAC 比较必须常量时间，避免逐字节比较把正确前缀长度泄露给反复尝试的攻击者。
+// Why(English): Compare MACs in constant time so repeated attempts cannot learn the length of a correct prefix.
+func VerifyFieldMACV1(sk []byte, path string, salt []byte, leaves []FieldLeaf, macB64 string) error {
+	want, err := FieldMACV1(sk, path, salt, leaves)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(want), []byte(macB64)) {
+		return ErrFieldMAC
+	}
+	return nil
+}
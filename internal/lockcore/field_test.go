@@ -0,0 +1,71 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func fieldFixtureSK(t *testing.T) []byte {
+	t.Helper()
+	sk, _ := hex.DecodeString("b1ec885280602151c894fb7c17d076a2469ae59161d3b418c08e2ce0b2f2ef21")
+	return sk
+}
+
+// Why(中文): token 必须保持单行且可原样解密回类型与明文，这是结构化文件可 diff 的前提。
+// Why(English): Tokens must stay single-line and open back to type and plaintext, which is what keeps structured files diffable.
+func TestSealOpenFieldV1RoundTrip(t *testing.T) {
+	sk := fieldFixtureSK(t)
+	salt := make([]byte, 32)
+	token, err := SealFieldV1(sk, "m/44'/60'/0'/0/777", salt, "/db/password", "str", []byte("s3cret"), bytes.NewReader(make([]byte, 12)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	if !IsFieldTokenV1(token) || strings.ContainsAny(token, "\n ") {
+		t.Fatalf("unexpected token shape: %q", token)
+	}
+	typ, pt, err := OpenFieldV1(sk, "m/44'/60'/0'/0/777", salt, "/db/password", token)
+	if err != nil || typ != "str" || string(pt) != "s3cret" {
+		t.Fatalf("unexpected open result: %q %q %v", typ, pt, err)
+	}
+}
+
+// Why(中文): 键路径绑定在 AAD 中，把 token 挪到其他键必须认证失败。
+// Why(English): The key path is AAD-bound, so moving a token to another key must fail authentication.
+func TestOpenFieldV1RejectsKeySwap(t *testing.T) {
+	sk := fieldFixtureSK(t)
+	salt := make([]byte, 32)
+	token, err := SealFieldV1(sk, "m/44'/60'/0'/0/777", salt, "/a", "str", []byte("x"), bytes.NewReader(make([]byte, 12)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	if _, _, err := OpenFieldV1(sk, "m/44'/60'/0'/0/777", salt, "/b", token); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for key swap, got %v", err)
+	}
+	retyped := strings.Replace(token, ":str:", ":int:", 1)
+	if _, _, err := OpenFieldV1(sk, "m/44'/60'/0'/0/777", salt, "/a", retyped); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for type drift, got %v", err)
+	}
+}
+
+// Why(中文): 文档 MAC 对叶子集合敏感，增删任一叶子都必须改变结果，且与输入顺序无关。
+// Why(English): The document MAC must react to any added or removed leaf while staying independent of input order.
+func TestFieldMACV1DetectsLeafSetChanges(t *testing.T) {
+	sk := fieldFixtureSK(t)
+	salt := make([]byte, 32)
+	a := []FieldLeaf{{Path: "/a", Type: "str", Value: "1"}, {Path: "/b", Type: "num", Value: "2"}}
+	b := []FieldLeaf{a[1], a[0]}
+	macA, _ := FieldMACV1(sk, "m/44'/60'/0'/0/777", salt, a)
+	macB, _ := FieldMACV1(sk, "m/44'/60'/0'/0/777", salt, b)
+	if macA != macB {
+		t.Fatalf("expected order-independent mac")
+	}
+	if err := VerifyFieldMACV1(sk, "m/44'/60'/0'/0/777", salt, a[:1], macA); err != ErrFieldMAC {
+		t.Fatalf("expected ErrFieldMAC for removed leaf, got %v", err)
+	}
+	extra := append([]FieldLeaf{{Path: "/c", Type: "str", Value: ""}}, a...)
+	if err := VerifyFieldMACV1(sk, "m/44'/60'/0'/0/777", salt, extra, macA); err != ErrFieldMAC {
+		t.Fatalf("expected ErrFieldMAC for added leaf, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package lockcore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+var errGCMSIVOpen = errors.New("gcm-siv: message authentication failed")
+
+const (
+	gcmSIVNonceSize = 12
+	gcmSIVTagSize   = 16
+	gcmSIVMaxInput  = 1 << 36
+)
+
+// Why(中文): 标准库与已有依赖都不提供 AES-GCM-SIV，按 RFC 8452 用 crypto/aes 自行实现，保证恢复时仍只依赖 Go 标准库。
+// Why(English): Neither the stdlib nor existing deps ship AES-GCM-SIV, so it is built on crypto/aes per RFC 8452 and recovery still needs only the standard library.
+type gcmSIV struct {
+	kgk cipher.Block
+}
+
+// Why(中文): 只接受 32 字节密钥，与套件名 aes-256-gcm-siv 一一对应，不允许降级到 AES-128。
+// Why(English): Accept only 32-byte keys so the suite name aes-256-gcm-siv can never silently downgrade to AES-128.
+func newAESGCMSIV(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, aes.KeySizeError(len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &gcmSIV{kgk: block}, nil
+}
+
+func (g *gcmSIV) NonceSize() int { return gcmSIVNonceSize }
+
+func (g *gcmSIV) Overhead() int { return gcmSIVTagSize }
+
+// Why(中文): 每个 nonce 派生独立的认证密钥与加密密钥（RFC 8452 §4），nonce 重复时也只泄露明文是否相同。
+// Why(English): Derive per-nonce authentication and encryption keys (RFC 8452 §4) so a repeated nonce reveals only whether plaintexts are equal.
+func (g *gcmSIV) deriveKeys(nonce []byte) ([16]byte, cipher.Block) {
+	var in, out [16]byte
+	var authKey [16]byte
+	var encKey [32]byte
+	copy(in[4:], nonce)
+	for i := uint32(0); i < 6; i++ {
+		binary.LittleEndian.PutUint32(in[:4], i)
+		g.kgk.Encrypt(out[:], in[:])
+		if i < 2 {
+			copy(authKey[i*8:], out[:8])
+		} else {
+			copy(encKey[(i-2)*8:], out[:8])
+		}
+	}
+	enc, _ := aes.NewCipher(encKey[:])
+	return authKey, enc
+}
+
+// Why(中文): 标签由 POLYVAL(AAD, 明文, 长度块) 与 nonce 混合后加密得到，同时充当 CTR 的初始计数器，这是抗 nonce 误用的核心。
+// Why(English): The tag is the encrypted mix of POLYVAL(AAD, plaintext, lengths) and the nonce, and it doubles as the CTR start, which is what makes the mode misuse-resistant.
+func gcmSIVTag(authKey [16]byte, enc cipher.Block, nonce, plaintext, aad []byte) [16]byte {
+	p := newPolyval(authKey)
+	p.update(aad)
+	p.update(plaintext)
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(aad))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*8)
+	p.update(lengths[:])
+	s := p.sum()
+	for i := range gcmSIVNonceSize {
+		s[i] ^= nonce[i]
+	}
+	s[15] &= 0x7f
+	enc.Encrypt(s[:], s[:])
+	return s
+}
+
+// Why(中文): GCM-SIV 的计数器是首 32 位小端递增并按 2^32 回绕，与 GCM 的大端计数不同，必须单独实现。
+// Why(English): GCM-SIV counts on the first 32 bits little-endian with 2^32 wraparound, unlike GCM's big-endian counter, so it needs its own CTR loop.
+func gcmSIVCTR(enc cipher.Block, tag [16]byte, dst, src []byte) {
+	ctr := tag
+	ctr[15] |= 0x80
+	var ks [16]byte
+	for len(src) > 0 {
+		enc.Encrypt(ks[:], ctr[:])
+		n := subtle.XORBytes(dst, src, ks[:])
+		dst, src = dst[n:], src[n:]
+		binary.LittleEndian.PutUint32(ctr[:4], binary.LittleEndian.Uint32(ctr[:4])+1)
+	}
+}
+
+// Why(中文): 先对明文计算标签再加密，输出布局与标准库 GCM 相同（密文 || 标签），调用方无需区分套件。
+// Why(English): Tag the plaintext first, then encrypt; the output layout matches stdlib GCM (ciphertext || tag) so callers need not care which suite ran.
+func (g *gcmSIV) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("gcm-siv: incorrect nonce length")
+	}
+	if uint64(len(plaintext)) > gcmSIVMaxInput || uint64(len(additionalData)) > gcmSIVMaxInput {
+		panic("gcm-siv: message too large")
+	}
+	authKey, enc := g.deriveKeys(nonce)
+	tag := gcmSIVTag(authKey, enc, nonce, plaintext, additionalData)
+	ret, out := sliceForAppend(dst, len(plaintext)+gcmSIVTagSize)
+	gcmSIVCTR(enc, tag, out[:len(plaintext)], plaintext)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+// Why(中文): 解密后重算标签并常数时间比较，失败时清零已写出的明文，不让未认证数据留在调用方缓冲区。
+// Why(English): Recompute the tag after decrypting and compare in constant time; on failure zero the output so unauthenticated bytes never linger in the caller's buffer.
+func (g *gcmSIV) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("gcm-siv: incorrect nonce length")
+	}
+	if len(ciphertext) < gcmSIVTagSize || uint64(len(ciphertext)) > gcmSIVMaxInput+gcmSIVTagSize || uint64(len(additionalData)) > gcmSIVMaxInput {
+		return nil, errGCMSIVOpen
+	}
+	var tag [16]byte
+	copy(tag[:], ciphertext[len(ciphertext)-gcmSIVTagSize:])
+	body := ciphertext[:len(ciphertext)-gcmSIVTagSize]
+	authKey, enc := g.deriveKeys(nonce)
+	ret, out := sliceForAppend(dst, len(body))
+	gcmSIVCTR(enc, tag, out, body)
+	want := gcmSIVTag(authKey, enc, nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(want[:], tag[:]) != 1 {
+		clear(out)
+		return nil, errGCMSIVOpen
+	}
+	return ret, nil
+}
+
+// Why(中文): 与标准库 AEAD 相同的 append 语义，dst 容量足够时原地写入，避免额外分配。
+// Why(English): Same append semantics as stdlib AEADs: write in place when dst has capacity, avoiding an extra allocation.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
+
+// Why(中文): POLYVAL 在小端位序的 GF(2^128) 上运算（模 x^128+x^127+x^126+x^121+1），用掩码代替分支保持常数时间。
+// Why(English): POLYVAL works in little-endian-bit GF(2^128) modulo x^128+x^127+x^126+x^121+1, using masks instead of branches to stay constant time.
+type polyval struct {
+	hLo, hHi uint64
+	sLo, sHi uint64
+}
+
+func newPolyval(h [16]byte) *polyval {
+	return &polyval{hLo: binary.LittleEndian.Uint64(h[:8]), hHi: binary.LittleEndian.Uint64(h[8:])}
+}
+
+// Why(中文): 输入按 16 字节分块，末块右侧补零，与 RFC 8452 对 AAD 与明文的填充规则一致。
+// Why(English): Input is split into 16-byte blocks with the last one zero-padded, matching RFC 8452's padding of AAD and plaintext.
+func (p *polyval) update(data []byte) {
+	for len(data) > 0 {
+		var blk [16]byte
+		n := copy(blk[:], data)
+		data = data[n:]
+		p.mulBlock(blk)
+	}
+}
+
+// Why(中文): S = dot(S ⊕ X, H)，dot(a,b) = a·b·x^-128；逐位累加后乘 x^-1 共 128 次，恰好得到 x^-128 因子。
+// Why(English): S = dot(S ⊕ X, H) with dot(a,b) = a·b·x^-128; accumulating bit by bit and multiplying by x^-1 each of 128 steps yields exactly that factor.
+func (p *polyval) mulBlock(blk [16]byte) {
+	xLo := p.sLo ^ binary.LittleEndian.Uint64(blk[:8])
+	xHi := p.sHi ^ binary.LittleEndian.Uint64(blk[8:])
+	var rLo, rHi uint64
+	for i := range 128 {
+		var bit uint64
+		if i < 64 {
+			bit = (xLo >> i) & 1
+		} else {
+			bit = (xHi >> (i - 64)) & 1
+		}
+		m := -bit
+		rLo ^= p.hLo & m
+		rHi ^= p.hHi & m
+		c := -(rLo & 1)
+		rHi ^= c & 0xc200000000000000
+		rLo = rLo>>1 | rHi<<63
+		rHi = rHi>>1 | c&(1<<63)
+	}
+	p.sLo, p.sHi = rLo, rHi
+}
+
+func (p *polyval) sum() [16]byte {
+	var out [16]byte
+	binary.LittleEndian.PutUint64(out[:8], p.sLo)
+	binary.LittleEndian.PutUint64(out[8:], p.sHi)
+	return out
+}
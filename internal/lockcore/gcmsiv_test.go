@@ -0,0 +1,287 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("bad hex %q: %v", s, err)
+	}
+	return b
+}
+
+// Why(中文): POLYVAL 是 GCM-SIV 的认证核心，先用 RFC 8452 附录 A 的示例单独锁定。
+// Why(English): POLYVAL is the authentication core of GCM-SIV, so pin it alone to the RFC 8452 Appendix A example first.
+func TestPolyvalRFC8452Example(t *testing.T) {
+	var h [16]byte
+	copy(h[:], mustHex(t, "25629347589242761d31f826ba4b757b"))
+	p := newPolyval(h)
+	p.update(mustHex(t, "4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362"))
+	got := p.sum()
+	if want := "f7a3b47b846119fae5b7866cf5e5b77e"; hex.EncodeToString(got[:]) != want {
+		t.Fatalf("polyval=%x want=%s", got, want)
+	}
+}
+
+// Why(中文): 自实现的 AES-256-GCM-SIV 必须与 RFC 8452 附录 C.2 的已知答案逐字节一致，覆盖非空 AAD、不足一块与多块明文、非对齐的 AAD 长度，以及 C.3 中计数器低 32 位回绕的两个用例。
+// Why(English): The in-tree AES-256-GCM-SIV must match RFC 8452 Appendix C.2 known answers byte for byte, covering non-empty AAD, partial-block and multi-block plaintexts and unaligned AAD lengths, plus the two C.3 cases where the low 32 counter bits wrap.
+func TestAESGCMSIVRFC8452Vectors(t *testing.T) {
+	cases := []struct {
+		name, key, nonce, aad, pt, out string
+	}{
+		{
+			name:  "C.2: empty plaintext, empty AAD",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			out:   "07f5f4169bbf55a8400cd47ea6fd400f",
+		},
+		{
+			name:  "C.2: 8-byte plaintext",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			pt:    "0100000000000000",
+			out:   "c2ef328e5c71c83b843122130f7364b761e0b97427e3df28",
+		},
+		{
+			name:  "C.2: 12-byte partial block",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			pt:    "010000000000000000000000",
+			out:   "9aab2aeb3faa0a34aea8e2b18ca50da9ae6559e48fd10f6e5c9ca17e",
+		},
+		{
+			name:  "C.2: one full block",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			pt:    "01000000000000000000000000000000",
+			out:   "85a01b63025ba19b7fd3ddfc033b3e76c9eac6fa700942702e90862383c6c366",
+		},
+		{
+			name:  "C.2: two blocks",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			pt:    "0100000000000000000000000000000002000000000000000000000000000000",
+			out:   "4a6a9db4c8c6549201b9edb53006cba821ec9cf850948a7c86c68ac7539d027fe819e63abcd020b006a976397632eb5d",
+		},
+		{
+			name:  "C.2: three blocks",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			pt:    "010000000000000000000000000000000200000000000000000000000000000003000000000000000000000000000000",
+			out:   "c00d121893a9fa603f48ccc1ca3c57ce7499245ea0046db16c53c7c66fe717e39cf6c748837b61f6ee3adcee17534ed5790bc96880a99ba804bd12c0e6a22cc4",
+		},
+		{
+			name:  "C.2: 1-byte AAD, 8-byte plaintext",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			aad:   "01",
+			pt:    "0200000000000000",
+			out:   "1de22967237a813291213f267e3b452f02d01ae33e4ec854",
+		},
+		{
+			name:  "C.2: 1-byte AAD, partial block",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			aad:   "01",
+			pt:    "020000000000000000000000",
+			out:   "163d6f9cc1b346cd453a2e4cc1a4a19ae800941ccdc57cc8413c277f",
+		},
+		{
+			name:  "C.2: 1-byte AAD, one block",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			aad:   "01",
+			pt:    "02000000000000000000000000000000",
+			out:   "c91545823cc24f17dbb0e9e807d5ec17b292d28ff61189e8e49f3875ef91aff7",
+		},
+		{
+			name:  "C.2: 1-byte AAD, two blocks",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			aad:   "01",
+			pt:    "0200000000000000000000000000000003000000000000000000000000000000",
+			out:   "07dad364bfc2b9da89116d7bef6daaaf6f255510aa654f920ac81b94e8bad365aea1bad12702e1965604374aab96dbbc",
+		},
+		{
+			name:  "C.2: 12-byte AAD, 4-byte plaintext",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			aad:   "010000000000000000000000",
+			pt:    "02000000",
+			out:   "22b3f4cd1835e517741dfddccfa07fa4661b74cf",
+		},
+		{
+			name:  "C.2: 18-byte AAD, 20-byte plaintext",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			aad:   "010000000000000000000000000000000200",
+			pt:    "0300000000000000000000000000000004000000",
+			out:   "43dd0163cdb48f9fe3212bf61b201976067f342bb879ad976d8242acc188ab59cabfe307",
+		},
+		{
+			name:  "C.2: 20-byte AAD, 18-byte plaintext",
+			key:   "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce: "030000000000000000000000",
+			aad:   "0100000000000000000000000000000002000000",
+			pt:    "030000000000000000000000000000000400",
+			out:   "462401724b5ce6588d5a54aae5375513a075cfcdf5042112aa29685c912fc2056543",
+		},
+		{
+			name:  "C.2: random key, empty",
+			key:   "e66021d5eb8e4f4066d4adb9c33560e4f46e44bb3da0015c94f7088736864200",
+			nonce: "e0eaf5284d884a0e77d31646",
+			out:   "169fbb2fbf389a995f6390af22228a62",
+		},
+		{
+			name:  "C.2: random key, 3-byte plaintext",
+			key:   "bae8e37fc83441b16034566b7a806c46bb91c3c5aedb64a6c590bc84d1a5e269",
+			nonce: "e4b47801afc0577e34699b9e",
+			aad:   "4fbdc66f14",
+			pt:    "671fdd",
+			out:   "0eaccb93da9bb81333aee0c785b240d319719d",
+		},
+		{
+			name:  "C.2: random key, 6-byte plaintext",
+			key:   "6545fc880c94a95198874296d5cc1fd161320b6920ce07787f86743b275d1ab3",
+			nonce: "2f6d1f0434d8848c1177441f",
+			aad:   "6787f3ea22c127aaf195",
+			pt:    "195495860f04",
+			out:   "a254dad4f3f96b62b84dc40c84636a5ec12020ec8c2c",
+		},
+		{
+			name:  "C.2: random key, 9-byte plaintext",
+			key:   "d1894728b3fed1473c528b8426a582995929a1499e9ad8780c8d63d0ab4149c0",
+			nonce: "9f572c614b4745914474e7c7",
+			aad:   "489c8fde2be2cf97e74e932d4ed87d",
+			pt:    "c9882e5386fd9f92ec",
+			out:   "0df9e308678244c44bc0fd3dc6628dfe55ebb0b9fb2295c8c2",
+		},
+		{
+			name:  "C.2: random key, 12-byte plaintext",
+			key:   "a44102952ef94b02b805249bac80e6f61455bfac8308a2d40d8c845117808235",
+			nonce: "5c9e940fea2f582950a70d5a",
+			aad:   "0da55210cc1c1b0abde3b2f204d1e9f8b06bc47f",
+			pt:    "1db2316fd568378da107b52b",
+			out:   "8dbeb9f7255bf5769dd56692404099c2587f64979f21826706d497d5",
+		},
+		{
+			name:  "C.2: random key, 15-byte plaintext",
+			key:   "9745b3d1ae06556fb6aa7890bebc18fe6b3db4da3d57aa94842b9803a96e07fb",
+			nonce: "6de71860f762ebfbd08284e4",
+			aad:   "f37de21c7ff901cfe8a69615a93fdf7a98cad481796245709f",
+			pt:    "21702de0de18baa9c9596291b08466",
+			out:   "793576dfa5c0f88729a7ed3c2f1bffb3080d28f6ebb5d3648ce97bd5ba67fd",
+		},
+		{
+			name:  "C.2: random key, 18-byte plaintext",
+			key:   "b18853f68d833640e42a3c02c25b64869e146d7b233987bddfc240871d7576f7",
+			nonce: "028ec6eb5ea7e298342a94d4",
+			aad:   "9c2159058b1f0fe91433a5bdc20e214eab7fecef4454a10ef0657df21ac7",
+			pt:    "b202b370ef9768ec6561c4fe6b7e7296fa85",
+			out:   "857e16a64915a787637687db4a9519635cdd454fc2a154fea91f8363a39fec7d0a49",
+		},
+		{
+			name:  "C.2: random key, 21-byte plaintext",
+			key:   "3c535de192eaed3822a2fbbe2ca9dfc88255e14a661b8aa82cc54236093bbc23",
+			nonce: "688089e55540db1872504e1c",
+			aad:   "734320ccc9d9bbbb19cb81b2af4ecbc3e72834321f7aa0f70b7282b4f33df23f167541",
+			pt:    "ced532ce4159b035277d4dfbb7db62968b13cd4eec",
+			out:   "626660c26ea6612fb17ad91e8e767639edd6c9faee9d6c7029675b89eaf4ba1ded1a286594",
+		},
+		{
+			name:  "C.3: counter wrap, two blocks",
+			key:   "0000000000000000000000000000000000000000000000000000000000000000",
+			nonce: "000000000000000000000000",
+			pt:    "000000000000000000000000000000004db923dc793ee6497c76dcc03a98e108",
+			out:   "f3f80f2cf0cb2dd9c5984fcda908456cc537703b5ba70324a6793a7bf218d3eaffffffff000000000000000000000000",
+		},
+		{
+			name:  "C.3: counter wrap, 24-byte plaintext",
+			key:   "0000000000000000000000000000000000000000000000000000000000000000",
+			nonce: "000000000000000000000000",
+			pt:    "eb3640277c7ffd1303c7a542d02d3e4c0000000000000000",
+			out:   "18ce4f0b8cb4d0cac65fea8f79257b20888e53e72299e56dffffffff000000000000000000000000",
+		},
+	}
+	for _, tc := range cases {
+		a, err := newAESGCMSIV(mustHex(t, tc.key))
+		if err != nil {
+			t.Fatalf("%s: new: %v", tc.name, err)
+		}
+		got := a.Seal(nil, mustHex(t, tc.nonce), mustHex(t, tc.pt), mustHex(t, tc.aad))
+		if hex.EncodeToString(got) != tc.out {
+			t.Fatalf("%s: seal=%x want=%s", tc.name, got, tc.out)
+		}
+		pt, err := a.Open(nil, mustHex(t, tc.nonce), got, mustHex(t, tc.aad))
+		if err != nil || !bytes.Equal(pt, mustHex(t, tc.pt)) {
+			t.Fatalf("%s: open=%x err=%v", tc.name, pt, err)
+		}
+	}
+}
+
+// Why(中文): 以 RFC 8452 的非空 AAD 多块用例为基准，逐位翻转标签、逐位翻转 AAD、增删 AAD 字节都必须认证失败且不返回明文。
+// Why(English): Starting from an RFC 8452 multi-block case with non-empty AAD, flipping any tag bit, flipping any AAD bit, or adding or dropping AAD bytes must fail auth and return no plaintext.
+func TestAESGCMSIVRejectsTagAndAADTamper(t *testing.T) {
+	a, err := newAESGCMSIV(mustHex(t, "0100000000000000000000000000000000000000000000000000000000000000"))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	nonce := mustHex(t, "030000000000000000000000")
+	aad := mustHex(t, "0100000000000000000000000000000002000000")
+	ct := mustHex(t, "462401724b5ce6588d5a54aae5375513a075cfcdf5042112aa29685c912fc2056543")
+	if _, err := a.Open(nil, nonce, ct, aad); err != nil {
+		t.Fatalf("baseline open: %v", err)
+	}
+	for i := len(ct) - gcmSIVTagSize; i < len(ct); i++ {
+		for bit := 0; bit < 8; bit++ {
+			bad := append([]byte(nil), ct...)
+			bad[i] ^= 1 << bit
+			if pt, err := a.Open(nil, nonce, bad, aad); err == nil || pt != nil {
+				t.Fatalf("tag byte %d bit %d: tamper accepted", i, bit)
+			}
+		}
+	}
+	for i := range aad {
+		for bit := 0; bit < 8; bit++ {
+			bad := append([]byte(nil), aad...)
+			bad[i] ^= 1 << bit
+			if pt, err := a.Open(nil, nonce, ct, bad); err == nil || pt != nil {
+				t.Fatalf("aad byte %d bit %d: tamper accepted", i, bit)
+			}
+		}
+	}
+	for _, bad := range [][]byte{nil, aad[:len(aad)-1], append(append([]byte(nil), aad...), 0)} {
+		if _, err := a.Open(nil, nonce, ct, bad); err == nil {
+			t.Fatalf("aad of length %d accepted", len(bad))
+		}
+	}
+}
+
+// Why(中文): 任意一位密文、AAD 改动或截断都必须认证失败，且不接受 AES-128 密钥。
+// Why(English): Any bit flip, AAD change or truncation must fail auth, and AES-128 keys are refused.
+func TestAESGCMSIVRejectsTamper(t *testing.T) {
+	a, _ := newAESGCMSIV(bytes.Repeat([]byte{7}, 32))
+	nonce := make([]byte, 12)
+	ct := a.Seal(nil, nonce, []byte("hello gcm-siv"), []byte("aad"))
+	for i := range ct {
+		bad := append([]byte(nil), ct...)
+		bad[i] ^= 1
+		if _, err := a.Open(nil, nonce, bad, []byte("aad")); err == nil {
+			t.Fatalf("tampered byte %d accepted", i)
+		}
+	}
+	if _, err := a.Open(nil, nonce, ct, []byte("aaD")); err == nil {
+		t.Fatal("wrong aad accepted")
+	}
+	if _, err := a.Open(nil, nonce, ct[:15], nil); err == nil {
+		t.Fatal("short ciphertext accepted")
+	}
+	if _, err := newAESGCMSIV(make([]byte, 16)); err == nil {
+		t.Fatal("expected 16-byte key rejected")
+	}
+}
@@ -0,0 +1,44 @@
+package lockcore
+
+import "errors"
+
+var (
+	ErrInvalidLabel  = errors.New("invalid label")
+	ErrLabelMismatch = errors.New("label does not match envelope header")
+)
+
+// Why(中文): 标签前缀固定并带命名空间，文件子密钥与 vault 的 "txlock/vault/..." 子密钥以及其他 HKDF 用途互不重叠。
+// Why(English): The label prefix is fixed and namespaced, so file subkeys never overlap the vault's "txlock/vault/..." subkeys or any other HKDF use.
+const LabelInfoPrefix = "txlock/file/"
+
+const maxLabelLen = 64
+
+// Why(中文): 标签会写入 AAD 绑定的头字段，只允许 1–64 个 [A-Za-z0-9._/-]，既能表达逻辑名与层级，又不会与头字段语法冲突或出现同形字符。
+// Why(English): The label may sit in the AAD-bound header, so only 1–64 of [A-Za-z0-9._/-] are allowed: enough for logical names and hierarchy, without clashing with header syntax or admitting look-alike characters.
+func ValidLabel(label string) bool {
+	if label == "" || len(label) > maxLabelLen {
+		return false
+	}
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == '/', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Why(中文): 子密钥 = HKDF(sk, 空 salt, "txlock/file/"+标签, 32)：同一索引下每个逻辑名得到独立密钥，泄露一个文件的子密钥不会暴露 sk 或其他文件；只凭助记词、索引与标签即可重算。
+// Why(English): subkey = HKDF(sk, empty salt, "txlock/file/"+label, 32): every logical name under one index gets its own key, a leaked file subkey exposes neither sk nor other files, and the mnemonic, index and label alone recompute it.
+func LabelKey(sk []byte, label string) ([]byte, error) {
+	if len(sk) != 32 {
+		return nil, ErrInvalidSK
+	}
+	if !ValidLabel(label) {
+		return nil, ErrInvalidLabel
+	}
+	return hkdfSHA256(sk, nil, []byte(LabelInfoPrefix+label), 32), nil
+}
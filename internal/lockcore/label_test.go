@@ -0,0 +1,134 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// Why(中文): 标签语法是头字段与命令行共用的契约，边界（空、超长、空白、冒号、非 ASCII）必须全部拒绝。
+// Why(English): The label grammar is a contract shared by the header and the command line; every boundary (empty, overlong, whitespace, colon, non-ASCII) must be rejected.
+func TestValidLabel(t *testing.T) {
+	for _, ok := range []string{"a", "notes.md", "bank/2024_q1-statement", strings.Repeat("x", 64)} {
+		if !ValidLabel(ok) {
+			t.Fatalf("%q should be valid", ok)
+		}
+	}
+	for _, bad := range []string{"", strings.Repeat("x", 65), "a b", "a:b", "a\nb", "é", "a+b"} {
+		if ValidLabel(bad) {
+			t.Fatalf("%q should be invalid", bad)
+		}
+	}
+}
+
+// Why(中文): 子密钥必须等于文档公式 HKDF(sk, 空 salt, "txlock/file/"+标签, 32)，不同标签互不相同且都不等于 sk。
+// Why(English): The subkey must equal the documented HKDF(sk, empty salt, "txlock/file/"+label, 32); different labels differ and none equals sk.
+func TestLabelKeyFormula(t *testing.T) {
+	sk := fixtureSKV2()
+	a, err := LabelKey(sk, "a")
+	if err != nil {
+		t.Fatalf("label key: %v", err)
+	}
+	b, _ := LabelKey(sk, "b")
+	if !bytes.Equal(a, hkdfSHA256(sk, make([]byte, 32), []byte("txlock/file/a"), 32)) {
+		t.Fatalf("subkey does not follow the documented formula")
+	}
+	if bytes.Equal(a, b) || bytes.Equal(a, sk) {
+		t.Fatalf("subkeys must be distinct from each other and from sk")
+	}
+	if _, err := LabelKey(sk, "a b"); err != ErrInvalidLabel {
+		t.Fatalf("expected ErrInvalidLabel, got %v", err)
+	}
+	if _, err := LabelKey(sk[:31], "a"); err != ErrInvalidSK {
+		t.Fatalf("expected ErrInvalidSK, got %v", err)
+	}
+}
+
+// Why(中文): 头字段携带标签时解密无需额外输入；标签受 AAD 保护，改写或删除都认证失败，显式给出冲突标签报 ErrLabelMismatch。
+// Why(English): A header label opens with no extra input; it is AAD-bound, so rewriting or stripping it fails auth, and an explicit conflicting label is ErrLabelMismatch.
+func TestSealOpenV2HeaderLabel(t *testing.T) {
+	path := "m/44'/60'/0'/0/777"
+	sealed, err := SealV2(fixtureSKV2(), path, []byte("hello"), SealOptionsV2{Label: "notes.md", Commit: true}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	raw := BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	if !strings.Contains(raw, "\nkdf:hkdf-sha256\nlabel:notes.md\naead:") {
+		t.Fatalf("label must follow kdf in the header:\n%s", raw)
+	}
+	h, ct, ok := ParseEnvelopeV2(raw)
+	if !ok {
+		t.Fatalf("parse failed")
+	}
+	if got, err := OpenV2(fixtureSKV2(), path, h, ct, OpenOptionsV2{}); err != nil || string(got) != "hello" {
+		t.Fatalf("open: %q %v", got, err)
+	}
+	if _, err := OpenV2(fixtureSKV2(), path, h, ct, OpenOptionsV2{Label: "other"}); err != ErrLabelMismatch {
+		t.Fatalf("expected ErrLabelMismatch, got %v", err)
+	}
+	rewritten := append([]HeaderField(nil), h...)
+	rewritten[1].Value = "other"
+	if _, err := OpenV2(fixtureSKV2(), path, rewritten, ct, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("rewritten label: expected ErrDecrypt, got %v", err)
+	}
+	stripped := append(append([]HeaderField(nil), h[0]), h[2:]...)
+	if _, err := OpenV2(fixtureSKV2(), path, stripped, ct, OpenOptionsV2{Label: "notes.md"}); err != ErrDecrypt {
+		t.Fatalf("stripped label: expected ErrDecrypt, got %v", err)
+	}
+	bin, ok := MarkdownToBinary(raw)
+	if !ok {
+		t.Fatalf("labeled envelope must convert to binary")
+	}
+	if back, ok := BinaryToMarkdown(bin); !ok || back != raw {
+		t.Fatalf("binary round trip changed the envelope")
+	}
+}
+
+// Why(中文): 隐藏标签不落盘，只有给出同一标签才能解开；不给或给错都与错误助记词一样认证失败。
+// Why(English): A hidden label is never written, so only the same label opens the file; omitting or mistyping it fails auth like a wrong mnemonic.
+func TestSealOpenV2HiddenLabel(t *testing.T) {
+	path := "m/44'/60'/0'/0/777"
+	sealed, err := SealV2(fixtureSKV2(), path, []byte("hello"), SealOptionsV2{Label: "notes.md", HideLabel: true}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if _, ok := HeaderValue(sealed.Header, "label"); ok {
+		t.Fatalf("hidden label leaked into the header")
+	}
+	if got, err := OpenV2(fixtureSKV2(), path, sealed.Header, sealed.Ciphertext, OpenOptionsV2{Label: "notes.md"}); err != nil || string(got) != "hello" {
+		t.Fatalf("open: %q %v", got, err)
+	}
+	for _, label := range []string{"", "notes.txt"} {
+		if _, err := OpenV2(fixtureSKV2(), path, sealed.Header, sealed.Ciphertext, OpenOptionsV2{Label: label}); err != ErrDecrypt {
+			t.Fatalf("label %q: expected ErrDecrypt, got %v", label, err)
+		}
+	}
+	if _, err := SealV2(fixtureSKV2(), path, []byte("x"), SealOptionsV2{HideLabel: true}, bytes.NewReader(make([]byte, 64))); err != ErrInvalidLabel {
+		t.Fatalf("hide without label: expected ErrInvalidLabel, got %v", err)
+	}
+}
+
+// Why(中文): 标签只对钱包 kdf 有意义：口令类封装拒绝标签选项，口令类头字段里出现标签也必须在解析阶段拒绝。
+// Why(English): Labels only make sense for the wallet kdf: password seals refuse the label options, and a label in a password-kdf header must be rejected at parse time.
+func TestLabelHeaderRequiresWalletKDF(t *testing.T) {
+	h := []HeaderField{
+		{Key: "kdf", Value: "argon2id"},
+		{Key: "kdf_params", Value: "m=65536,t=3,p=1"},
+		{Key: "label", Value: "a"},
+		{Key: "aead", Value: "aes-256-gcm"},
+	}
+	if validHeaderValuesV2(h) {
+		t.Fatalf("label with a password kdf must be rejected")
+	}
+	if !validHeaderValuesV2(append(h[:2:2], h[3])) {
+		t.Fatalf("the same header without the label must stay valid")
+	}
+	opts := SealOptionsV2{Label: "a"}
+	if _, err := SealPasswordV2([]byte("pw"), MinArgon2ParamsV2, []byte("x"), opts, bytes.NewReader(make([]byte, 64))); err != ErrInvalidLabel {
+		t.Fatalf("password seal: expected ErrInvalidLabel, got %v", err)
+	}
+	if _, err := SealHybridV2(fixtureSKV2(), "m/44'/60'/0'/0/777", []byte("pw"), MinArgon2ParamsV2, []byte("x"), opts, bytes.NewReader(make([]byte, 64))); err != ErrInvalidLabel {
+		t.Fatalf("hybrid seal: expected ErrInvalidLabel, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package lockcore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrMetadata = errors.New("invalid file metadata")
+
+const maxMetaLenV2 = 64 << 10
+
+type FileMetaV2 struct {
+	Name        string `json:"name,omitempty"`
+	Mode        string `json:"mode,omitempty"`
+	MTime       string `json:"mtime,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	SHA256      string `json:"sha256"`
+}
+
+// Why(中文): 统一权限与时间的序列化格式（4 位八进制、UTC RFC3339Nano），避免调用方各自格式化导致校验不一致。
+// Why(English): Fix mode and time serialization (4-digit octal, UTC RFC3339Nano) in one constructor so callers cannot format them inconsistently.
+func NewFileMetaV2(name string, mode fs.FileMode, mtime time.Time, contentType string) FileMetaV2 {
+	m := FileMetaV2{Name: name, ContentType: contentType}
+	if mode != 0 {
+		m.Mode = fmt.Sprintf("%04o", uint32(mode.Perm()))
+	}
+	if !mtime.IsZero() {
+		m.MTime = mtime.UTC().Format(time.RFC3339Nano)
+	}
+	return m
+}
+
+// Why(中文): 解析后的权限与时间以标准类型返回，CLI 落盘时无需再次解析字符串。
+// Why(English): Return parsed mode and time as standard types so the CLI never re-parses strings when restoring.
+func (m *FileMetaV2) FileMode() (fs.FileMode, bool) {
+	if m.Mode == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(m.Mode, 8, 32)
+	return fs.FileMode(n), err == nil
+}
+
+// Why(中文): 与 FileMode 对称，缺失时间时返回 false 让调用方保持默认 mtime。
+// Why(English): Mirrors FileMode; a missing time returns false so callers keep the default mtime.
+func (m *FileMetaV2) ModTime() (time.Time, bool) {
+	if m.MTime == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, m.MTime)
+	return t, err == nil
+}
+
+// Why(中文): 元数据只接受单层文件名与合法权限/时间格式，解密侧据此落盘时不会被引导写到目标目录之外；"." 与含任一平台路径分隔符的名字也拒绝，前者会让默认输出路径变成目录本身，后者在另一平台上会变成子路径。
+// Why(English): Metadata accepts only a bare file name and well-formed mode/time so writing it back can never escape the target directory; "." and names with either platform's path separator are refused too, since the former makes the default output path the directory itself and the latter becomes a sub-path on another platform.
+func (m *FileMetaV2) valid() bool {
+	if m.Name != "" && (m.Name == "." || strings.ContainsAny(m.Name, `/\`) || filepath.Base(m.Name) != m.Name || !filepath.IsLocal(m.Name)) {
+		return false
+	}
+	if m.Mode != "" {
+		n, err := strconv.ParseUint(m.Mode, 8, 32)
+		if err != nil || n > 0o777 || len(m.Mode) != 4 || m.Mode[0] != '0' {
+			return false
+		}
+	}
+	if m.MTime != "" {
+		if _, err := time.Parse(time.RFC3339Nano, m.MTime); err != nil {
+			return false
+		}
+	}
+	if len(m.SHA256) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(m.SHA256)
+	return err == nil
+}
+
+// Why(中文): 元数据与正文一起放进 AEAD 载荷，名称、权限、时间都不会出现在可见头中；长度前缀让拆分无歧义。
+// Why(English): Metadata rides inside the AEAD payload with the body so name, mode and time never appear in the visible header; a length prefix keeps the split unambiguous.
+func frameMetaV2(meta FileMetaV2, plaintext []byte) ([]byte, error) {
+	sum := sha256.Sum256(plaintext)
+	meta.SHA256 = hex.EncodeToString(sum[:])
+	if !meta.valid() {
+		return nil, ErrMetadata
+	}
+	js, err := json.Marshal(meta)
+	if err != nil || len(js) > maxMetaLenV2 {
+		return nil, ErrMetadata
+	}
+	out := make([]byte, 4, 4+len(js)+len(plaintext))
+	binary.BigEndian.PutUint32(out, uint32(len(js)))
+	out = append(out, js...)
+	return append(out, plaintext...), nil
+}
+
+// Why(中文): 拆分时严格解码 JSON 并复核 SHA-256，确保恢复出的文件与加密时的原文逐字节一致。
+// Why(English): Decode the JSON strictly and re-check SHA-256 on unframe so the restored file is byte-identical to what was sealed.
+func unframeMetaV2(payload []byte) (*FileMetaV2, []byte, error) {
+	if len(payload) < 4 {
+		return nil, nil, ErrMetadata
+	}
+	n := binary.BigEndian.Uint32(payload)
+	if n > maxMetaLenV2 || int(n) > len(payload)-4 {
+		return nil, nil, ErrMetadata
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload[4 : 4+n]))
+	dec.DisallowUnknownFields()
+	var meta FileMetaV2
+	if err := dec.Decode(&meta); err != nil || dec.More() || !meta.valid() {
+		return nil, nil, ErrMetadata
+	}
+	body := payload[4+n:]
+	sum := sha256.Sum256(body)
+	if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(meta.SHA256)) != 1 {
+		return nil, nil, ErrMetadata
+	}
+	return &meta, body, nil
+}
@@ -0,0 +1,54 @@
+package lockcore
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// Why(中文): 元数据必须随密文往返且 SHA-256 自动填充，解密侧才能据此忠实还原文件。
+// Why(English): Metadata must round-trip with an auto-filled SHA-256 so the decrypt side can restore the file faithfully.
+func TestSealOpenV2Meta(t *testing.T) {
+	mtime := time.Date(2024, 5, 6, 7, 8, 9, 123, time.UTC)
+	meta := NewFileMetaV2("notes.md", 0o600, mtime, "text/markdown")
+	sealed, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", []byte("# notes\n"), SealOptionsV2{Meta: &meta, Compress: "gzip"}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	pt, got, err := OpenV2Meta(fixtureSKV2(), "m/44'/60'/0'/0/777", sealed.Header, sealed.Ciphertext, OpenOptionsV2{})
+	if err != nil || string(pt) != "# notes\n" || got == nil {
+		t.Fatalf("unexpected open result: %q %v %v", pt, got, err)
+	}
+	mode, _ := got.FileMode()
+	mt, _ := got.ModTime()
+	if got.Name != "notes.md" || mode != 0o600 || !mt.Equal(mtime) || got.ContentType != "text/markdown" || len(got.SHA256) != 64 {
+		t.Fatalf("unexpected metadata: %#v", got)
+	}
+}
+
+// Why(中文): 带路径分隔符、".." 或 "." 的文件名会让解密侧写出目标目录或写到目录本身，必须在加密时就拒绝。
+// Why(English): Names with separators, ".." or "." would let decryption write outside the target or onto the directory itself, so sealing must reject them.
+func TestFrameMetaV2RejectsUnsafeName(t *testing.T) {
+	for _, name := range []string{"../x", "a/b", "/etc/passwd", "..", ".", `a\b`, `..\x`} {
+		if _, err := frameMetaV2(FileMetaV2{Name: name}, []byte("x")); err != ErrMetadata {
+			t.Fatalf("%q: expected ErrMetadata, got %v", name, err)
+		}
+	}
+}
+
+// Why(中文): 元数据中的摘要必须与正文一致，长度前缀越界或摘要不符都应拒绝。
+// Why(English): The recorded digest must match the body; out-of-range length prefixes or digest mismatches must be refused.
+func TestUnframeMetaV2Strict(t *testing.T) {
+	framed, err := frameMetaV2(FileMetaV2{Name: "a.txt"}, []byte("body"))
+	if err != nil {
+		t.Fatalf("unexpected frame error: %v", err)
+	}
+	if _, _, err := unframeMetaV2(append(append([]byte(nil), framed...), 'x')); err != ErrMetadata {
+		t.Fatalf("expected ErrMetadata for body drift, got %v", err)
+	}
+	bad := append([]byte(nil), framed...)
+	bad[0] = 0xff
+	if _, _, err := unframeMetaV2(bad); err != ErrMetadata {
+		t.Fatalf("expected ErrMetadata for length overflow, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package lockcore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Why(中文): PADMÉ 的规范长度用论文中的小值锁定，并校验开销上限不超过 12%。
+// Why(English): Pin PADMÉ's canonical lengths on small known values and check the overhead bound stays under 12%.
+func TestPaddedLenV2Padme(t *testing.T) {
+	cases := map[int]int{1: 1, 9: 10, 100: 104, 1000: 1024, 1025: 1088}
+	for in, want := range cases {
+		if got, ok := paddedLenV2("padme", in); !ok || got != want {
+			t.Fatalf("padme(%d) = %d, want %d", in, got, want)
+		}
+	}
+	for n := 2; n < 1<<16; n += 97 {
+		got, _ := paddedLenV2("padme", n)
+		if got < n || float64(got-n)/float64(n) > 0.12 {
+			t.Fatalf("padme(%d) = %d exceeds bound", n, got)
+		}
+	}
+}
+
+// Why(中文): 分桶填充应让 12 词与 24 词助记词备份得到相同长度，这是本特性要解决的核心泄露。
+// Why(English): Bucketed padding must give 12-word and 24-word backups the same length, the core leak this feature addresses.
+func TestPadV2HidesMnemonicLength(t *testing.T) {
+	short := []byte(strings.Repeat("abandon ", 11) + "about\n")
+	long := []byte(strings.Repeat("abandon ", 23) + "art\n")
+	a, err := padV2("bucket-256", short)
+	if err != nil {
+		t.Fatalf("pad short: %v", err)
+	}
+	b, err := padV2("bucket-256", long)
+	if err != nil {
+		t.Fatalf("pad long: %v", err)
+	}
+	if len(a) != len(b) {
+		t.Fatalf("expected equal padded lengths, got %d and %d", len(a), len(b))
+	}
+	got, err := unpadV2("bucket-256", a)
+	if err != nil || !bytes.Equal(got, short) {
+		t.Fatalf("unpad mismatch: err=%v", err)
+	}
+}
+
+// Why(中文): 非零填充、越界长度与非规范总长都必须拒绝，确保填充形态唯一。
+// Why(English): Non-zero fill, out-of-range lengths and non-canonical totals must all fail so the padded form is unique.
+func TestUnpadV2Strict(t *testing.T) {
+	p, _ := padV2("bucket-64", []byte("abc"))
+	bad := append([]byte(nil), p...)
+	bad[10] = 1
+	if _, err := unpadV2("bucket-64", bad); err != ErrPadding {
+		t.Fatalf("expected ErrPadding for non-zero fill, got %v", err)
+	}
+	long := make([]byte, 128)
+	copy(long, "abc")
+	long[127] = 128 - 3 - 8
+	if _, err := unpadV2("bucket-64", long); err != ErrPadding {
+		t.Fatalf("expected ErrPadding for non-canonical total, got %v", err)
+	}
+	for _, name := range []string{"bucket-100", "bucket-32", "bucket-064", "pkcs7"} {
+		if IsPaddingV2(name) {
+			t.Fatalf("expected %q to be rejected", name)
+		}
+	}
+}
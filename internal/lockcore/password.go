@@ -0,0 +1,216 @@
+package lockcore
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	ErrWeakKDF  = errors.New("kdf parameters below floor")
+	ErrKDFLimit = errors.New("kdf parameters exceed the decrypt ceiling")
+)
+
+// Why(中文): 双因子 kdf 名导出给 CLI，用于判断解密前是否需要同时索取助记词与口令。
+// Why(English): The two-factor kdf name is exported so the CLI can tell whether to ask for both mnemonic and password before opening.
+const KDFHybridV2 = "hkdf-sha256+argon2id"
+
+type Argon2ParamsV2 struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+// Why(中文): 默认取 RFC 9106 第二推荐配置（64 MiB、t=3、p=4），普通笔记本上约数百毫秒，离线爆破成本足够高。
+// Why(English): Defaults follow RFC 9106's second recommended profile (64 MiB, t=3, p=4): a few hundred ms on a laptop and costly to brute-force offline.
+var DefaultArgon2ParamsV2 = Argon2ParamsV2{Memory: 64 * 1024, Time: 3, Threads: 4}
+
+// Why(中文): 下限取 OWASP 最低建议（19 MiB、t=2、p=1），加解密两侧都强制，攻击者无法通过改写头字段把成本降到下限以下。
+// Why(English): Floors follow the OWASP minimum (19 MiB, t=2, p=1) and are enforced on both sides so a rewritten header cannot push the cost below them.
+var MinArgon2ParamsV2 = Argon2ParamsV2{Memory: 19 * 1024, Time: 2, Threads: 1}
+
+// Why(中文): 格式上限（4 GiB、t=64）只界定合法参数；头字段在认证之前就被采用，这个上限本身仍足以让恶意文件耗尽资源，所以解密另有更低的默认上限。
+// Why(English): Format limits (4 GiB, t=64) only bound what is a legal parameter set; the header is used before anything is authenticated and this limit alone still lets a hostile file exhaust resources, so decrypt applies a lower default ceiling.
+const (
+	maxArgon2MemoryV2 = 4 * 1024 * 1024
+	maxArgon2TimeV2   = 64
+)
+
+// Why(中文): 解密默认上限 1 GiB、t=16：未经认证的头字段最多让解密方付出这么多就被拒绝；更高的参数需要调用方经 OpenOptionsV2.MaxKDF 显式放开。
+// Why(English): The default decrypt ceiling is 1 GiB and t=16, the most an unauthenticated header can make the opener spend before rejection; higher parameters need the caller to opt in through OpenOptionsV2.MaxKDF.
+var DefaultMaxArgon2ParamsV2 = Argon2ParamsV2{Memory: 1024 * 1024, Time: 16}
+
+// Why(中文): 参数以固定顺序 m=,t=,p= 写入头字段，随 AAD 一起被认证。
+// Why(English): Parameters are written as a fixed-order m=,t=,p= header value and authenticated with the AAD.
+func (p Argon2ParamsV2) String() string {
+	return "m=" + strconv.FormatUint(uint64(p.Memory), 10) +
+		",t=" + strconv.FormatUint(uint64(p.Time), 10) +
+		",p=" + strconv.FormatUint(uint64(p.Threads), 10)
+}
+
+// Why(中文): 下限与上限集中在一处判断，CLI 校验参数与解析头字段共用同一规则。
+// Why(English): Floors and ceilings live in one check shared by CLI flag validation and header parsing.
+func (p Argon2ParamsV2) Valid() bool {
+	return p.Memory >= MinArgon2ParamsV2.Memory && p.Memory <= maxArgon2MemoryV2 &&
+		p.Time >= MinArgon2ParamsV2.Time && p.Time <= maxArgon2TimeV2 &&
+		p.Threads >= MinArgon2ParamsV2.Threads
+}
+
+// Why(中文): 严格解析：顺序固定、十进制无前导零，同一参数只有一种写法，避免 AAD 出现多种等价表示。
+// Why(English): Strict parsing with fixed order and no leading zeros so each parameter set has exactly one spelling in the AAD.
+func ParseArgon2ParamsV2(s string) (Argon2ParamsV2, bool) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return Argon2ParamsV2{}, false
+	}
+	var vals [3]uint64
+	for i, prefix := range []string{"m=", "t=", "p="} {
+		raw, ok := strings.CutPrefix(parts[i], prefix)
+		if !ok || raw == "" || (len(raw) > 1 && raw[0] == '0') {
+			return Argon2ParamsV2{}, false
+		}
+		n, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return Argon2ParamsV2{}, false
+		}
+		vals[i] = n
+	}
+	if vals[2] > 255 {
+		return Argon2ParamsV2{}, false
+	}
+	return Argon2ParamsV2{Memory: uint32(vals[0]), Time: uint32(vals[1]), Threads: uint8(vals[2])}, true
+}
+
+// Why(中文): Argon2id 输出作为 HKDF 的输入密钥材料，后续套件、版本与承诺的域分离与钱包模式完全一致。
+// Why(English): The Argon2id output becomes HKDF input keying material, so suite, version and commitment separation match wallet mode exactly.
+func passwordSourceV2(password []byte, params Argon2ParamsV2) keySourceV2 {
+	return keySourceV2{
+		kdf: []HeaderField{{Key: "kdf", Value: "argon2id"}, {Key: "kdf_params", Value: params.String()}},
+		ikm: func(salt []byte) ([]byte, bool) {
+			if !params.Valid() {
+				return nil, false
+			}
+			return argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, 32), true
+		},
+	}
+}
+
+// Why(中文): 口令模式让没有钱包的审计方也能解密；除密钥来源外复用同一 envelope、AEAD 与可选特性。
+// Why(English): Password mode lets a wallet-less auditor decrypt; apart from the key source it reuses the same envelope, AEAD and optional features.
+func SealPasswordV2(password []byte, params Argon2ParamsV2, plaintext []byte, opts SealOptionsV2, random io.Reader) (*SealResultV2, error) {
+	if opts.Label != "" || opts.HideLabel {
+		return nil, ErrInvalidLabel
+	}
+	if len(password) == 0 {
+		return nil, ErrEncrypt
+	}
+	if !params.Valid() {
+		return nil, ErrWeakKDF
+	}
+	return sealV2(passwordSourceV2(password, params), plaintext, opts, random)
+}
+
+// Why(中文): 参数取自尚未认证的头字段，因此在运行 Argon2 之前先按下限与解密上限复核，降级或超额的头字段直接拒绝而不是白白计算。
+// Why(English): Parameters come from the not-yet-authenticated header, so they are checked against the floors and the decrypt ceiling before Argon2 runs; a downgraded or oversized header is refused rather than computed.
+func OpenPasswordV2Meta(password []byte, h []HeaderField, ciphertext []byte, opts OpenOptionsV2) ([]byte, *FileMetaV2, error) {
+	if len(password) == 0 {
+		return nil, nil, ErrDecrypt
+	}
+	params, err := headerArgon2ParamsV2(h, "argon2id", opts.MaxKDF)
+	if err != nil {
+		return nil, nil, err
+	}
+	return openV2(passwordSourceV2(password, params), h, ciphertext, opts)
+}
+
+// Why(中文): 两种含口令的 kdf 共用同一参数提取与上下限复核，kdf 不符按解密失败处理，低于下限报 ErrWeakKDF，超过解密上限（不高于格式上限）报 ErrKDFLimit；limit 的零值字段取默认上限。
+// Why(English): Both password-bearing kdfs share one parameter extraction and bounds check; a kdf mismatch is a decrypt failure, a floor breach is ErrWeakKDF and exceeding the decrypt ceiling (never above the format limits) is ErrKDFLimit; zero fields in limit take the default ceiling.
+func headerArgon2ParamsV2(h []HeaderField, kdf string, limit Argon2ParamsV2) (Argon2ParamsV2, error) {
+	if got, _ := HeaderValue(h, "kdf"); got != kdf {
+		return Argon2ParamsV2{}, ErrDecrypt
+	}
+	raw, _ := HeaderValue(h, "kdf_params")
+	params, ok := ParseArgon2ParamsV2(raw)
+	if !ok {
+		return Argon2ParamsV2{}, ErrDecrypt
+	}
+	if limit.Memory == 0 {
+		limit.Memory = DefaultMaxArgon2ParamsV2.Memory
+	}
+	if limit.Time == 0 {
+		limit.Time = DefaultMaxArgon2ParamsV2.Time
+	}
+	if params.Memory > min(limit.Memory, maxArgon2MemoryV2) || params.Time > min(limit.Time, maxArgon2TimeV2) {
+		return Argon2ParamsV2{}, ErrKDFLimit
+	}
+	if !params.Valid() {
+		return Argon2ParamsV2{}, ErrWeakKDF
+	}
+	return params, nil
+}
+
+// Why(中文): 双因子模式把 SK 与 Argon2id 口令哈希拼接后一起送入 HKDF，任何单一因子都无法得到 K；路径仍绑定在 INFO 与 AAD 中。
+// Why(English): Two-factor mode feeds SK concatenated with the Argon2id password hash into HKDF so neither factor alone yields K; the path stays bound in INFO and AAD.
+func hybridSourceV2(sk []byte, path string, password []byte, params Argon2ParamsV2) keySourceV2 {
+	return keySourceV2{
+		path: path,
+		kdf:  []HeaderField{{Key: "kdf", Value: KDFHybridV2}, {Key: "kdf_params", Value: params.String()}},
+		ikm: func(salt []byte) ([]byte, bool) {
+			if !params.Valid() {
+				return nil, false
+			}
+			ikm := make([]byte, 0, len(sk)+32)
+			ikm = append(ikm, sk...)
+			return append(ikm, argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, 32)...), true
+		},
+	}
+}
+
+// Why(中文): 头字段 kdf 直接声明需要助记词与口令两个因子，解密方据此知道必须同时提供两者。
+// Why(English): The header kdf itself declares that both mnemonic and password are required, so the opener knows to supply both.
+func SealHybridV2(sk []byte, path string, password []byte, params Argon2ParamsV2, plaintext []byte, opts SealOptionsV2, random io.Reader) (*SealResultV2, error) {
+	if opts.Label != "" || opts.HideLabel {
+		return nil, ErrInvalidLabel
+	}
+	if len(sk) != 32 {
+		return nil, ErrInvalidSK
+	}
+	if !isPathV1(path) {
+		return nil, ErrInvalidPath
+	}
+	if len(password) == 0 {
+		return nil, ErrEncrypt
+	}
+	if !params.Valid() {
+		return nil, ErrWeakKDF
+	}
+	return sealV2(hybridSourceV2(sk, path, password, params), plaintext, opts, random)
+}
+
+// Why(中文): 与口令入口相同地先复核参数下限，再用两个因子重建 IKM；缺任一因子都只会得到认证失败。
+// Why(English): Like the password entry, re-check the floors first, then rebuild IKM from both factors; missing either factor only ever fails auth.
+func OpenHybridV2Meta(sk []byte, path string, password []byte, h []HeaderField, ciphertext []byte, opts OpenOptionsV2) ([]byte, *FileMetaV2, error) {
+	if len(sk) != 32 {
+		return nil, nil, ErrInvalidSK
+	}
+	if !isPathV1(path) {
+		return nil, nil, ErrInvalidPath
+	}
+	if len(password) == 0 {
+		return nil, nil, ErrDecrypt
+	}
+	params, err := headerArgon2ParamsV2(h, KDFHybridV2, opts.MaxKDF)
+	if err != nil {
+		return nil, nil, err
+	}
+	return openV2(hybridSourceV2(sk, path, password, params), h, ciphertext, opts)
+}
+
+// Why(中文): argon2id 与双因子两种 kdf 都必须携带 kdf_params，集中判断供解析白名单复用。
+// Why(English): Both argon2id and the two-factor kdf must carry kdf_params; one predicate serves the parse-time allow-list.
+func usesArgon2V2(kdf string) bool {
+	return kdf == "argon2id" || kdf == KDFHybridV2
+}
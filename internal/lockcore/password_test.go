@@ -0,0 +1,163 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func fixturePasswordV2() []byte {
+	return []byte("correct horse battery staple")
+}
+
+// Why(中文): 锁定口令模式的确定性向量，Argon2id、HKDF 与无路径 AAD 的任何改动都会在这里暴露。
+// Why(English): Lock a deterministic password-mode vector so changes to Argon2id, HKDF or the path-free AAD show up here.
+func TestSealPasswordV2Vector(t *testing.T) {
+	pt := []byte("txlock password vector")
+	sealed, err := SealPasswordV2(fixturePasswordV2(), MinArgon2ParamsV2, pt, SealOptionsV2{}, bytes.NewReader(bytes.Repeat([]byte{0x42}, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	if v, _ := HeaderValue(sealed.Header, "kdf_params"); v != "m=19456,t=2,p=1" {
+		t.Fatalf("unexpected kdf_params: %q", v)
+	}
+	if got := hex.EncodeToString(sealed.Ciphertext); got != "fff14fcf9c30601c883c438b4699f25554f5343416c1265a0ddebbebb9307cceb5456e254999" {
+		t.Fatalf("unexpected ciphertext: %s", got)
+	}
+	raw := BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	h, ct, ok := ParseEnvelopeV2(raw)
+	if !ok {
+		t.Fatalf("unexpected parse failure")
+	}
+	got, _, err := OpenPasswordV2Meta(fixturePasswordV2(), h, ct, OpenOptionsV2{})
+	if err != nil || !bytes.Equal(got, pt) {
+		t.Fatalf("round-trip mismatch: err=%v", err)
+	}
+	if _, _, err := OpenPasswordV2Meta([]byte("wrong horse"), h, ct, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for wrong password, got %v", err)
+	}
+}
+
+// Why(中文): 把头字段改成低于下限的参数必须直接报 ErrWeakKDF；改成其他合法参数也会因 AAD 变化而认证失败。
+// Why(English): Rewriting the header below the floors must yield ErrWeakKDF; rewriting to other valid parameters still fails auth via the AAD.
+func TestOpenPasswordV2RejectsDowngrade(t *testing.T) {
+	sealed, err := SealPasswordV2(fixturePasswordV2(), MinArgon2ParamsV2, []byte("hello"), SealOptionsV2{Commit: true}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	weak := append([]HeaderField(nil), sealed.Header...)
+	weak[1].Value = "m=8,t=1,p=1"
+	if _, _, err := OpenPasswordV2Meta(fixturePasswordV2(), weak, sealed.Ciphertext, OpenOptionsV2{}); err != ErrWeakKDF {
+		t.Fatalf("expected ErrWeakKDF, got %v", err)
+	}
+	other := append([]HeaderField(nil), sealed.Header...)
+	other[1].Value = "m=19456,t=3,p=1"
+	if _, _, err := OpenPasswordV2Meta(fixturePasswordV2(), other, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+	if _, err := SealPasswordV2(fixturePasswordV2(), Argon2ParamsV2{Memory: 1024, Time: 1, Threads: 1}, []byte("x"), SealOptionsV2{}, bytes.NewReader(make([]byte, 64))); err != ErrWeakKDF {
+		t.Fatalf("expected ErrWeakKDF on seal, got %v", err)
+	}
+}
+
+// Why(中文): 未认证的头字段超过默认解密上限（1 GiB、t=16）时必须在运行 Argon2 之前报 ErrKDFLimit；调用方显式放开后才会计算（此处因 AAD 变化而认证失败），但放开也不能越过格式上限。
+// Why(English): An unauthenticated header above the default decrypt ceiling (1 GiB, t=16) must yield ErrKDFLimit before Argon2 runs; only an explicit caller opt-in lets it compute (failing auth here via the AAD), and the opt-in never goes past the format limits.
+func TestOpenPasswordV2EnforcesDecryptCeiling(t *testing.T) {
+	sealed, err := SealPasswordV2(fixturePasswordV2(), MinArgon2ParamsV2, []byte("hello"), SealOptionsV2{}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	for _, tc := range []struct {
+		params string
+		limit  Argon2ParamsV2
+		want   error
+	}{
+		{"m=2097152,t=2,p=1", Argon2ParamsV2{}, ErrKDFLimit},
+		{"m=19456,t=17,p=1", Argon2ParamsV2{}, ErrKDFLimit},
+		{"m=19456,t=17,p=1", Argon2ParamsV2{Time: 16}, ErrKDFLimit},
+		{"m=8388608,t=2,p=1", Argon2ParamsV2{Memory: 16 << 20}, ErrKDFLimit},
+		{"m=19456,t=17,p=1", Argon2ParamsV2{Time: 32}, ErrDecrypt},
+	} {
+		h := append([]HeaderField(nil), sealed.Header...)
+		h[1].Value = tc.params
+		if _, _, err := OpenPasswordV2Meta(fixturePasswordV2(), h, sealed.Ciphertext, OpenOptionsV2{MaxKDF: tc.limit}); err != tc.want {
+			t.Fatalf("%s with %+v: expected %v, got %v", tc.params, tc.limit, tc.want, err)
+		}
+	}
+}
+
+// Why(中文): 钱包入口与口令入口互不接受对方的 envelope，避免 kdf 混淆。
+// Why(English): Wallet and password entry points refuse each other's envelopes so kdf modes can never be confused.
+func TestOpenV2KDFModeMismatch(t *testing.T) {
+	pw, err := SealPasswordV2(fixturePasswordV2(), MinArgon2ParamsV2, []byte("hello"), SealOptionsV2{}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	if _, err := OpenV2(fixtureSKV2(), "m/44'/60'/0'/0/777", pw.Header, pw.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+	wallet, err := SealV2(fixtureSKV2(), "m/44'/60'/0'/0/777", []byte("hello"), SealOptionsV2{Compress: "gzip"}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	if _, _, err := OpenPasswordV2Meta(fixturePasswordV2(), wallet.Header, wallet.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+}
+
+// Why(中文): 参数文本只有一种规范写法，乱序、前导零、溢出都视为非法。
+// Why(English): The parameter text has one canonical spelling; reordering, leading zeros and overflow are invalid.
+func TestParseArgon2ParamsV2(t *testing.T) {
+	p, ok := ParseArgon2ParamsV2("m=65536,t=3,p=4")
+	if !ok || p != DefaultArgon2ParamsV2 || p.String() != "m=65536,t=3,p=4" {
+		t.Fatalf("unexpected parse result: %#v %v", p, ok)
+	}
+	for _, bad := range []string{"", "t=3,m=65536,p=4", "m=065536,t=3,p=4", "m=65536,t=3,p=256", "m=65536,t=3", "m=65536,t=3,p=4,x=1", "m=-1,t=3,p=4", "m=65536, t=3,p=4"} {
+		if _, ok := ParseArgon2ParamsV2(bad); ok {
+			t.Fatalf("expected reject for %q", bad)
+		}
+	}
+	if (Argon2ParamsV2{Memory: 19 * 1024, Time: 1, Threads: 1}).Valid() {
+		t.Fatalf("t below floor must be invalid")
+	}
+	if (Argon2ParamsV2{Memory: 8 << 20, Time: 3, Threads: 4}).Valid() {
+		t.Fatalf("memory above ceiling must be invalid")
+	}
+}
+
+// Why(中文): 锁定双因子模式向量，并验证只有 SK、只有口令、或任一因子错误时都无法解密。
+// Why(English): Lock a two-factor vector and verify that SK alone, password alone, or either factor wrong never decrypts.
+func TestSealHybridV2RequiresBothFactors(t *testing.T) {
+	path := "m/44'/60'/0'/0/777"
+	pt := []byte("txlock hybrid vector")
+	sealed, err := SealHybridV2(fixtureSKV2(), path, fixturePasswordV2(), MinArgon2ParamsV2, pt, SealOptionsV2{}, bytes.NewReader(bytes.Repeat([]byte{0x42}, 64)))
+	if err != nil {
+		t.Fatalf("unexpected seal error: %v", err)
+	}
+	if got := hex.EncodeToString(sealed.Ciphertext); got != "673a9c206e85085275d0cc5c8a8ffd1097b054487e3fdce83421b0882550ae86f7460c1c" {
+		t.Fatalf("unexpected ciphertext: %s", got)
+	}
+	if kdf, _ := HeaderValue(sealed.Header, "kdf"); kdf != KDFHybridV2 {
+		t.Fatalf("hybrid header must declare both factors, got %q", kdf)
+	}
+	got, _, err := OpenHybridV2Meta(fixtureSKV2(), path, fixturePasswordV2(), sealed.Header, sealed.Ciphertext, OpenOptionsV2{})
+	if err != nil || !bytes.Equal(got, pt) {
+		t.Fatalf("round-trip mismatch: err=%v", err)
+	}
+	if _, err := OpenV2(fixtureSKV2(), path, sealed.Header, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for SK alone, got %v", err)
+	}
+	if _, _, err := OpenPasswordV2Meta(fixturePasswordV2(), sealed.Header, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for password alone, got %v", err)
+	}
+	if _, _, err := OpenHybridV2Meta(fixtureSKV2(), path, []byte("wrong horse"), sealed.Header, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for wrong password, got %v", err)
+	}
+	if _, _, err := OpenHybridV2Meta(bytes.Repeat([]byte{0x11}, 32), path, fixturePasswordV2(), sealed.Header, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for wrong SK, got %v", err)
+	}
+	if _, _, err := OpenHybridV2Meta(fixtureSKV2(), "m/44'/60'/0'/0/778", fixturePasswordV2(), sealed.Header, sealed.Ciphertext, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt for path drift, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package lockcore
+
+import (
+	"errors"
+	"strconv"
+)
+
+var ErrInvalidSequence = errors.New("invalid id/seq")
+
+// Why(中文): seq 是十进制正整数且不允许前导零，同一序号只有一种写法，写入 AAD 后无法被改写成等值的别种拼写。
+// Why(English): seq is a positive decimal with no leading zeros, so each number has exactly one spelling and an AAD-bound value cannot be respelled.
+func ParseSeq(s string) (uint64, bool) {
+	if s == "" || s[0] == '0' {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// Why(中文): id 与 seq 成对出现；只有认证通过后的头字段才值得据此做回滚判断，调用方应在解密成功后再读取。
+// Why(English): id and seq come as a pair; only an authenticated header is worth a rollback decision, so callers should read them after decryption succeeds.
+func SequenceOf(h []HeaderField) (string, uint64, bool) {
+	id, hasID := HeaderValue(h, "id")
+	raw, hasSeq := HeaderValue(h, "seq")
+	if !hasID || !hasSeq {
+		return "", 0, false
+	}
+	seq, ok := ParseSeq(raw)
+	return id, seq, ok
+}
@@ -0,0 +1,70 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// Why(中文): seq 只接受无前导零的正十进制数，溢出与空串同样拒绝。
+// Why(English): seq accepts only positive decimals without leading zeros; overflow and the empty string are rejected too.
+func TestParseSeq(t *testing.T) {
+	for in, want := range map[string]uint64{"1": 1, "42": 42, "18446744073709551615": 1<<64 - 1} {
+		if got, ok := ParseSeq(in); !ok || got != want {
+			t.Fatalf("%q: got %d %v", in, got, ok)
+		}
+	}
+	for _, bad := range []string{"", "0", "01", "-1", "+1", "1.0", "18446744073709551616"} {
+		if _, ok := ParseSeq(bad); ok {
+			t.Fatalf("%q should be rejected", bad)
+		}
+	}
+}
+
+// Why(中文): id/seq 写入头字段并受 AAD 保护：改写序号必须认证失败；口令模式同样可用；只给一半或非法取值报 ErrInvalidSequence。
+// Why(English): id/seq go into the header under the AAD: rewriting the seq must fail auth; password mode works too; half a pair or invalid values are ErrInvalidSequence.
+func TestSealOpenV2Sequence(t *testing.T) {
+	path := "m/44'/60'/0'/0/777"
+	sealed, err := SealV2(fixtureSKV2(), path, []byte("v7"), SealOptionsV2{ID: "notes", Seq: 7, Label: "notes.md"}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	raw := BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext))
+	if !strings.Contains(raw, "\nlabel:notes.md\nid:notes\nseq:7\naead:") {
+		t.Fatalf("unexpected header order:\n%s", raw)
+	}
+	h, ct, ok := ParseEnvelopeV2(raw)
+	if !ok {
+		t.Fatalf("parse failed")
+	}
+	if id, seq, ok := SequenceOf(h); !ok || id != "notes" || seq != 7 {
+		t.Fatalf("SequenceOf: %q %d %v", id, seq, ok)
+	}
+	if got, err := OpenV2(fixtureSKV2(), path, h, ct, OpenOptionsV2{}); err != nil || string(got) != "v7" {
+		t.Fatalf("open: %q %v", got, err)
+	}
+	if _, _, ok := ParseEnvelopeV2(strings.Replace(raw, "seq:7\n", "", 1)); ok {
+		t.Fatalf("id without seq must not parse")
+	}
+	rolled := strings.Replace(raw, "seq:7\n", "seq:8\n", 1)
+	h2, ct2, ok := ParseEnvelopeV2(rolled)
+	if !ok {
+		t.Fatalf("parse failed")
+	}
+	if _, err := OpenV2(fixtureSKV2(), path, h2, ct2, OpenOptionsV2{}); err != ErrDecrypt {
+		t.Fatalf("rewritten seq: expected ErrDecrypt, got %v", err)
+	}
+	pw, err := SealPasswordV2([]byte("pw"), MinArgon2ParamsV2, []byte("x"), SealOptionsV2{ID: "notes", Seq: 1}, bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("password seal: %v", err)
+	}
+	if _, seq, ok := SequenceOf(pw.Header); !ok || seq != 1 {
+		t.Fatalf("password header lacks id/seq")
+	}
+	for _, opts := range []SealOptionsV2{{ID: "notes"}, {Seq: 1}, {ID: "bad id", Seq: 1}} {
+		if _, err := SealV2(fixtureSKV2(), path, []byte("x"), opts, bytes.NewReader(make([]byte, 64))); err != ErrInvalidSequence {
+			t.Fatalf("%+v: expected ErrInvalidSequence, got %v", opts, err)
+		}
+	}
+}
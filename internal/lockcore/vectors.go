@@ -0,0 +1,76 @@
+package lockcore
+
+import (
+	"bytes"
+	"encoding/base64"
+)
+
+type WorkedExample struct {
+	Version   string
+	Path      string
+	Info      string
+	Key       []byte
+	CommitKey []byte
+	AAD       string
+	Envelope  string
+}
+
+// Why(中文): 恢复包里的向量必须由真实实现现场计算，而不是手抄常量；这里直接调用 hkdfSHA256/buildAADV1/SealV1，输出每一个中间值。
+// Why(English): Recovery-kit vectors must be computed live by the real implementation rather than copied constants, so this calls hkdfSHA256, buildAADV1 and SealV1 directly and exposes every intermediate.
+func WorkedExampleV1(sk []byte, path string, salt []byte, nonce []byte, plaintext []byte) (*WorkedExample, error) {
+	sealed, err := SealV1(sk, path, plaintext, bytes.NewReader(append(append([]byte{}, salt...), nonce...)))
+	if err != nil {
+		return nil, err
+	}
+	return &WorkedExample{
+		Version:  "v1",
+		Path:     path,
+		Info:     infoV1,
+		Key:      hkdfSHA256(sk, salt, []byte(infoV1), 32),
+		AAD:      string(buildAADV1(path, sealed.SaltB64, sealed.NonceB64)),
+		Envelope: BuildEnvelopeV1(path, sealed.SaltB64, sealed.NonceB64, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext)),
+	}, nil
+}
+
+// Why(中文): v3 示例覆盖 v2 语法、INFO 组合与密钥承诺三处规则，恢复实现只要对上它就同时覆盖 v2（去掉 commit 即可）。
+// Why(English): The v3 example exercises the v2 grammar, the composed INFO and key commitment at once; matching it covers v2 too, which merely drops the commitment.
+func WorkedExampleV3(sk []byte, path string, salt []byte, nonce []byte, plaintext []byte) (*WorkedExample, error) {
+	sealed, err := SealV2(sk, path, plaintext, SealOptionsV2{Commit: true}, bytes.NewReader(append(append([]byte{}, salt...), nonce...)))
+	if err != nil {
+		return nil, err
+	}
+	key, commitKey := deriveKeysV2(sk, salt, "v3", sealed.Header)
+	return &WorkedExample{
+		Version:   "v3",
+		Path:      path,
+		Info:      string(infoV2("v3", sealed.Header)),
+		Key:       key,
+		CommitKey: commitKey,
+		AAD:       string(buildAADV2("v3", path, sealed.Header)),
+		Envelope:  BuildEnvelopeV2(sealed.Header, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext)),
+	}, nil
+}
+
+// Why(中文): 规范文档需要列出头字段顺序，导出副本而不是切片本身，调用方无法改动解析规则。
+// Why(English): The spec document lists the header order; a copy is exported rather than the slice itself so callers cannot alter parsing rules.
+func HeaderOrderV2() []string {
+	return append([]string(nil), headerOrderV2...)
+}
+
+// Why(中文): 一致性向量需要让独立实现逐步对照 HKDF 输出，这里只导出包装，内部实现与调用点保持不变。
+// Why(English): Conformance vectors let independent implementations check HKDF output step by step; only a wrapper is exported and the internal implementation and callers stay unchanged.
+func HKDFSHA256(ikm []byte, salt []byte, info []byte, size int) []byte {
+	return hkdfSHA256(ikm, salt, info, size)
+}
+
+// Why(中文): 导出 v1 AAD 模板供向量生成与校验使用，向量中的 AAD 字节因此与封装时实际认证的字节完全一致。
+// Why(English): Export the v1 AAD template for vector generation and checking, so vector AAD bytes are exactly what sealing authenticates.
+func AADV1(path string, saltB64 string, nonceB64 string) []byte {
+	return buildAADV1(path, saltB64, nonceB64)
+}
+
+// Why(中文): v2/v3 的 AAD 由头字段逐行组成，同样直接复用内部实现，避免向量与实现各写一份。
+// Why(English): The v2/v3 AAD is the header line by line; reusing the internal builder keeps vectors and implementation from being written twice.
+func AADV2(version string, path string, h []HeaderField) []byte {
+	return buildAADV2(version, path, h)
+}
@@ -0,0 +1,317 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"TXLOCK/internal/lockcore"
+)
+
+var (
+	ErrMalformed = errors.New("manifest is malformed")
+	ErrMAC       = errors.New("manifest verification failed (index/mnemonic mismatch or tampered manifest)")
+	ErrStale     = errors.New("manifest sequence is below the minimum accepted (rolled-back manifest)")
+	ErrPath      = errors.New("file path cannot be recorded in a manifest")
+)
+
+// Why(中文): 清单默认放在被保护目录的根下；文件名不以 .lock 结尾，扫描时不会把清单自身算作条目。
+// Why(English): The manifest lives at the root of the protected tree by default; its name does not end in .lock, so a scan never counts the manifest as an entry.
+const (
+	DefaultFile = "txlock-manifest.json"
+	LockSuffix  = ".lock"
+
+	formatVersion = 1
+	maxHistory    = 32
+)
+
+// Why(中文): MAC 密钥与 vault、文件标签子密钥同样由 HKDF(sk, 标签) 派生但标签不同，同一索引下互不可替代。
+// Why(English): The MAC key is derived as HKDF(sk, label) like the vault and file-label subkeys, but under its own label, so none can stand in for another under the same index.
+const keyLabel = "txlock/manifest"
+
+type Kind string
+
+const (
+	KindMissing    Kind = "missing"
+	KindExtra      Kind = "extra"
+	KindReplaced   Kind = "replaced"
+	KindRolledBack Kind = "rolled-back"
+)
+
+type Entry struct {
+	Path    string   `json:"path"`
+	SHA256  string   `json:"sha256"`
+	Seq     uint64   `json:"seq"`
+	History []string `json:"history,omitempty"`
+}
+
+type Manifest struct {
+	Version int     `json:"version"`
+	Seq     uint64  `json:"seq"`
+	Root    string  `json:"root"`
+	Entries []Entry `json:"entries"`
+	MAC     string  `json:"mac"`
+}
+
+type Finding struct {
+	Path string
+	Kind Kind
+}
+
+// Why(中文): 只凭助记词与索引即可重算，清单本身不保存任何密钥材料。
+// Why(English): Recomputable from the mnemonic and index alone; the manifest itself stores no key material.
+func Key(sk []byte) []byte {
+	return lockcore.HKDFSHA256(sk, nil, []byte(keyLabel), 32)
+}
+
+// Why(中文): 递归列出目录下全部以 .lock 结尾的普通文件及其 SHA-256；路径统一为相对的 "/" 分隔形式，清单在不同系统间可比。
+// Why(English): Recursively list every regular file ending in .lock with its SHA-256; paths are relative and "/"-separated so manifests compare across systems.
+func Scan(dir string) (map[string]string, error) {
+	out := map[string]string{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(d.Name(), LockSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		out[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Why(中文): 每次构建序号加一；内容未变的条目保留原序号与历史，内容变化的条目记下新序号并把旧摘要移入历史，check 因此能区分“回滚到旧版本”与“被替换”。
+// Why(English): Each build bumps the sequence; unchanged entries keep their seq and history, while changed ones take the new seq and move the old digest into history, which lets check tell "rolled back to an old version" from "replaced".
+func Build(dir string, key []byte, prev *Manifest) (*Manifest, error) {
+	files, err := Scan(dir)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{Version: formatVersion, Seq: 1, Entries: []Entry{}}
+	old := map[string]Entry{}
+	if prev != nil {
+		m.Seq = prev.Seq + 1
+		for _, e := range prev.Entries {
+			old[e.Path] = e
+		}
+	}
+	for p, sum := range files {
+		if !validPath(p) {
+			return nil, ErrPath
+		}
+		e, ok := old[p]
+		switch {
+		case !ok:
+			e = Entry{Path: p, SHA256: sum, Seq: m.Seq}
+		case e.SHA256 != sum:
+			history := append(append([]string(nil), e.History...), e.SHA256)
+			e = Entry{Path: p, SHA256: sum, Seq: m.Seq, History: trimHistory(history, sum)}
+		}
+		m.Entries = append(m.Entries, e)
+	}
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].Path < m.Entries[j].Path })
+	m.Root = hex.EncodeToString(MerkleRoot(m.Entries))
+	m.MAC = hex.EncodeToString(mac(key, m.Seq, m.Root))
+	return m, nil
+}
+
+// Why(中文): 输出带缩进与结尾换行的 JSON，便于人工审阅与 diff；MAC 只覆盖序号与根，条目由根间接覆盖。
+// Why(English): Emit indented JSON with a trailing newline for human review and diffs; the MAC covers only seq and root, and the entries are covered through the root.
+func (m *Manifest) Marshal() ([]byte, error) {
+	out, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// Why(中文): 解析即校验：未知字段、乱序或重复路径、非法路径与摘要一律 ErrMalformed；重算的 Merkle 根或 MAC 不符统一为 ErrMAC，不区分错误密钥与篡改。
+// Why(English): Parsing is verification: unknown fields, unsorted or duplicate paths and invalid paths or digests are ErrMalformed; a recomputed Merkle root or MAC mismatch is ErrMAC, without distinguishing a wrong key from tampering.
+func Parse(raw []byte, key []byte) (*Manifest, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	var m Manifest
+	if err := dec.Decode(&m); err != nil || dec.More() {
+		return nil, ErrMalformed
+	}
+	if m.Version != formatVersion || m.Seq == 0 || m.Entries == nil || !validDigest(m.Root) || !validDigest(m.MAC) {
+		return nil, ErrMalformed
+	}
+	for i, e := range m.Entries {
+		if !validPath(e.Path) || !validDigest(e.SHA256) || e.Seq == 0 || e.Seq > m.Seq || len(e.History) > maxHistory {
+			return nil, ErrMalformed
+		}
+		if i > 0 && m.Entries[i-1].Path >= e.Path {
+			return nil, ErrMalformed
+		}
+		for _, h := range e.History {
+			if !validDigest(h) {
+				return nil, ErrMalformed
+			}
+		}
+	}
+	root := hex.EncodeToString(MerkleRoot(m.Entries))
+	want, _ := hex.DecodeString(m.MAC)
+	if root != m.Root || !hmac.Equal(mac(key, m.Seq, m.Root), want) {
+		return nil, ErrMAC
+	}
+	return &m, nil
+}
+
+// Why(中文): 先拒绝低于调用方记录的序号（整份清单连同文件一起回滚时唯一的信号），再逐条对照目录：缺失、多出、旧版本回滚与替换分别报告，按路径排序。
+// Why(English): First refuse a sequence below the caller's recorded minimum, the only signal when the manifest is rolled back together with its files, then compare the tree entry by entry, reporting missing, extra, rolled-back and replaced files sorted by path.
+func Check(dir string, m *Manifest, minSeq uint64) ([]Finding, error) {
+	if m.Seq < minSeq {
+		return nil, ErrStale
+	}
+	files, err := Scan(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []Finding
+	for _, e := range m.Entries {
+		sum, ok := files[e.Path]
+		delete(files, e.Path)
+		switch {
+		case !ok:
+			out = append(out, Finding{Path: e.Path, Kind: KindMissing})
+		case sum == e.SHA256:
+		case contains(e.History, sum):
+			out = append(out, Finding{Path: e.Path, Kind: KindRolledBack})
+		default:
+			out = append(out, Finding{Path: e.Path, Kind: KindReplaced})
+		}
+	}
+	for p := range files {
+		out = append(out, Finding{Path: p, Kind: KindExtra})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// Why(中文): RFC 6962 形式的 Merkle 树：叶子与内部节点加不同前缀字节，按不超过 n 的最大 2 的幂切分，空树为 SHA-256("")；单个文件可据此给出包含证明。
+// Why(English): An RFC 6962-style Merkle tree: leaves and interior nodes get distinct prefix bytes, splits fall at the largest power of two below n, and the empty tree is SHA-256(""), so a single file can later be proven included.
+func MerkleRoot(entries []Entry) []byte {
+	if len(entries) == 0 {
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	}
+	if len(entries) == 1 {
+		sum := sha256.Sum256(append([]byte{0x00}, leafBytes(entries[0])...))
+		return sum[:]
+	}
+	k := 1
+	for k*2 < len(entries) {
+		k *= 2
+	}
+	node := append([]byte{0x01}, MerkleRoot(entries[:k])...)
+	node = append(node, MerkleRoot(entries[k:])...)
+	sum := sha256.Sum256(node)
+	return sum[:]
+}
+
+// Why(中文): 叶子编码逐行写出路径、摘要、序号与历史，字段都不含换行，编码无歧义。
+// Why(English): The leaf encoding writes path, digest, seq and history one per line; no field can contain a newline, so the encoding is unambiguous.
+func leafBytes(e Entry) []byte {
+	return []byte("path:" + e.Path + "\nsha256:" + e.SHA256 + "\nseq:" + strconv.FormatUint(e.Seq, 10) + "\nhistory:" + strings.Join(e.History, ",") + "\n")
+}
+
+// Why(中文): MAC 输入带格式前缀，不会与其他 HMAC 用途的输入混淆。
+// Why(English): The MAC input carries a format prefix so it cannot be confused with input to any other HMAC use.
+func mac(key []byte, seq uint64, root string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte("txlock-manifest:v1\nseq:" + strconv.FormatUint(seq, 10) + "\nroot:" + root + "\n"))
+	return h.Sum(nil)
+}
+
+// Why(中文): 流式计算摘要，大文件不必整体读入内存。
+// Why(English): Hash as a stream so large files are never loaded whole.
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Why(中文): 历史中去掉与当前摘要相同的项并只保留最近若干个，清单大小有界。
+// Why(English): Drop history items equal to the current digest and keep only the most recent few so the manifest stays bounded.
+func trimHistory(history []string, current string) []string {
+	var out []string
+	for _, h := range history {
+		if h != current {
+			out = append(out, h)
+		}
+	}
+	if len(out) > maxHistory {
+		out = out[len(out)-maxHistory:]
+	}
+	return out
+}
+
+// Why(中文): 路径必须是规范的相对 "/" 路径、以 .lock 结尾且不含 ".." 或控制字符，清单里的路径因此不能指向目录之外。
+// Why(English): A path must be a clean relative "/" path ending in .lock with no ".." or control characters, so manifest paths can never point outside the tree.
+func validPath(p string) bool {
+	if p == "" || path.Clean(p) != p || path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") || !strings.HasSuffix(p, LockSuffix) {
+		return false
+	}
+	for i := 0; i < len(p); i++ {
+		if p[i] < 0x20 || p[i] == 0x7f || p[i] == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// Why(中文): 摘要与 MAC 都是 32 字节的小写十六进制，大写或其他长度视为畸形，保证同一清单只有一种文本。
+// Why(English): Digests and the MAC are 32-byte lowercase hex; uppercase or other lengths are malformed so a manifest has exactly one textual form.
+func validDigest(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !(s[i] >= '0' && s[i] <= '9' || s[i] >= 'a' && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Why(中文): 历史很短，线性查找即可。
+// Why(English): History is short, so a linear scan is enough.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
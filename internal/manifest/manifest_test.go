@@ -0,0 +1,176 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Why(中文): 测试只需要一个固定的 32 字节 sk，清单逻辑与 sk 的来源无关。
+// Why(English): Tests need only a fixed 32-byte sk; manifest logic does not depend on where sk comes from.
+func fixtureKey() []byte {
+	return Key(bytes.Repeat([]byte{0x42}, 32))
+}
+
+// Why(中文): 在临时目录中写入若干 .lock 文件与一个非 .lock 文件，后者不得进入清单。
+// Why(English): Write a few .lock files plus one non-.lock file into a temp dir; the latter must stay out of the manifest.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+}
+
+// Why(中文): 序列化再解析必须得到同一清单，且只有 .lock 文件被记录；未改动的目录检查无发现。
+// Why(English): Marshal then Parse must yield the same manifest with only .lock files recorded, and an untouched tree checks clean.
+func TestBuildParseCheckClean(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.lock": "A1", "sub/b.lock": "B1", "notes.txt": "ignored"})
+	m, err := Build(dir, fixtureKey(), nil)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if m.Seq != 1 || len(m.Entries) != 2 || m.Entries[1].Path != "sub/b.lock" {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	raw, err := m.Marshal()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	got, err := Parse(raw, fixtureKey())
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got.Root != m.Root || got.MAC != m.MAC {
+		t.Fatalf("round trip changed root or mac")
+	}
+	findings, err := Check(dir, got, 1)
+	if err != nil || len(findings) != 0 {
+		t.Fatalf("expected a clean check, got %+v %v", findings, err)
+	}
+}
+
+// Why(中文): 缺失、多出、替换与回滚到旧版本必须各自以正确类别报告；回滚依赖第二次构建记下的历史摘要。
+// Why(English): Missing, extra, replaced and rolled-back files must each be reported under the right kind; rollback relies on the history recorded by the second build.
+func TestCheckDetectsEveryKind(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"keep.lock": "K", "gone.lock": "G", "swap.lock": "S1", "old.lock": "O1"})
+	first, err := Build(dir, fixtureKey(), nil)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	writeTree(t, dir, map[string]string{"old.lock": "O2"})
+	m, err := Build(dir, fixtureKey(), first)
+	if err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	if m.Seq != 2 {
+		t.Fatalf("expected seq 2, got %d", m.Seq)
+	}
+	for _, e := range m.Entries {
+		if e.Path == "keep.lock" && e.Seq != 1 || e.Path == "old.lock" && (e.Seq != 2 || len(e.History) != 1) {
+			t.Fatalf("unexpected entry: %+v", e)
+		}
+	}
+	if err := os.Remove(filepath.Join(dir, "gone.lock")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	writeTree(t, dir, map[string]string{"swap.lock": "forged", "old.lock": "O1", "new/x.lock": "X"})
+	findings, err := Check(dir, m, 2)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	want := []Finding{
+		{Path: "gone.lock", Kind: KindMissing},
+		{Path: "new/x.lock", Kind: KindExtra},
+		{Path: "old.lock", Kind: KindRolledBack},
+		{Path: "swap.lock", Kind: KindReplaced},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("findings: %+v", findings)
+	}
+	for i := range want {
+		if findings[i] != want[i] {
+			t.Fatalf("finding %d: got %+v want %+v", i, findings[i], want[i])
+		}
+	}
+	if _, err := Check(dir, m, 3); err != ErrStale {
+		t.Fatalf("expected ErrStale, got %v", err)
+	}
+}
+
+// Why(中文): 改写条目、序号或 MAC，或用其他索引的密钥，都必须报 ErrMAC；语法层面的破坏报 ErrMalformed。
+// Why(English): Rewriting an entry, the seq or the MAC, or using another index's key, must all be ErrMAC; syntactic damage is ErrMalformed.
+func TestParseRejectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.lock": "A", "b.lock": "B"})
+	m, err := Build(dir, fixtureKey(), nil)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	raw, _ := m.Marshal()
+	if _, err := Parse(raw, Key(bytes.Repeat([]byte{0x43}, 32))); err != ErrMAC {
+		t.Fatalf("wrong key: expected ErrMAC, got %v", err)
+	}
+	forged := sha256.Sum256([]byte("forged"))
+	for name, edit := range map[string]func(string) string{
+		"entry digest": func(s string) string {
+			return strings.Replace(s, m.Entries[0].SHA256, hex.EncodeToString(forged[:]), 1)
+		},
+		"seq": func(s string) string {
+			return strings.Replace(s, `"seq": 1,`+"\n  \"root\"", `"seq": 2,`+"\n  \"root\"", 1)
+		},
+		"mac": func(s string) string { return strings.Replace(s, m.MAC, m.Root, 1) },
+	} {
+		edited := edit(string(raw))
+		if edited == string(raw) {
+			t.Fatalf("%s: edit did not apply", name)
+		}
+		if _, err := Parse([]byte(edited), fixtureKey()); err != ErrMAC {
+			t.Fatalf("%s: expected ErrMAC, got %v", name, err)
+		}
+	}
+	for _, bad := range []string{
+		"",
+		strings.Replace(string(raw), `"version": 1`, `"version": 2`, 1),
+		strings.Replace(string(raw), `"path": "a.lock"`, `"path": "../a.lock"`, 1),
+		strings.Replace(string(raw), `"path": "b.lock"`, `"path": "a.lock"`, 1),
+		strings.Replace(string(raw), `"mac"`, `"extra": 1, "mac"`, 1),
+	} {
+		if _, err := Parse([]byte(bad), fixtureKey()); err != ErrMalformed {
+			t.Fatalf("expected ErrMalformed for %q, got %v", bad, err)
+		}
+	}
+}
+
+// Why(中文): Merkle 根按 RFC 6962 手工组合核对：三个叶子时左子树为前两个叶子，右子树为第三个；空树为 SHA-256("")。
+// Why(English): Check the Merkle root against a hand-built RFC 6962 composition: with three leaves the left subtree holds the first two and the right the third; the empty tree is SHA-256("").
+func TestMerkleRootShape(t *testing.T) {
+	entries := []Entry{{Path: "a.lock", SHA256: strings.Repeat("0", 64), Seq: 1}, {Path: "b.lock", SHA256: strings.Repeat("1", 64), Seq: 1}, {Path: "c.lock", SHA256: strings.Repeat("2", 64), Seq: 1, History: []string{strings.Repeat("3", 64)}}}
+	leaf := func(e Entry) []byte {
+		sum := sha256.Sum256(append([]byte{0x00}, leafBytes(e)...))
+		return sum[:]
+	}
+	node := func(l, r []byte) []byte {
+		sum := sha256.Sum256(append(append([]byte{0x01}, l...), r...))
+		return sum[:]
+	}
+	want := node(node(leaf(entries[0]), leaf(entries[1])), leaf(entries[2]))
+	if !bytes.Equal(MerkleRoot(entries), want) {
+		t.Fatalf("three-leaf root does not follow RFC 6962 splitting")
+	}
+	empty := sha256.Sum256(nil)
+	if !bytes.Equal(MerkleRoot(nil), empty[:]) {
+		t.Fatalf("empty root must be SHA-256 of the empty string")
+	}
+}
@@ -0,0 +1,261 @@
+package paper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+
+	"TXLOCK/internal/ethsig"
+	"TXLOCK/internal/lockcore"
+)
+
+var (
+	ErrNotEnvelope = errors.New("not a Markdown txlock envelope")
+	ErrFormat      = errors.New("no paper backup header found")
+	ErrDamaged     = errors.New("paper backup has missing or mistyped lines")
+	ErrDigest      = errors.New("reassembled envelope does not match the backup digest")
+)
+
+// Why(中文): 两种纸质形态各有固定 magic，恢复时据此分流；版本号写进 magic，未来改格式不会被旧解析器误读。
+// Why(English): Each paper form has a fixed magic that routes restore; the version lives in the magic so a future layout is never misread by an old parser.
+const (
+	sheetMagic = "txlock-paper:v1"
+	qrMagic    = "txlock-qr:v1"
+)
+
+// Why(中文): 纸质备份只接受严格通过解析的 Markdown 信封（可带内嵌签名）；二进制容器需先 txlock convert，保证打印的就是可直接解密的文本。
+// Why(English): Paper backups accept only Markdown envelopes that pass strict parsing, embedded signature allowed; binary containers go through txlock convert first so what is printed is decryptable text.
+func CheckEnvelope(raw []byte) error {
+	if lockcore.IsBinaryEnvelope(raw) {
+		return ErrNotEnvelope
+	}
+	envelope, _, _ := ethsig.SplitEmbedded(string(raw))
+	ok := false
+	switch lockcore.DetectEnvelopeVersion(envelope) {
+	case "v1":
+		_, _, _, _, ok = lockcore.ParseEnvelopeV1(envelope)
+	case "v2", "v3":
+		_, _, ok = lockcore.ParseEnvelopeV2(envelope)
+	}
+	if !ok {
+		return ErrNotEnvelope
+	}
+	return nil
+}
+
+// Why(中文): 摘要只取 SHA-256 前 8 字节，足以发现拼错份或漏行，又短到可以手抄核对。
+// Why(English): The digest is the first 8 bytes of SHA-256: enough to catch mixed-up backups or dropped lines, short enough to copy by hand.
+func digest(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Why(中文): 行校验把行号一起算进 CRC，抄错内容与行序错乱都能定位到具体行。
+// Why(English): The line checksum covers the line number too, so both mistyped content and swapped lines are pinned to a specific line.
+func lineCRC(n int, line string) string {
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(strconv.Itoa(n)+":"+line)))
+}
+
+// Why(中文): 信封与签名块的每一行都不含空白，按行切分后逐行打印即可无歧义还原，末尾换行由恢复侧补回。
+// Why(English): No envelope or signature line contains whitespace, so printing line by line restores unambiguously, with the trailing newline added back on restore.
+func envelopeLines(raw []byte) []string {
+	return strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+}
+
+// Why(中文): 打印页首行声明行数与摘要，之后每行为“行号 CRC 内容”，重新录入时逐行核对即可。
+// Why(English): The sheet opens with the line count and digest, then each line is "number CRC content" so retyping can be checked line by line.
+func Sheet(raw []byte) string {
+	lines := envelopeLines(raw)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s lines=%d sha256=%s\n", sheetMagic, len(lines), digest(raw))
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%04d %s %s\n", i+1, lineCRC(i+1, line), line)
+	}
+	return b.String()
+}
+
+// Why(中文): 二维码按整行分组，每份载荷不超过 maxBytes，首行标注“序号/总数 摘要”，扫描顺序打乱也能还原。
+// Why(English): QR payloads group whole lines up to maxBytes each, headed by "index/total digest", so scans can arrive in any order.
+func QRPayloads(raw []byte, maxBytes int) []string {
+	var parts []string
+	var cur strings.Builder
+	for _, line := range envelopeLines(raw) {
+		if cur.Len() > 0 && cur.Len()+len(line)+1 > maxBytes {
+			parts = append(parts, cur.String())
+			cur.Reset()
+		}
+		cur.WriteString(line)
+		cur.WriteString("\n")
+	}
+	parts = append(parts, cur.String())
+	sum := digest(raw)
+	out := make([]string, len(parts))
+	for i, p := range parts {
+		out[i] = fmt.Sprintf("%s %d/%d %s\n%s", qrMagic, i+1, len(parts), sum, p)
+	}
+	return out
+}
+
+// Why(中文): 恢复入口自动识别打印页或二维码载荷；有问题时返回逐行/逐份诊断，全部通过后还要核对摘要并严格解析信封。
+// Why(English): Restore detects a printed sheet or QR payloads on its own; problems come back as per-line or per-part diagnostics, and a clean result still has to match the digest and parse strictly.
+func Restore(input string) ([]byte, []string, error) {
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	var lines []string
+	var sum string
+	var issues []string
+	switch {
+	case strings.HasPrefix(input, qrMagic+" ") || strings.Contains(input, "\n"+qrMagic+" "):
+		lines, sum, issues = restoreQR(input)
+	case strings.Contains(input, sheetMagic+" "):
+		lines, sum, issues = restoreSheet(input)
+	default:
+		return nil, nil, ErrFormat
+	}
+	if len(issues) > 0 {
+		return nil, issues, ErrDamaged
+	}
+	raw := []byte(strings.Join(lines, "\n") + "\n")
+	if digest(raw) != sum {
+		return nil, nil, ErrDigest
+	}
+	if err := CheckEnvelope(raw); err != nil {
+		return nil, nil, err
+	}
+	return raw, nil, nil
+}
+
+// Why(中文): 逐行校验 CRC 并报告缺行、重复与无法识别的行；行号顺序不限，录入时可以分段补抄。
+// Why(English): Check every line's CRC and report missing, conflicting and unrecognized lines; line order is free so retyping can happen in pieces.
+func restoreSheet(input string) ([]string, string, []string) {
+	total, sum := -1, ""
+	got := map[int]string{}
+	var issues []string
+	for _, raw := range strings.Split(input, "\n") {
+		f := strings.Fields(raw)
+		if len(f) == 0 {
+			continue
+		}
+		if f[0] == sheetMagic && len(f) == 3 && total < 0 {
+			n, err := strconv.Atoi(strings.TrimPrefix(f[1], "lines="))
+			if err != nil || !strings.HasPrefix(f[1], "lines=") || n <= 0 || !strings.HasPrefix(f[2], "sha256=") {
+				issues = append(issues, "header: unreadable "+strconv.Quote(raw))
+				continue
+			}
+			total, sum = n, strings.ToLower(strings.TrimPrefix(f[2], "sha256="))
+			continue
+		}
+		n, err := strconv.Atoi(f[0])
+		if len(f) != 3 || err != nil || n <= 0 {
+			issues = append(issues, "unrecognized line: "+strconv.Quote(raw))
+			continue
+		}
+		if lineCRC(n, f[2]) != strings.ToLower(f[1]) {
+			issues = append(issues, fmt.Sprintf("line %04d: checksum mismatch (typo in this line)", n))
+			continue
+		}
+		if prev, dup := got[n]; dup && prev != f[2] {
+			issues = append(issues, fmt.Sprintf("line %04d: entered twice with different content", n))
+			continue
+		}
+		got[n] = f[2]
+	}
+	if total < 0 {
+		return nil, "", append(issues, "header: missing "+sheetMagic+" line")
+	}
+	lines := make([]string, total)
+	for n := 1; n <= total; n++ {
+		line, ok := got[n]
+		if !ok && !hasLineIssue(issues, n) {
+			issues = append(issues, fmt.Sprintf("line %04d: missing", n))
+		}
+		lines[n-1] = line
+	}
+	for n := range got {
+		if n > total {
+			issues = append(issues, fmt.Sprintf("line %04d: beyond lines=%d", n, total))
+		}
+	}
+	sort.Strings(issues)
+	return lines, sum, issues
+}
+
+// Why(中文): 已报告 CRC 错误的行不再重复报告缺失，诊断保持一行一条。
+// Why(English): A line already reported for a bad CRC is not reported missing again, keeping one diagnostic per line.
+func hasLineIssue(issues []string, n int) bool {
+	prefix := fmt.Sprintf("line %04d:", n)
+	for _, issue := range issues {
+		if strings.HasPrefix(issue, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Why(中文): 载荷可以任意顺序、重复拼接在一起；每份以 magic 行开头，总数与摘要必须一致，缺哪份就报哪份。
+// Why(English): Payloads may be concatenated in any order and repeated; each starts at its magic line, totals and digests must agree, and each missing part is named.
+func restoreQR(input string) ([]string, string, []string) {
+	total, sum := 0, ""
+	parts := map[int][]string{}
+	var issues []string
+	cur := -1
+	for _, line := range strings.Split(input, "\n") {
+		if !strings.HasPrefix(line, qrMagic+" ") {
+			if cur > 0 && line != "" {
+				parts[cur] = append(parts[cur], line)
+			}
+			continue
+		}
+		cur = -1
+		f := strings.Fields(line)
+		var idx, n int
+		if len(f) != 3 || !parsePart(f[1], &idx, &n) {
+			issues = append(issues, "unreadable payload header: "+strconv.Quote(line))
+			continue
+		}
+		if total == 0 {
+			total, sum = n, strings.ToLower(f[2])
+		}
+		if n != total || strings.ToLower(f[2]) != sum {
+			issues = append(issues, fmt.Sprintf("part %d/%d: belongs to a different backup", idx, n))
+			continue
+		}
+		if _, dup := parts[idx]; dup {
+			delete(parts, idx)
+		}
+		cur = idx
+		parts[cur] = nil
+	}
+	var lines []string
+	for i := 1; i <= total; i++ {
+		p, ok := parts[i]
+		if !ok {
+			issues = append(issues, fmt.Sprintf("part %d/%d: missing", i, total))
+		}
+		lines = append(lines, p...)
+	}
+	if total == 0 && len(issues) == 0 {
+		issues = append(issues, "no readable payload")
+	}
+	return lines, sum, issues
+}
+
+// Why(中文): "i/n" 必须满足 1 <= i <= n，否则视为扫描错误。
+// Why(English): "i/n" must satisfy 1 <= i <= n, anything else is a bad scan.
+func parsePart(s string, idx *int, n *int) bool {
+	a, b, ok := strings.Cut(s, "/")
+	if !ok {
+		return false
+	}
+	i, err1 := strconv.Atoi(a)
+	t, err2 := strconv.Atoi(b)
+	if err1 != nil || err2 != nil || i < 1 || i > t {
+		return false
+	}
+	*idx, *n = i, t
+	return true
+}
@@ -0,0 +1,74 @@
+package paper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"TXLOCK/internal/lockcore"
+)
+
+// Why(中文): 夹具用真实 v1 信封并带足够长的密文，打印页与二维码都会跨多行、多份。
+// Why(English): The fixture is a real v1 envelope with enough ciphertext that both the sheet and the QR payloads span many lines and parts.
+func fixtureEnvelope(t *testing.T) []byte {
+	t.Helper()
+	sealed, err := lockcore.SealV1(bytes.Repeat([]byte{0x42}, 32), "m/44'/60'/0'/0/777", bytes.Repeat([]byte("paper "), 200), bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	return []byte(lockcore.BuildEnvelopeV1("", sealed.SaltB64, sealed.NonceB64, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext)))
+}
+
+// Why(中文): 打印页往返必须逐字节还原；改一个字符只报告那一行，删一行报告缺失，二者都不得返回数据。
+// Why(English): A sheet round trip must restore every byte; one changed character reports only that line, a dropped line reports it missing, and neither returns data.
+func TestSheetRoundTripAndTypos(t *testing.T) {
+	raw := fixtureEnvelope(t)
+	sheet := Sheet(raw)
+	got, issues, err := Restore(sheet)
+	if err != nil || !bytes.Equal(got, raw) {
+		t.Fatalf("round trip failed: %v %v", err, issues)
+	}
+	lines := strings.Split(sheet, "\n")
+	typo := append([]string{}, lines...)
+	typo[8] = typo[8][:20] + "x" + typo[8][21:]
+	if _, issues, err := Restore(strings.Join(typo, "\n")); err != ErrDamaged || len(issues) != 1 || !strings.HasPrefix(issues[0], "line 0008: checksum mismatch") {
+		t.Fatalf("expected one checksum issue on line 8, got %v %v", err, issues)
+	}
+	dropped := append(append([]string{}, lines[:5]...), lines[6:]...)
+	if _, issues, err := Restore(strings.Join(dropped, "\n")); err != ErrDamaged || len(issues) != 1 || issues[0] != "line 0005: missing" {
+		t.Fatalf("expected line 5 missing, got %v %v", err, issues)
+	}
+}
+
+// Why(中文): 二维码载荷乱序、重复扫描都能还原；缺一份必须指出是哪一份，混入其他备份的载荷要识别出来。
+// Why(English): QR payloads restore when shuffled or scanned twice; a missing part must be named and a payload from another backup must be spotted.
+func TestQRPayloadsRestore(t *testing.T) {
+	raw := fixtureEnvelope(t)
+	parts := QRPayloads(raw, 300)
+	if len(parts) < 3 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	for _, p := range parts {
+		if len(p) > 300+64 {
+			t.Fatalf("payload too large: %d", len(p))
+		}
+	}
+	shuffled := strings.Join(append([]string{parts[2], parts[0], parts[0]}, parts[1:]...), "")
+	got, issues, err := Restore(shuffled)
+	if err != nil || !bytes.Equal(got, raw) {
+		t.Fatalf("restore failed: %v %v", err, issues)
+	}
+	missing := strings.Join(append([]string{parts[0]}, parts[2:]...), "")
+	if _, issues, err := Restore(missing); err != ErrDamaged || len(issues) != 1 || !strings.HasPrefix(issues[0], "part 2/") {
+		t.Fatalf("expected part 2 missing, got %v %v", err, issues)
+	}
+	other := QRPayloads(append(raw[:len(raw)-5:len(raw)-5], []byte("AAAA\n-->\n")...), 300)
+	mixed := strings.Join(append(append([]string{}, parts...), other[len(other)-1]), "")
+	if _, _, err := Restore(mixed); err != ErrDamaged {
+		t.Fatalf("expected foreign part rejection, got %v", err)
+	}
+	if _, _, err := Restore("hello\n"); err != ErrFormat {
+		t.Fatalf("expected ErrFormat, got %v", err)
+	}
+}
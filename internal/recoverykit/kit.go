@@ -0,0 +1,127 @@
+package recoverykit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"TXLOCK/internal/derive"
+	"TXLOCK/internal/lockcore"
+)
+
+var ErrNotEmpty = errors.New("recovery kit directory is not empty")
+
+// Why(中文): 参考解密器以真实 Go 源码存放在子包中，随 go vet/go test 一起编译和测试，再原样嵌入恢复包，避免“文档里的代码”悄悄失效。
+// Why(English): The reference decryptor lives as real Go source in a sub-package, compiled and tested with go vet/go test, then embedded verbatim so the kit never ships stale "code in a document".
+//
+//go:embed refdecrypt/main.go
+var referenceDecryptor []byte
+
+//go:embed spec.md.tmpl
+var specTemplate string
+
+// Why(中文): 向量输入与 docs/test-vectors.md 的固定夹具相同，恢复包与仓库测试使用同一组数据。
+// Why(English): Vector inputs match the fixed fixtures in docs/test-vectors.md so the kit and the repository tests share one data set.
+const (
+	vectorMnemonic  = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	vectorIndex     = "777"
+	vectorPath      = "m/44'/60'/0'/0/777"
+	vectorSaltHex   = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	vectorNonceHex  = "00112233445566778899aabb"
+	vectorPlaintext = "hello txlock\n"
+)
+
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Why(中文): 恢复包内容全部在内存中生成，调用方可以写盘或直接检查；SHA256SUMS 最后计算，覆盖其余全部文件。
+// Why(English): The whole kit is built in memory so callers can write or inspect it; SHA256SUMS comes last and covers every other file.
+func Build() ([]File, error) {
+	sk, err := derive.DeriveSK(vectorMnemonic, vectorIndex)
+	if err != nil {
+		return nil, err
+	}
+	salt, _ := hex.DecodeString(vectorSaltHex)
+	nonce, _ := hex.DecodeString(vectorNonceHex)
+	v1, err := lockcore.WorkedExampleV1(sk, vectorPath, salt, nonce, []byte(vectorPlaintext))
+	if err != nil {
+		return nil, err
+	}
+	v3, err := lockcore.WorkedExampleV3(sk, vectorPath, salt, nonce, []byte(vectorPlaintext))
+	if err != nil {
+		return nil, err
+	}
+	var spec bytes.Buffer
+	err = template.Must(template.New("spec").Parse(specTemplate)).Execute(&spec, map[string]string{
+		"HeaderOrder": strings.Join(lockcore.HeaderOrderV2(), "`, `"),
+		"InfoV1":      v1.Info,
+		"InfoV3":      v3.Info,
+		"KDFHybrid":   lockcore.KDFHybridV2,
+		"AEADNames":   strings.Join(lockcore.AEADNamesV2(), "`, `"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := []File{
+		{Name: "SPEC.md", Data: spec.Bytes()},
+		{Name: "VECTORS.md", Data: vectorsDoc(sk, v1, v3)},
+		{Name: "txlock-recover.go", Data: referenceDecryptor},
+	}
+	var sums strings.Builder
+	for _, f := range files {
+		sum := sha256.Sum256(f.Data)
+		fmt.Fprintf(&sums, "%s  %s\n", hex.EncodeToString(sum[:]), f.Name)
+	}
+	return append(files, File{Name: "SHA256SUMS", Data: []byte(sums.String())}), nil
+}
+
+// Why(中文): 向量文档按计算顺序列出每个中间值（SK、K、CK、AAD、信封），恢复实现可以逐步对照定位偏差。
+// Why(English): The vector document lists every intermediate in computation order (SK, K, CK, AAD, envelope) so a recovery implementation can find where it diverges step by step.
+func vectorsDoc(sk []byte, examples ...*lockcore.WorkedExample) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# TXLock worked vectors\n\nComputed live by `txlock recovery-kit`. Test-only salt/nonce; real files always use fresh randomness.\n\n")
+	fmt.Fprintf(&b, "- mnemonic: `%s`\n- index: `%s`\n- path: `%s`\n- SK (hex): `%x`\n", vectorMnemonic, vectorIndex, vectorPath, sk)
+	fmt.Fprintf(&b, "- salt (hex): `%s`\n- nonce (hex): `%s`\n- plaintext: `%q`\n", vectorSaltHex, vectorNonceHex, vectorPlaintext)
+	for _, ex := range examples {
+		fmt.Fprintf(&b, "\n## %s\n\n- INFO: `%s`\n- K (hex): `%x`\n", ex.Version, ex.Info, ex.Key)
+		if ex.CommitKey != nil {
+			fmt.Fprintf(&b, "- CK (hex): `%x`\n", ex.CommitKey)
+		}
+		fmt.Fprintf(&b, "\nAAD:\n\n```\n%s```\n\nEnvelope:\n\n```\n%s```\n", ex.AAD, ex.Envelope)
+	}
+	return []byte(b.String())
+}
+
+// Why(中文): 只写入空目录（不存在则创建），避免与旧版本恢复包的文件混杂导致校验和对不上。
+// Why(English): Write only into an empty directory, created if missing, so files never mix with an older kit and break the checksums.
+func Write(dir string) error {
+	files, err := Build()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return ErrNotEmpty
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f.Name), f.Data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package recoverykit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"TXLOCK/internal/derive"
+	"TXLOCK/internal/lockcore"
+)
+
+// Why(中文): 恢复包必须自洽：校验和覆盖其余文件，规范列出全部现行 AEAD 套件，向量中的信封能被主实现解开，参考解密器原样嵌入。
+// Why(English): The kit must be self-consistent: checksums cover the other files, the spec names every current AEAD suite, the vector envelopes open with the main implementation, and the reference decryptor is embedded verbatim.
+func TestBuildIsSelfConsistent(t *testing.T) {
+	files, err := Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	byName := map[string][]byte{}
+	for _, f := range files {
+		byName[f.Name] = f.Data
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(byName["SHA256SUMS"]), "\n"), "\n") {
+		sum, name, _ := strings.Cut(line, "  ")
+		got := sha256.Sum256(byName[name])
+		if hex.EncodeToString(got[:]) != sum {
+			t.Fatalf("checksum mismatch for %s", name)
+		}
+	}
+	spec := string(byName["SPEC.md"])
+	for _, want := range append(lockcore.AEADNamesV2(), "txlock:v1|chain=ethereum|path=bip44|kdf=hkdf-sha256|aead=aes-256-gcm", "`salt_b64`, `nonce_b64`, `commit_b64`") {
+		if !strings.Contains(spec, want) {
+			t.Fatalf("SPEC.md misses %q", want)
+		}
+	}
+	if !bytes.Equal(byName["txlock-recover.go"], referenceDecryptor) || !bytes.Contains(referenceDecryptor, []byte("package main")) {
+		t.Fatalf("reference decryptor not embedded")
+	}
+	sk, _ := derive.DeriveSK(vectorMnemonic, vectorIndex)
+	vectors := string(byName["VECTORS.md"])
+	for _, version := range []string{"v1", "v3"} {
+		start := strings.Index(vectors, "<!--\ntxlock:"+version)
+		end := strings.Index(vectors[start:], "-->\n") + start + len("-->\n")
+		envelope := vectors[start:end]
+		var got []byte
+		if version == "v1" {
+			_, salt, nonce, ct, ok := lockcore.ParseEnvelopeV1(envelope)
+			if !ok {
+				t.Fatalf("v1 vector does not parse")
+			}
+			got, err = lockcore.OpenV1(sk, vectorPath, salt, nonce, ct)
+		} else {
+			h, ct, ok := lockcore.ParseEnvelopeV2(envelope)
+			if !ok {
+				t.Fatalf("v3 vector does not parse")
+			}
+			got, err = lockcore.OpenV2(sk, vectorPath, h, ct, lockcore.OpenOptionsV2{})
+		}
+		if err != nil || string(got) != vectorPlaintext {
+			t.Fatalf("%s vector: %q %v", version, got, err)
+		}
+	}
+}
+
+// Why(中文): 写入非空目录必须拒绝；docs/recovery.md 与生成的规范必须引用同一 INFO 字符串，防止文档漂移。
+// Why(English): Writing into a non-empty directory must fail, and docs/recovery.md must quote the same INFO string as the generated spec so the document cannot drift.
+func TestWriteRefusesNonEmptyAndDocsAgree(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "kit")
+	if err := Write(dir); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := Write(dir); err != ErrNotEmpty {
+		t.Fatalf("expected ErrNotEmpty, got %v", err)
+	}
+	doc, err := os.ReadFile("../../docs/recovery.md")
+	if err != nil {
+		t.Fatalf("read docs/recovery.md: %v", err)
+	}
+	for _, want := range []string{"txlock:v1|chain=ethereum|path=bip44|kdf=hkdf-sha256|aead=aes-256-gcm", "m/44'/60'/0'/0/<index>", "txlock recovery-kit"} {
+		if !bytes.Contains(doc, []byte(want)) {
+			t.Fatalf("docs/recovery.md misses %q", want)
+		}
+	}
+}
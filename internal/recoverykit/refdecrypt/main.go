@@ -0,0 +1,387 @@
+// txlock-recover is the reference decryptor shipped in every txlock recovery kit.
+// It uses only the Go standard library so it still builds when every third-party
+// module the main tool depends on has disappeared:
+//
+//	go run txlock-recover.go -in notes.lock -mnemonic-env MNEM -index 777 > notes.md
+//
+// Supported: v1; v2/v3 with kdf hkdf-sha256 and aead aes-256-gcm, including
+// meta, compress gzip, pad and file labels (pass -label for a hidden one).
+// Password kdfs and other AEAD suites need the
+// full tool. Binary containers must be converted to Markdown first.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv))
+}
+
+// Why(中文): 参数与主工具一致（-mnemonic-env/-index），另提供 -sk-hex 以便只持有派生私钥时也能恢复。
+// Why(English): Flags match the main tool (-mnemonic-env/-index), plus -sk-hex for when only the derived private key survives.
+func run(args []string, getenv func(string) string) int {
+	fs := flag.NewFlagSet("txlock-recover", flag.ContinueOnError)
+	inPath := fs.String("in", "", "envelope file (Markdown form)")
+	outPath := fs.String("out", "-", "plaintext output, - for stdout")
+	mnemonicEnv := fs.String("mnemonic-env", "", "name of the env var holding the BIP39 mnemonic")
+	index := fs.String("index", "", "BIP44 address index used at encryption time")
+	skHex := fs.String("sk-hex", "", "derived private key in hex instead of a mnemonic")
+	label := fs.String("label", "", "file label, only needed when it was kept out of the header")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *inPath == "" || *index == "" || (*mnemonicEnv == "") == (*skHex == "") {
+		fmt.Fprintln(os.Stderr, "usage: txlock-recover -in FILE -index N (-mnemonic-env ENV | -sk-hex HEX) [-label NAME] [-out PATH]")
+		return 1
+	}
+	n, err := strconv.ParseUint(*index, 10, 31)
+	if err != nil || strconv.FormatUint(n, 10) != *index {
+		fmt.Fprintln(os.Stderr, "invalid -index")
+		return 1
+	}
+	path := "m/44'/60'/0'/0/" + *index
+	var sk []byte
+	if *skHex != "" {
+		sk, err = hex.DecodeString(*skHex)
+	} else {
+		sk, err = deriveSK(getenv(*mnemonicEnv), uint32(n))
+	}
+	if err != nil || len(sk) != 32 {
+		fmt.Fprintln(os.Stderr, "cannot obtain the private key:", err)
+		return 1
+	}
+	raw, err := os.ReadFile(*inPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	plain, meta, err := decrypt(string(raw), sk, path, *label)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "decrypt failed:", err)
+		return 2
+	}
+	if meta != "" {
+		fmt.Fprintln(os.Stderr, "meta:", meta)
+	}
+	if *outPath == "-" {
+		_, err = os.Stdout.Write(plain)
+	} else {
+		err = os.WriteFile(*outPath, plain, 0o600)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
+
+// Why(中文): 只处理文本信封：去掉可选的内嵌签名块，核对注释边界，按行拆出头字段与密文。
+// Why(English): Handles text envelopes only: drop an optional embedded signature block, check the comment boundaries, then split header lines from ciphertext.
+func parse(raw string) (string, [][2]string, []byte, error) {
+	if i := strings.LastIndex(raw, "-->\n<!--\ntxlock-sig:v1\n"); i >= 0 {
+		raw = raw[:i+len("-->\n")]
+	}
+	if !strings.HasPrefix(raw, "<!--\n") || !strings.HasSuffix(raw, "\n-->\n") {
+		return "", nil, nil, errors.New("not a Markdown txlock envelope")
+	}
+	lines := strings.Split(strings.TrimSuffix(raw[len("<!--\n"):], "\n-->\n"), "\n")
+	version, ok := strings.CutPrefix(lines[0], "txlock:")
+	if !ok {
+		return "", nil, nil, errors.New("missing txlock:<version> line")
+	}
+	var header [][2]string
+	i := 1
+	for ; i < len(lines) && lines[i] != "ct_b64:"; i++ {
+		k, v, ok := strings.Cut(lines[i], ":")
+		if !ok {
+			return "", nil, nil, errors.New("bad header line: " + lines[i])
+		}
+		header = append(header, [2]string{k, v})
+	}
+	if i == len(lines) {
+		return "", nil, nil, errors.New("missing ct_b64")
+	}
+	ct, err := canonicalB64(strings.Join(lines[i+1:], ""))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return version, header, ct, nil
+}
+
+// Why(中文): 规范要求“解码后再编码相等”，尾部比特非零或夹带换行的写法在派生密钥之前拒绝。
+// Why(English): The spec requires decode-then-encode equality, so spellings with non-zero trailing bits or embedded line breaks are rejected before any key derivation.
+func canonicalB64(s string) ([]byte, error) {
+	out, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if base64.RawStdEncoding.EncodeToString(out) != s {
+		return nil, errors.New("non-canonical base64 encoding")
+	}
+	return out, nil
+}
+
+// Why(中文): 按头字段查值；v1 的额外 chain/path 行也能查到，但 AAD 始终使用由 -index 构造的 path。
+// Why(English): Header lookup; v1's optional chain/path lines are visible too, but the AAD always uses the path built from -index.
+func value(h [][2]string, key string) (string, bool) {
+	for _, f := range h {
+		if f[0] == key {
+			return f[1], true
+		}
+	}
+	return "", false
+}
+
+// Why(中文): 完整复现规范：有标签时先以 HKDF 取文件子密钥代替 SK，HKDF 取 K（v3 额外取承诺密钥并先校验），AES-256-GCM 以 AAD 解密，再按 pad→compress→meta 逆序还原明文。
+// Why(English): Reproduces the spec end to end: a label first swaps SK for the HKDF file subkey, HKDF yields K (v3 also the commitment key, checked first), AES-256-GCM opens under the AAD, then pad, compress and meta are undone in that order.
+func decrypt(raw string, sk []byte, path string, label string) ([]byte, string, error) {
+	version, h, ct, err := parse(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	saltB64, _ := value(h, "salt_b64")
+	nonceB64, _ := value(h, "nonce_b64")
+	salt, err1 := canonicalB64(saltB64)
+	nonce, err2 := canonicalB64(nonceB64)
+	if err1 != nil || err2 != nil || len(salt) != 32 || len(nonce) != 12 {
+		return nil, "", errors.New("bad salt_b64/nonce_b64")
+	}
+	kdf, _ := value(h, "kdf")
+	aeadName, _ := value(h, "aead")
+	if kdf != "hkdf-sha256" || aeadName != "aes-256-gcm" {
+		return nil, "", errors.New("kdf " + kdf + " / aead " + aeadName + " needs the full txlock tool")
+	}
+	if stored, ok := value(h, "label"); ok {
+		if label != "" && label != stored {
+			return nil, "", errors.New("-label " + label + " does not match the header label " + stored)
+		}
+		label = stored
+	}
+	if label != "" {
+		if version == "v1" {
+			return nil, "", errors.New("v1 envelopes never use a label")
+		}
+		sk = hkdf(sk, nil, "txlock/file/"+label, 32)
+	}
+	var key []byte
+	var aad string
+	switch version {
+	case "v1":
+		key = hkdf(sk, salt, "txlock:v1|chain=ethereum|path=bip44|kdf=hkdf-sha256|aead=aes-256-gcm", 32)
+		aad = "txlock:v1\nchain:ethereum\npath:" + path + "\nkdf:hkdf-sha256\naead:aes-256-gcm\nsalt_b64:" + saltB64 + "\nnonce_b64:" + nonceB64 + "\n"
+	case "v2", "v3":
+		okm := hkdf(sk, salt, "txlock:"+version+"|chain=ethereum|path=bip44|kdf=hkdf-sha256|aead=aes-256-gcm", 64)
+		key = okm[:32]
+		aad = "txlock:" + version + "\nchain:ethereum\npath:" + path + "\n"
+		var rest string
+		for _, f := range h {
+			aad += f[0] + ":" + f[1] + "\n"
+			if f[0] != "commit_b64" {
+				rest += f[0] + ":" + f[1] + "\n"
+			}
+		}
+		if version == "v3" {
+			commit, _ := value(h, "commit_b64")
+			want, err := canonicalB64(commit)
+			mac := hmac.New(sha256.New, okm[32:])
+			mac.Write([]byte("txlock:v3|commit\ntxlock:v3\nchain:ethereum\npath:" + path + "\n" + rest))
+			if err != nil || !hmac.Equal(mac.Sum(nil), want) {
+				return nil, "", errors.New("key commitment mismatch (wrong mnemonic/index or tampered header)")
+			}
+		}
+	default:
+		return nil, "", errors.New("unknown envelope version " + version)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, "", err
+	}
+	payload, err := gcm.Open(nil, nonce, ct, []byte(aad))
+	if err != nil {
+		return nil, "", errors.New("authentication failed (wrong mnemonic/index or tampered file)")
+	}
+	if _, ok := value(h, "pad"); ok {
+		if len(payload) < 8 {
+			return nil, "", errors.New("bad padding")
+		}
+		padLen := binary.BigEndian.Uint64(payload[len(payload)-8:])
+		if padLen > uint64(len(payload)-8) {
+			return nil, "", errors.New("bad padding")
+		}
+		payload = payload[:len(payload)-8-int(padLen)]
+	}
+	if name, ok := value(h, "compress"); ok {
+		if name != "gzip" {
+			return nil, "", errors.New("unknown compression " + name)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, "", err
+		}
+		if payload, err = io.ReadAll(zr); err != nil {
+			return nil, "", err
+		}
+	}
+	meta := ""
+	if _, ok := value(h, "meta"); ok {
+		if len(payload) < 4 || int(binary.BigEndian.Uint32(payload)) > len(payload)-4 {
+			return nil, "", errors.New("bad metadata frame")
+		}
+		n := int(binary.BigEndian.Uint32(payload))
+		meta, payload = string(payload[4:4+n]), payload[4+n:]
+	}
+	return payload, meta, nil
+}
+
+// Why(中文): RFC 5869 HKDF-SHA256，extract 与 expand 一次完成。
+// Why(English): RFC 5869 HKDF-SHA256, extract and expand in one go.
+func hkdf(ikm []byte, salt []byte, info string, size int) []byte {
+	ext := hmac.New(sha256.New, salt)
+	ext.Write(ikm)
+	prk := ext.Sum(nil)
+	var out, t []byte
+	for i := byte(1); len(out) < size; i++ {
+		m := hmac.New(sha256.New, prk)
+		m.Write(t)
+		m.Write([]byte(info))
+		m.Write([]byte{i})
+		t = m.Sum(nil)
+		out = append(out, t...)
+	}
+	return out[:size]
+}
+
+// Why(中文): BIP39 种子：PBKDF2-HMAC-SHA512(助记词, "mnemonic", 2048, 64)，口令固定为空；助记词先转小写并压缩空白，与主工具一致。
+// Why(English): BIP39 seed: PBKDF2-HMAC-SHA512(mnemonic, "mnemonic", 2048, 64) with an empty passphrase; the mnemonic is lower-cased and whitespace-collapsed like the main tool.
+func bip39Seed(mnemonic string) []byte {
+	password := []byte(strings.ToLower(strings.Join(strings.Fields(mnemonic), " ")))
+	var seed []byte
+	for block := uint32(1); len(seed) < 64; block++ {
+		mac := hmac.New(sha512.New, password)
+		mac.Write([]byte("mnemonic"))
+		mac.Write(binary.BigEndian.AppendUint32(nil, block))
+		u := mac.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < 2048; i++ {
+			mac = hmac.New(sha512.New, password)
+			mac.Write(u)
+			u = mac.Sum(nil)
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		seed = append(seed, t...)
+	}
+	return seed[:64]
+}
+
+// Why(中文): secp256k1 曲线常量；非硬化 BIP32 子密钥需要父公钥，因此必须做一次标量乘法。
+// Why(English): secp256k1 curve constants; non-hardened BIP32 children need the parent public key, hence one scalar multiplication.
+var (
+	curveP, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	curveN, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	curveGx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	curveGy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+)
+
+// Why(中文): 仿射坐标加法（nil 表示无穷远点），速度无关紧要，只求简单可核对。
+// Why(English): Affine point addition with nil as the point at infinity; speed is irrelevant, only simplicity and auditability matter.
+func pointAdd(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if x1 == nil {
+		return x2, y2
+	}
+	if x2 == nil {
+		return x1, y1
+	}
+	var l *big.Int
+	if x1.Cmp(x2) == 0 {
+		if new(big.Int).Add(y1, y2).Mod(new(big.Int).Add(y1, y2), curveP).Sign() == 0 {
+			return nil, nil
+		}
+		num := new(big.Int).Mul(big.NewInt(3), new(big.Int).Mul(x1, x1))
+		den := new(big.Int).ModInverse(new(big.Int).Mul(big.NewInt(2), y1), curveP)
+		l = num.Mul(num, den)
+	} else {
+		num := new(big.Int).Sub(y2, y1)
+		den := new(big.Int).ModInverse(new(big.Int).Mod(new(big.Int).Sub(x2, x1), curveP), curveP)
+		l = num.Mul(num, den)
+	}
+	l.Mod(l, curveP)
+	x3 := new(big.Int).Sub(new(big.Int).Mul(l, l), x1)
+	x3.Sub(x3, x2).Mod(x3, curveP)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, l).Sub(y3, y1).Mod(y3, curveP)
+	return x3, y3
+}
+
+// Why(中文): 压缩公钥 = 0x02/0x03（y 奇偶）|| x（32 字节）。
+// Why(English): Compressed public key = 0x02/0x03 by y parity || x as 32 bytes.
+func compressedPub(k []byte) []byte {
+	var x, y *big.Int
+	e := new(big.Int).SetBytes(k)
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		x, y = pointAdd(x, y, x, y)
+		if e.Bit(i) == 1 {
+			x, y = pointAdd(x, y, curveGx, curveGy)
+		}
+	}
+	out := make([]byte, 33)
+	out[0] = 0x02 + byte(y.Bit(0))
+	x.FillBytes(out[1:])
+	return out
+}
+
+// Why(中文): BIP32 CKDpriv：硬化用 0x00||k，非硬化用父公钥；子私钥 = (IL + k) mod n，左补零到 32 字节。
+// Why(English): BIP32 CKDpriv: 0x00||k for hardened, the parent public key otherwise; child = (IL + k) mod n, left-padded to 32 bytes.
+func deriveSK(mnemonic string, index uint32) ([]byte, error) {
+	if strings.TrimSpace(mnemonic) == "" {
+		return nil, errors.New("mnemonic env is empty")
+	}
+	m := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	m.Write(bip39Seed(mnemonic))
+	I := m.Sum(nil)
+	k, c := I[:32], I[32:]
+	for _, child := range []uint32{0x80000000 + 44, 0x80000000 + 60, 0x80000000, 0, index} {
+		var data []byte
+		if child >= 0x80000000 {
+			data = append([]byte{0}, k...)
+		} else {
+			data = compressedPub(k)
+		}
+		m := hmac.New(sha512.New, c)
+		m.Write(binary.BigEndian.AppendUint32(data, child))
+		I := m.Sum(nil)
+		il := new(big.Int).SetBytes(I[:32])
+		if il.Cmp(curveN) >= 0 {
+			return nil, errors.New("invalid child key (BIP32 edge case)")
+		}
+		il.Add(il, new(big.Int).SetBytes(k)).Mod(il, curveN)
+		if il.Sign() == 0 {
+			return nil, errors.New("invalid child key (BIP32 edge case)")
+		}
+		k, c = il.FillBytes(make([]byte, 32)), I[32:]
+	}
+	return k, nil
+}
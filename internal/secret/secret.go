@@ -0,0 +1,59 @@
+package secret
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+var (
+	ErrNoTerminal = errors.New("no terminal available for password prompt")
+	ErrEmpty      = errors.New("empty password")
+	ErrMismatch   = errors.New("passwords do not match")
+)
+
+// Why(中文): 口令只从控制终端读取且关闭回显，不经过命令行参数或 shell 历史，也不会被重定向的 stdin 意外吞掉。
+// Why(English): Read the password only from the controlling terminal with echo off, so it never lands in argv or shell history and is not swallowed by redirected stdin.
+func Prompt(label string, confirm bool) ([]byte, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return nil, ErrNoTerminal
+		}
+		return prompt(os.Stderr, int(os.Stdin.Fd()), label, confirm, term.ReadPassword)
+	}
+	defer tty.Close()
+	return prompt(tty, int(tty.Fd()), label, confirm, term.ReadPassword)
+}
+
+// Why(中文): 读取逻辑与终端解耦，确认输入、空口令等分支可以用假读取函数单测。
+// Why(English): Decouple the read loop from the terminal so confirmation and empty-input branches are unit-testable with a fake reader.
+func prompt(w io.Writer, fd int, label string, confirm bool, read func(int) ([]byte, error)) ([]byte, error) {
+	_, _ = io.WriteString(w, label+": ")
+	first, err := read(fd)
+	_, _ = io.WriteString(w, "\n")
+	if err != nil {
+		return nil, err
+	}
+	if len(first) == 0 {
+		return nil, ErrEmpty
+	}
+	if !confirm {
+		return first, nil
+	}
+	_, _ = io.WriteString(w, "Confirm "+label+": ")
+	second, err := read(fd)
+	_, _ = io.WriteString(w, "\n")
+	if err != nil {
+		return nil, err
+	}
+	if string(first) != string(second) {
+		clear(first)
+		clear(second)
+		return nil, ErrMismatch
+	}
+	clear(second)
+	return first, nil
+}
@@ -0,0 +1,44 @@
+package secret
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func fakeReader(answers ...string) func(int) ([]byte, error) {
+	return func(int) ([]byte, error) {
+		next := answers[0]
+		answers = answers[1:]
+		return []byte(next), nil
+	}
+}
+
+// Why(中文): 加密侧需要两次输入一致才接受，避免一次手误导致文件永远无法解开。
+// Why(English): The encrypt side accepts only two matching entries so a single typo cannot lock a file forever.
+func TestPromptConfirm(t *testing.T) {
+	var out bytes.Buffer
+	got, err := prompt(&out, 0, "Password", true, fakeReader("s3cret", "s3cret"))
+	if err != nil || string(got) != "s3cret" {
+		t.Fatalf("unexpected result: %q %v", got, err)
+	}
+	if !strings.Contains(out.String(), "Confirm Password: ") {
+		t.Fatalf("expected confirmation prompt, got %q", out.String())
+	}
+	if _, err := prompt(&out, 0, "Password", true, fakeReader("s3cret", "s3creT")); err != ErrMismatch {
+		t.Fatalf("expected ErrMismatch, got %v", err)
+	}
+}
+
+// Why(中文): 空输入直接拒绝，不能派生出“空口令”密钥。
+// Why(English): Empty input is refused outright so no key is ever derived from an empty password.
+func TestPromptRejectsEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := prompt(&out, 0, "Password", false, fakeReader("")); err != ErrEmpty {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+	got, err := prompt(&out, 0, "Password", false, fakeReader("once"))
+	if err != nil || string(got) != "once" || strings.Contains(out.String(), "Confirm") {
+		t.Fatalf("unexpected single-entry result: %q %v %q", got, err, out.String())
+	}
+}
@@ -0,0 +1,150 @@
+package seqstate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"TXLOCK/internal/lockcore"
+)
+
+var (
+	ErrRollback = errors.New("envelope is older than the highest version seen for its id")
+	ErrCorrupt  = errors.New("sequence state file is corrupt")
+)
+
+// Why(中文): 状态文件默认放在用户配置目录，与被保护的备份树分开；能改写备份的攻击者通常碰不到本机配置。
+// Why(English): The state file defaults to the user config directory, apart from the backup tree; an attacker who can rewrite backups usually cannot reach local config.
+const (
+	DirName  = "txlock"
+	FileName = "seq-state.json"
+
+	stateVersion = 1
+)
+
+type fileFormat struct {
+	Version int               `json:"version"`
+	Highest map[string]uint64 `json:"highest"`
+}
+
+// Why(中文): 内存中只保留每个 id 见过的最大 seq；Save 原子替换整个文件，崩溃时不会留下半截状态。
+// Why(English): In memory only the highest seq seen per id is kept; Save replaces the whole file atomically so a crash never leaves half a state.
+type State struct {
+	path    string
+	highest map[string]uint64
+}
+
+// Why(中文): 默认路径为 <用户配置目录>/txlock/seq-state.json；取不到配置目录时返回错误，由调用方要求显式 -state。
+// Why(English): The default path is <user config dir>/txlock/seq-state.json; without a config directory an error is returned and callers ask for an explicit -state.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DirName, FileName), nil
+}
+
+// Why(中文): 显式路径优先，否则用默认路径；enc 与 dec 共用同一规则，两边读写的一定是同一个文件。
+// Why(English): An explicit path wins, otherwise the default; enc and dec share this rule so both always use the same file.
+func Resolve(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	return DefaultPath()
+}
+
+// Why(中文): 文件不存在视为空状态（首次使用）；存在但无法解析或含非法 id 则报 ErrCorrupt，而不是悄悄当作空状态放过回滚。
+// Why(English): A missing file is an empty state (first use); one that exists but fails to parse or holds an invalid id is ErrCorrupt rather than silently treated as empty, which would let a rollback through.
+func Load(path string) (*State, error) {
+	s := &State{path: path, highest: map[string]uint64{}}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	var f fileFormat
+	if err := dec.Decode(&f); err != nil || f.Version != stateVersion || f.Highest == nil {
+		return nil, ErrCorrupt
+	}
+	for id, seq := range f.Highest {
+		if !lockcore.ValidLabel(id) || seq == 0 {
+			return nil, ErrCorrupt
+		}
+	}
+	s.highest = f.Highest
+	return s, nil
+}
+
+// Why(中文): 未见过的 id 返回 0，任何合法 seq 都不小于它。
+// Why(English): An unseen id returns 0, which every valid seq is at least.
+func (s *State) Highest(id string) uint64 {
+	return s.highest[id]
+}
+
+// Why(中文): 等于已见最大值是重复打开同一版本，允许；严格小于才是回滚。
+// Why(English): Equal to the highest seen means reopening the same version and is allowed; only strictly lower is a rollback.
+func (s *State) Check(id string, seq uint64) error {
+	if seq < s.highest[id] {
+		return ErrRollback
+	}
+	return nil
+}
+
+// Why(中文): 只前进不后退；即使调用方允许回滚打开旧版本，记录的最大值也保持不变。
+// Why(English): Only ever moves forward; even when the caller allows opening an old version, the recorded highest stays put.
+func (s *State) Record(id string, seq uint64) bool {
+	if seq <= s.highest[id] {
+		return false
+	}
+	s.highest[id] = seq
+	return true
+}
+
+// Why(中文): 目录按需创建且仅本人可读写；先写同目录临时文件再 rename。
+// Why(English): Create the directory on demand, readable by the owner only; write a temp file in the same directory and rename it into place.
+func (s *State) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(fileFormat{Version: stateVersion, Highest: s.highest}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	name := tmp.Name()
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(name)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(name)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	if err := os.Rename(name, s.path); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	return nil
+}
+
+// Why(中文): 错误信息带上 id 与两个序号，用户能直接判断是否真的需要 -allow-rollback。
+// Why(English): The message carries the id and both numbers so the user can judge whether -allow-rollback is really wanted.
+func Describe(id string, seq uint64, highest uint64) string {
+	return "id " + id + " seq " + strconv.FormatUint(seq, 10) + " is older than seq " + strconv.FormatUint(highest, 10) + " already seen"
+}
@@ -0,0 +1,58 @@
+package seqstate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Why(中文): 首次使用无文件；记录只前进，保存后重新加载得到同样的最大值，等值允许、更小报 ErrRollback。
+// Why(English): First use has no file; records only move forward, reloading after Save yields the same highest values, equal is allowed and lower is ErrRollback.
+func TestStateRecordSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("load missing: %v", err)
+	}
+	if s.Highest("notes") != 0 || s.Check("notes", 1) != nil {
+		t.Fatalf("empty state must accept any seq")
+	}
+	if !s.Record("notes", 5) || s.Record("notes", 3) || s.Record("notes", 5) {
+		t.Fatalf("Record must report only forward moves")
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if fi, err := os.Stat(filepath.Dir(path)); err != nil || fi.Mode().Perm() != 0o700 {
+		t.Fatalf("state dir must be private: %v", err)
+	}
+	s, err = Load(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if s.Highest("notes") != 5 || s.Check("notes", 5) != nil || s.Check("notes", 4) != ErrRollback {
+		t.Fatalf("reloaded state lost the highest seq")
+	}
+}
+
+// Why(中文): 损坏或被改坏的状态文件不能被当成空状态，否则回滚会被悄悄放过。
+// Why(English): A corrupt or edited state file must not count as empty, or rollbacks would slip through silently.
+func TestLoadRejectsCorruptState(t *testing.T) {
+	dir := t.TempDir()
+	for i, body := range []string{
+		"",
+		"{}",
+		`{"version":2,"highest":{}}`,
+		`{"version":1,"highest":{"bad id":1}}`,
+		`{"version":1,"highest":{"notes":0}}`,
+		`{"version":1,"highest":{},"extra":1}`,
+	} {
+		path := filepath.Join(dir, "s.json")
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if _, err := Load(path); err != ErrCorrupt {
+			t.Fatalf("case %d: expected ErrCorrupt, got %v", i, err)
+		}
+	}
+}
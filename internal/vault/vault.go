@@ -0,0 +1,394 @@
+package vault
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"TXLOCK/internal/lockcore"
+)
+
+var (
+	ErrExists      = errors.New("vault directory is not empty")
+	ErrNotVault    = errors.New("not a vault (manifest.lock missing)")
+	ErrUnlock      = errors.New("vault unlock failed (index/mnemonic mismatch or tampered manifest)")
+	ErrCorrupt     = errors.New("vault entry or manifest is corrupt or was tampered with")
+	ErrNotFound    = errors.New("no such vault entry")
+	ErrNameTaken   = errors.New("vault entry name already exists")
+	ErrInvalidName = errors.New("invalid vault entry name")
+)
+
+// Why(中文): 清单与条目文件都是标准 v1 信封；条目文件名只是随机 id，名称只出现在加密清单里。
+// Why(English): The manifest and entry files are standard v1 envelopes; entry filenames are random ids and names appear only inside the encrypted manifest.
+const (
+	ManifestFile = "manifest.lock"
+	EntriesDir   = "entries"
+
+	manifestVersion = 1
+	maxNameLen      = 255
+	idSize          = 16
+)
+
+// Why(中文): 子密钥标签固定写死，任何实现只要有助记词与索引就能按 HKDF(sk, label) 重算，不依赖 vault 内的任何明文状态。
+// Why(English): Subkey labels are fixed so any implementation holding the mnemonic and index can recompute HKDF(sk, label) without plaintext state from the vault.
+const (
+	manifestLabel    = "txlock/vault/manifest"
+	entryLabelPrefix = "txlock/vault/entry/"
+)
+
+type Entry struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+	Size int    `json:"size"`
+}
+
+type manifest struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Why(中文): 解锁后的 vault 持有 sk 副本与解密后的清单；所有修改先写条目再原子替换清单，中途失败最多留下无人引用的条目文件。
+// Why(English): An unlocked vault holds a copy of sk and the decrypted manifest; every change writes the entry first and then atomically replaces the manifest, so a failure midway leaves at most an unreferenced entry file.
+type Vault struct {
+	dir     string
+	path    string
+	sk      []byte
+	random  io.Reader
+	entries []Entry
+}
+
+// Why(中文): 只在不存在或为空的目录上初始化，避免把已有文件混进 vault 或覆盖另一个 vault 的清单。
+// Why(English): Initialize only a missing or empty directory so existing files never mix into the vault and another vault's manifest is never overwritten.
+func Init(dir string, sk []byte, path string, random io.Reader) (*Vault, error) {
+	if names, err := os.ReadDir(dir); err == nil && len(names) > 0 {
+		return nil, ErrExists
+	} else if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, EntriesDir), 0o700); err != nil {
+		return nil, err
+	}
+	v := newVault(dir, sk, path, random)
+	if err := v.saveManifest(); err != nil {
+		v.Close()
+		return nil, err
+	}
+	return v, nil
+}
+
+// Why(中文): 打开即解锁：清单认证失败说明索引/助记词不对或清单被改，统一报 ErrUnlock，不区分两者以免成为判定密钥的信号。
+// Why(English): Opening is unlocking: a manifest that fails authentication means a wrong index/mnemonic or a modified manifest, reported as ErrUnlock without telling the two apart so it never becomes a key oracle.
+func Open(dir string, sk []byte, path string, random io.Reader) (*Vault, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil, ErrNotVault
+	}
+	if err != nil {
+		return nil, err
+	}
+	v := newVault(dir, sk, path, random)
+	key := manifestKey(v.sk)
+	defer wipe(key)
+	plain, err := openEnvelope(key, path, raw)
+	if err == ErrCorrupt {
+		v.Close()
+		return nil, ErrCorrupt
+	}
+	if err != nil {
+		v.Close()
+		return nil, ErrUnlock
+	}
+	var m manifest
+	if err := json.Unmarshal(plain, &m); err != nil || !validManifest(m) {
+		v.Close()
+		return nil, ErrCorrupt
+	}
+	v.entries = m.Entries
+	return v, nil
+}
+
+// Why(中文): sk 在构造时复制一份，调用方可以立即清零自己的副本；Close 清零 vault 持有的这一份。
+// Why(English): sk is copied at construction so callers can wipe theirs at once; Close wipes the vault's copy.
+func newVault(dir string, sk []byte, path string, random io.Reader) *Vault {
+	return &Vault{dir: dir, path: path, sk: append([]byte(nil), sk...), random: random, entries: []Entry{}}
+}
+
+// Why(中文): 用完即清零 sk，之后的操作因密钥为零而无法通过认证。
+// Why(English): Wipe sk when done; later operations fail authentication because the key is zero.
+func (v *Vault) Close() {
+	wipe(v.sk)
+}
+
+// Why(中文): 每个条目用独立 id 派生独立子密钥；条目文件被互换时子密钥不匹配，解密直接失败。
+// Why(English): Each entry derives its own subkey from a fresh id, so swapped entry files fail decryption outright.
+func (v *Vault) Add(name string, data []byte) error {
+	if !validName(name) {
+		return ErrInvalidName
+	}
+	if v.find(name) >= 0 {
+		return ErrNameTaken
+	}
+	idRaw := make([]byte, idSize)
+	if _, err := io.ReadFull(v.random, idRaw); err != nil {
+		return lockcore.ErrRandomRead
+	}
+	id := hex.EncodeToString(idRaw)
+	key := entryKey(v.sk, id)
+	defer wipe(key)
+	env, err := sealEnvelope(key, v.path, data, v.random)
+	if err != nil {
+		return err
+	}
+	entryPath := v.entryPath(id)
+	if err := writeFileAtomic(entryPath, []byte(env)); err != nil {
+		return err
+	}
+	prev := v.entries
+	v.entries = append(append([]Entry(nil), prev...), Entry{Name: name, ID: id, Size: len(data)})
+	sortEntries(v.entries)
+	if err := v.saveManifest(); err != nil {
+		v.entries = prev
+		_ = os.Remove(entryPath)
+		return err
+	}
+	return nil
+}
+
+// Why(中文): 条目文件缺失、格式错误或认证失败都报 ErrCorrupt：清单已解锁，密钥不可能错，只能是文件被删改或互换。
+// Why(English): A missing, malformed or unauthenticated entry file is ErrCorrupt: the manifest unlocked, so the key is right and the file must have been altered, removed or swapped.
+func (v *Vault) Get(name string) ([]byte, error) {
+	i := v.find(name)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	raw, err := os.ReadFile(v.entryPath(v.entries[i].ID))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	key := entryKey(v.sk, v.entries[i].ID)
+	defer wipe(key)
+	plain, err := openEnvelope(key, v.path, raw)
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	return plain, nil
+}
+
+// Why(中文): 过滤为不区分大小写的子串匹配，空过滤返回全部；返回副本，调用方修改不会影响清单。
+// Why(English): The filter is a case-insensitive substring match and empty means all; a copy is returned so callers cannot alter the manifest.
+func (v *Vault) List(filter string) []Entry {
+	needle := strings.ToLower(filter)
+	out := []Entry{}
+	for _, e := range v.entries {
+		if strings.Contains(strings.ToLower(e.Name), needle) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Why(中文): 先提交不含该条目的清单再删文件；删除失败只留下无人引用的密文，不会出现清单指向缺失文件。
+// Why(English): Commit the manifest without the entry before deleting the file; a failed delete leaves only unreferenced ciphertext, never a manifest pointing at a missing file.
+func (v *Vault) Remove(name string) error {
+	i := v.find(name)
+	if i < 0 {
+		return ErrNotFound
+	}
+	prev := v.entries
+	id := prev[i].ID
+	v.entries = append(append([]Entry(nil), prev[:i]...), prev[i+1:]...)
+	if err := v.saveManifest(); err != nil {
+		v.entries = prev
+		return err
+	}
+	if err := os.Remove(v.entryPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Why(中文): 改名只改清单，条目 id 与子密钥不变，因此无需重新加密条目内容。
+// Why(English): Renaming touches only the manifest; the entry id and subkey stay the same, so the content is not re-encrypted.
+func (v *Vault) Move(oldName string, newName string) error {
+	if !validName(newName) {
+		return ErrInvalidName
+	}
+	i := v.find(oldName)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if v.find(newName) >= 0 {
+		return ErrNameTaken
+	}
+	prev := v.entries
+	v.entries = append([]Entry(nil), prev...)
+	v.entries[i].Name = newName
+	sortEntries(v.entries)
+	if err := v.saveManifest(); err != nil {
+		v.entries = prev
+		return err
+	}
+	return nil
+}
+
+// Why(中文): 条目始终按名称有序保存，查找走二分，清单明文在内容相同时也保持字节稳定。
+// Why(English): Entries stay sorted by name, so lookup is a binary search and the manifest plaintext is byte-stable for identical contents.
+func (v *Vault) find(name string) int {
+	i := sort.Search(len(v.entries), func(i int) bool { return v.entries[i].Name >= name })
+	if i < len(v.entries) && v.entries[i].Name == name {
+		return i
+	}
+	return -1
+}
+
+// Why(中文): 条目文件名只由随机 id 组成，目录列表不泄露任何名称。
+// Why(English): Entry filenames consist only of the random id, so a directory listing leaks no names.
+func (v *Vault) entryPath(id string) string {
+	return filepath.Join(v.dir, EntriesDir, id+".lock")
+}
+
+// Why(中文): 清单整体重新封装（新 salt/nonce），原子替换旧文件，读者永远看到完整的新清单或旧清单。
+// Why(English): The manifest is resealed as a whole (fresh salt/nonce) and atomically replaces the old file, so readers always see the complete new or old manifest.
+func (v *Vault) saveManifest() error {
+	plain, err := json.Marshal(manifest{Version: manifestVersion, Entries: v.entries})
+	if err != nil {
+		return err
+	}
+	key := manifestKey(v.sk)
+	defer wipe(key)
+	env, err := sealEnvelope(key, v.path, plain, v.random)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(v.dir, ManifestFile), []byte(env))
+}
+
+// Why(中文): 清单解密后仍逐项校验名称、id 与唯一性，畸形清单不会变成路径穿越或重名歧义。
+// Why(English): Even a decrypted manifest is checked entry by entry for name, id and uniqueness, so a malformed one cannot turn into path traversal or duplicate-name ambiguity.
+func validManifest(m manifest) bool {
+	if m.Version != manifestVersion || m.Entries == nil {
+		return false
+	}
+	ids := map[string]bool{}
+	for i, e := range m.Entries {
+		if !validName(e.Name) || !validID(e.ID) || ids[e.ID] || e.Size < 0 {
+			return false
+		}
+		if i > 0 && m.Entries[i-1].Name >= e.Name {
+			return false
+		}
+		ids[e.ID] = true
+	}
+	return true
+}
+
+// Why(中文): 名称可以含空格与任意 Unicode 文字，但不允许控制字符，ls 输出因此不会被换行或转义序列打乱。
+// Why(English): Names may contain spaces and any Unicode text but no control characters, so ls output cannot be broken by newlines or escape sequences.
+func validName(name string) bool {
+	if name == "" || len(name) > maxNameLen || !utf8.ValidString(name) {
+		return false
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Why(中文): id 固定为 32 位小写十六进制，拼接文件路径前必须确认不含分隔符或 ".."。
+// Why(English): An id is exactly 32 lowercase hex digits, confirmed before it is joined into a path so it cannot carry separators or "..".
+func validID(id string) bool {
+	if len(id) != 2*idSize {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if !(id[i] >= '0' && id[i] <= '9' || id[i] >= 'a' && id[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Why(中文): 排序规则与 find 的二分查找一致，按字节序比较名称。
+// Why(English): Sorting matches find's binary search, comparing names bytewise.
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+}
+
+// Why(中文): 清单子密钥与条目子密钥使用不同标签做域分离，同一 sk 下两者互不可替代。
+// Why(English): The manifest and entry subkeys use distinct labels for domain separation, so neither can stand in for the other under the same sk.
+func manifestKey(sk []byte) []byte {
+	return lockcore.HKDFSHA256(sk, nil, []byte(manifestLabel), 32)
+}
+
+// Why(中文): 标签含条目 id，每个条目得到独立子密钥；id 随清单保存，恢复时只需助记词与索引。
+// Why(English): The label carries the entry id so each entry gets its own subkey; the id lives in the manifest, so recovery needs only the mnemonic and index.
+func entryKey(sk []byte, id string) []byte {
+	return lockcore.HKDFSHA256(sk, nil, []byte(entryLabelPrefix+id), 32)
+}
+
+// Why(中文): 直接复用 SealV1 与 BuildEnvelopeV1，vault 文件因此与普通 v1 信封格式完全相同。
+// Why(English): Reuse SealV1 and BuildEnvelopeV1 directly so vault files share the exact format of ordinary v1 envelopes.
+func sealEnvelope(key []byte, path string, plain []byte, random io.Reader) (string, error) {
+	sealed, err := lockcore.SealV1(key, path, plain, random)
+	if err != nil {
+		return "", err
+	}
+	return lockcore.BuildEnvelopeV1(path, sealed.SaltB64, sealed.NonceB64, base64.RawStdEncoding.EncodeToString(sealed.Ciphertext)), nil
+}
+
+// Why(中文): 解析失败归为 ErrCorrupt，认证失败原样返回 lockcore 的错误，由调用方决定是解锁失败还是条目损坏。
+// Why(English): A parse failure is ErrCorrupt while an authentication failure returns lockcore's error as-is, letting the caller decide between an unlock failure and a corrupt entry.
+func openEnvelope(key []byte, path string, raw []byte) ([]byte, error) {
+	_, salt, nonce, ct, ok := lockcore.ParseEnvelopeV1(string(raw))
+	if !ok {
+		return nil, ErrCorrupt
+	}
+	return lockcore.OpenV1(key, path, salt, nonce, ct)
+}
+
+// Why(中文): 先写同目录临时文件并 fsync，再 rename 覆盖，断电或崩溃时不会留下半截清单。
+// Why(English): Write and fsync a temp file in the same directory, then rename over the target, so a crash or power loss never leaves a half-written manifest.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	name := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(name)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(name)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	if err := os.Rename(name, path); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	return nil
+}
+
+// Why(中文): 子密钥与 sk 副本用完即清零，缩短私密材料在内存中的停留时间。
+// Why(English): Zero subkeys and the sk copy after use to shorten how long secrets linger in memory.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
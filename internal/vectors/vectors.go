@@ -0,0 +1,619 @@
+package vectors
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"TXLOCK/internal/derive"
+	"TXLOCK/internal/lockcore"
+
+	bip39 "github.com/vcvvvc/go-wallet-sdk/crypto/go-bip39"
+)
+
+var ErrUnknownSuite = errors.New("unknown vector suite file")
+
+// Why(中文): 夹具输入与 docs/test-vectors.md 相同；第二个助记词取自公开的 BIP39 测试集，便于独立实现交叉核对种子。
+// Why(English): Fixture inputs match docs/test-vectors.md; the second mnemonic comes from the public BIP39 test set so independent implementations can cross-check the seed.
+const (
+	fixtureMnemonic  = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	fixtureMnemonic2 = "legal winner thank year wave sausage worth useful legal winner thank yellow"
+	fixtureIndex     = "777"
+	fixturePath      = "m/44'/60'/0'/0/777"
+	fixtureSaltHex   = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	fixtureNonceHex  = "00112233445566778899aabb"
+	fixturePlaintext = "hello txlock\n"
+	fixtureLabel     = "notes.md"
+)
+
+type File struct {
+	Name string
+	Data []byte
+}
+
+type suite[T any] struct {
+	Suite       string `json:"suite"`
+	Description string `json:"description"`
+	Cases       []T    `json:"cases"`
+}
+
+type deriveCase struct {
+	Name       string `json:"name"`
+	Mnemonic   string `json:"mnemonic"`
+	Passphrase string `json:"passphrase"`
+	SeedHex    string `json:"seed_hex"`
+	Index      string `json:"index"`
+	Path       string `json:"path"`
+	SKHex      string `json:"sk_hex"`
+}
+
+type hkdfCase struct {
+	Name    string `json:"name"`
+	IKMHex  string `json:"ikm_hex"`
+	SaltHex string `json:"salt_hex"`
+	InfoHex string `json:"info_hex"`
+	Length  int    `json:"length"`
+	OKMHex  string `json:"okm_hex"`
+}
+
+type headerEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type aadCase struct {
+	Name     string        `json:"name"`
+	Version  string        `json:"version"`
+	Path     string        `json:"path"`
+	SaltB64  string        `json:"salt_b64,omitempty"`
+	NonceB64 string        `json:"nonce_b64,omitempty"`
+	Header   []headerEntry `json:"header,omitempty"`
+	AAD      string        `json:"aad"`
+}
+
+type sealCase struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	SKHex     string `json:"sk_hex"`
+	Path      string `json:"path"`
+	SaltHex   string `json:"salt_hex"`
+	NonceHex  string `json:"nonce_hex"`
+	Compress  string `json:"compress,omitempty"`
+	Pad       string `json:"pad,omitempty"`
+	Plaintext string `json:"plaintext"`
+	Envelope  string `json:"envelope"`
+}
+
+type rejectCase struct {
+	Name     string `json:"name"`
+	Stage    string `json:"stage"`
+	Reason   string `json:"reason"`
+	SKHex    string `json:"sk_hex"`
+	Path     string `json:"path"`
+	Envelope string `json:"envelope"`
+}
+
+// Why(中文): 每个套件文件对应一个生成函数与一个校验函数；校验按文件内容重新计算，而不是与生成结果逐字节比较，独立追加的用例同样会被验证。
+// Why(English): Each suite file pairs a generator with a checker; checking recomputes from the file contents instead of diffing against generator output, so independently added cases are verified too.
+var suites = []struct {
+	name     string
+	generate func() (any, error)
+	check    func([]byte) (int, []string, error)
+}{
+	{"derive.json", generateDerive, checkSuite(checkDerive)},
+	{"hkdf.json", generateHKDF, checkSuite(checkHKDF)},
+	{"aad.json", generateAAD, checkSuite(checkAAD)},
+	{"seal.json", generateSeal, checkSuite(checkSeal)},
+	{"reject.json", generateReject, checkSuite(checkReject)},
+}
+
+// Why(中文): 全部套件在内存中生成并以固定格式序列化（两空格缩进、不转义 HTML），重新生成的文件可以直接 diff。
+// Why(English): Every suite is generated in memory and serialized in one fixed format (two-space indent, no HTML escaping) so regenerated files diff cleanly.
+func Generate() ([]File, error) {
+	files := make([]File, 0, len(suites))
+	for _, s := range suites {
+		v, err := s.generate()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: s.name, Data: b.Bytes()})
+	}
+	return files, nil
+}
+
+// Why(中文): 生成目标是仓库内受版本控制的目录，覆盖写入即可；是否有变化交给 git diff 判断。
+// Why(English): The target is a version-controlled directory in the repository, so files are simply overwritten and git diff shows whether anything changed.
+func Write(dir string) error {
+	files, err := Generate()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f.Name), f.Data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Why(中文): 校验返回用例数与逐条失败描述；缺少套件文件或出现未知 json 文件属于错误，防止套件被悄悄删减或拼错文件名。
+// Why(English): Checking returns the case count and one description per failure; a missing suite file or an unknown json file is an error so the suite cannot be silently trimmed or misnamed.
+func Check(dir string) (int, []string, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, nil, err
+	}
+	known := map[string]bool{}
+	for _, s := range suites {
+		known[s.name] = true
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[filepath.Base(name)] {
+			return 0, nil, fmt.Errorf("%w: %s", ErrUnknownSuite, filepath.Base(name))
+		}
+	}
+	total := 0
+	var failures []string
+	for _, s := range suites {
+		data, err := os.ReadFile(filepath.Join(dir, s.name))
+		if err != nil {
+			return 0, nil, err
+		}
+		n, fails, err := s.check(data)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+		total += n
+		for _, f := range fails {
+			failures = append(failures, s.name+": "+f)
+		}
+	}
+	return total, failures, nil
+}
+
+// Why(中文): 严格解码套件文件（拒绝未知字段与空用例表），字段拼错时直接报错而不是被当作空值静默通过。
+// Why(English): Decode suite files strictly, rejecting unknown fields and empty case lists, so a misspelled field fails loudly instead of passing as an empty value.
+func checkSuite[T any](each func(T) error) func([]byte) (int, []string, error) {
+	return func(data []byte) (int, []string, error) {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		var s suite[T]
+		if err := dec.Decode(&s); err != nil {
+			return 0, nil, err
+		}
+		if len(s.Cases) == 0 {
+			return 0, nil, errors.New("no cases")
+		}
+		var failures []string
+		for i, c := range s.Cases {
+			if err := each(c); err != nil {
+				failures = append(failures, fmt.Sprintf("case %d: %v", i, err))
+			}
+		}
+		return len(s.Cases), failures, nil
+	}
+}
+
+// Why(中文): 种子与 SK 分开给出，实现方可以区分是 BIP39 还是 BIP32/BIP44 环节出错。
+// Why(English): Seed and SK are listed separately so an implementation can tell a BIP39 fault from a BIP32/BIP44 one.
+func generateDerive() (any, error) {
+	s := suite[deriveCase]{Suite: "derive", Description: "BIP39 mnemonic (empty passphrase) -> seed -> BIP32/BIP44 m/44'/60'/0'/0/<index> -> 32-byte SK"}
+	for _, m := range []string{fixtureMnemonic, fixtureMnemonic2} {
+		seed, err := bip39.NewSeedWithErrorChecking(m, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range []string{"0", fixtureIndex, "2147483647"} {
+			sk, err := derive.DeriveSK(m, index)
+			if err != nil {
+				return nil, err
+			}
+			s.Cases = append(s.Cases, deriveCase{
+				Name:     fmt.Sprintf("%s-index-%s", strings.Fields(m)[0], index),
+				Mnemonic: m,
+				SeedHex:  hex.EncodeToString(seed),
+				Index:    index,
+				Path:     "m/44'/60'/0'/0/" + index,
+				SKHex:    hex.EncodeToString(sk),
+			})
+		}
+	}
+	return s, nil
+}
+
+// Why(中文): v1 冻结 BIP39 passphrase 为空串，非空 passphrase 的用例没有意义，校验时直接视为错误。
+// Why(English): v1 freezes the BIP39 passphrase to the empty string, so a case with a passphrase is meaningless and is reported as a failure.
+func checkDerive(c deriveCase) error {
+	if c.Passphrase != "" {
+		return errors.New("passphrase must be empty")
+	}
+	seed, err := bip39.NewSeedWithErrorChecking(c.Mnemonic, c.Passphrase)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(seed) != c.SeedHex {
+		return errors.New("seed mismatch")
+	}
+	if c.Path != "m/44'/60'/0'/0/"+c.Index {
+		return errors.New("path does not match index")
+	}
+	sk, err := derive.DeriveSK(c.Mnemonic, c.Index)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(sk) != c.SKHex {
+		return errors.New("sk mismatch")
+	}
+	return nil
+}
+
+// Why(中文): 第一条是 RFC 5869 A.1，证明 HKDF 原语本身正确；其后是 v1 与 v3 的真实 INFO，v3 一次输出 64 字节，前半为 K、后半为 CK；最后一条是空 salt 的文件标签子密钥。
+// Why(English): The first case is RFC 5869 A.1, proving the HKDF primitive itself; next come the real v1 and v3 INFO strings, where v3 outputs 64 bytes split into K and CK; the last is the empty-salt file label subkey.
+func generateHKDF() (any, error) {
+	s := suite[hkdfCase]{Suite: "hkdf", Description: "HKDF-SHA256(ikm, salt, info, length); protocol cases use ikm = SK and the fixture salt, the file label case an empty salt"}
+	ikm, _ := hex.DecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	s.Cases = append(s.Cases, hkdfCase{Name: "rfc5869-a1", IKMHex: hex.EncodeToString(ikm), SaltHex: hex.EncodeToString(salt), InfoHex: hex.EncodeToString(info), Length: 42, OKMHex: hex.EncodeToString(lockcore.HKDFSHA256(ikm, salt, info, 42))})
+	v1, v3, sk, err := workedExamples()
+	if err != nil {
+		return nil, err
+	}
+	for _, ex := range []*lockcore.WorkedExample{v1, v3} {
+		okm := append(append([]byte{}, ex.Key...), ex.CommitKey...)
+		name := ex.Version + "-key"
+		if ex.CommitKey != nil {
+			name = ex.Version + "-key-and-commit-key"
+		}
+		s.Cases = append(s.Cases, hkdfCase{Name: name, IKMHex: hex.EncodeToString(sk), SaltHex: fixtureSaltHex, InfoHex: hex.EncodeToString([]byte(ex.Info)), Length: len(okm), OKMHex: hex.EncodeToString(okm)})
+	}
+	subkey, err := lockcore.LabelKey(sk, fixtureLabel)
+	if err != nil {
+		return nil, err
+	}
+	s.Cases = append(s.Cases, hkdfCase{Name: "file-label-subkey", IKMHex: hex.EncodeToString(sk), InfoHex: hex.EncodeToString([]byte(lockcore.LabelInfoPrefix + fixtureLabel)), Length: 32, OKMHex: hex.EncodeToString(subkey)})
+	return s, nil
+}
+
+// Why(中文): HKDF 用例只依赖原语本身，按文件给出的参数重新计算即可。
+// Why(English): HKDF cases depend only on the primitive, so they are recomputed from the parameters in the file.
+func checkHKDF(c hkdfCase) error {
+	ikm, err1 := hex.DecodeString(c.IKMHex)
+	salt, err2 := hex.DecodeString(c.SaltHex)
+	info, err3 := hex.DecodeString(c.InfoHex)
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return err
+	}
+	if c.Length <= 0 || c.Length > 255*32 {
+		return errors.New("length out of range")
+	}
+	if hex.EncodeToString(lockcore.HKDFSHA256(ikm, salt, info, c.Length)) != c.OKMHex {
+		return errors.New("okm mismatch")
+	}
+	return nil
+}
+
+// Why(中文): AAD 以原文字符串给出（均为 ASCII，换行以 JSON 转义表示），实现方可以直接逐字节比较。
+// Why(English): AAD is given as the literal string (all ASCII, newlines JSON-escaped) so implementations can compare byte for byte.
+func generateAAD() (any, error) {
+	s := suite[aadCase]{Suite: "aad", Description: "Exact AAD bytes; v1 from path/salt_b64/nonce_b64, v2/v3 from the ordered header"}
+	v1, err := sealFixture("v1", "", "")
+	if err != nil {
+		return nil, err
+	}
+	_, saltB64, nonceB64, _, _ := lockcore.ParseEnvelopeV1(v1)
+	s.Cases = append(s.Cases, aadCase{Name: "v1", Version: "v1", Path: fixturePath, SaltB64: saltB64, NonceB64: nonceB64, AAD: string(lockcore.AADV1(fixturePath, saltB64, nonceB64))})
+	for _, version := range []string{"v2", "v3"} {
+		env, err := sealFixture(version, "gzip", "padme")
+		if err != nil {
+			return nil, err
+		}
+		h, _, _ := lockcore.ParseEnvelopeV2(env)
+		s.Cases = append(s.Cases, aadCase{Name: version + "-gzip-padme", Version: version, Path: fixturePath, Header: toEntries(h), AAD: string(lockcore.AADV2(version, fixturePath, h))})
+	}
+	return s, nil
+}
+
+// Why(中文): v1 只接受三元组输入，v2/v3 只接受有序头；两类字段混用说明用例本身写错。
+// Why(English): v1 takes only the triple and v2/v3 only the ordered header; mixing the two means the case itself is wrong.
+func checkAAD(c aadCase) error {
+	var got []byte
+	switch c.Version {
+	case "v1":
+		if c.Header != nil {
+			return errors.New("v1 case carries a header")
+		}
+		got = lockcore.AADV1(c.Path, c.SaltB64, c.NonceB64)
+	case "v2", "v3":
+		if c.SaltB64 != "" || c.NonceB64 != "" {
+			return errors.New("v2/v3 case carries v1 fields")
+		}
+		got = lockcore.AADV2(c.Version, c.Path, fromEntries(c.Header))
+	default:
+		return errors.New("unknown version")
+	}
+	if string(got) != c.AAD {
+		return errors.New("aad mismatch")
+	}
+	return nil
+}
+
+// Why(中文): 覆盖 v1、v2、v3 以及 v3 叠加 gzip 与 padme，全部使用夹具 salt/nonce，输出可逐字节复现。
+// Why(English): Cover v1, v2, v3 and v3 with gzip plus padme, all on the fixture salt and nonce so the output is reproducible byte for byte.
+func generateSeal() (any, error) {
+	s := suite[sealCase]{Suite: "seal", Description: "Seal with fixed salt/nonce (test only) must produce the exact envelope; opening it with SK and path must return the plaintext"}
+	sk, err := derive.DeriveSK(fixtureMnemonic, fixtureIndex)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range []struct{ version, compress, pad string }{{"v1", "", ""}, {"v2", "", ""}, {"v3", "", ""}, {"v3", "gzip", "padme"}} {
+		env, err := sealFixture(c.version, c.compress, c.pad)
+		if err != nil {
+			return nil, err
+		}
+		name := c.version
+		if c.compress != "" {
+			name += "-" + c.compress + "-" + c.pad
+		}
+		s.Cases = append(s.Cases, sealCase{Name: name, Version: c.version, SKHex: hex.EncodeToString(sk), Path: fixturePath, SaltHex: fixtureSaltHex, NonceHex: fixtureNonceHex, Compress: c.compress, Pad: c.pad, Plaintext: fixturePlaintext, Envelope: env})
+	}
+	return s, nil
+}
+
+// Why(中文): 封装方向与解封方向都要验证：重新封装必须得到相同信封，解封必须得到原明文。
+// Why(English): Both directions are verified: resealing must give the same envelope and opening must give the plaintext.
+func checkSeal(c sealCase) error {
+	sk, err1 := hex.DecodeString(c.SKHex)
+	salt, err2 := hex.DecodeString(c.SaltHex)
+	nonce, err3 := hex.DecodeString(c.NonceHex)
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return err
+	}
+	env, err := seal(sk, c.Path, salt, nonce, c.Version, c.Compress, c.Pad, []byte(c.Plaintext))
+	if err != nil {
+		return err
+	}
+	if env != c.Envelope {
+		return errors.New("envelope mismatch")
+	}
+	parsed, pt, err := open(sk, c.Path, c.Envelope)
+	if !parsed || err != nil {
+		return fmt.Errorf("open failed: %v", err)
+	}
+	if string(pt) != c.Plaintext {
+		return errors.New("plaintext mismatch")
+	}
+	return nil
+}
+
+// Why(中文): 拒绝目录从合法信封逐项变异而来，并标明应在哪一层失败：parse 表示严格解析即拒绝，encoding 表示因非规范 base64 在解析阶段（任何密钥派生之前）拒绝，open 表示解析通过但认证失败。
+// Why(English): The reject catalogue mutates valid envelopes one aspect at a time and states where each must fail: parse means strict parsing rejects it, encoding means non-canonical base64 rejects it at parse time before any key derivation, open means it parses but authentication fails.
+func generateReject() (any, error) {
+	s := suite[rejectCase]{Suite: "reject", Description: "Envelopes that must be rejected; stage parse = strict parser rejects, stage encoding = parser rejects because a base64 field is not canonical (decode then re-encode differs), stage open = parses but authentication fails"}
+	sk, err := derive.DeriveSK(fixtureMnemonic, fixtureIndex)
+	if err != nil {
+		return nil, err
+	}
+	skHex := hex.EncodeToString(sk)
+	v1, err := sealFixture("v1", "", "")
+	if err != nil {
+		return nil, err
+	}
+	v3, err := sealFixture("v3", "", "")
+	if err != nil {
+		return nil, err
+	}
+	lines1 := strings.Split(v1, "\n")
+	salt1, nonce1, ct1 := lines1[4], lines1[5], lines1[7]
+	lines3 := strings.Split(v3, "\n")
+	var commit3 string
+	for _, line := range lines3 {
+		if strings.HasPrefix(line, "commit_b64:") {
+			commit3 = line
+		}
+	}
+	add := func(name, stage, reason, path, env string) {
+		s.Cases = append(s.Cases, rejectCase{Name: name, Stage: stage, Reason: reason, SKHex: skHex, Path: path, Envelope: env})
+	}
+	add("v1-leading-byte", "parse", "bytes before the comment boundary", fixturePath, " "+v1)
+	add("v1-trailing-byte", "parse", "bytes after the comment boundary", fixturePath, v1+"x")
+	add("v1-crlf", "parse", "CRLF line endings", fixturePath, strings.ReplaceAll(v1, "\n", "\r\n"))
+	add("v1-header-space", "parse", "whitespace after the colon", fixturePath, strings.Replace(v1, "kdf:hkdf-sha256", "kdf: hkdf-sha256", 1))
+	add("v1-duplicate-header", "parse", "duplicate header key", fixturePath, strings.Replace(v1, "\nct_b64:\n", "\nkdf:hkdf-sha256\nct_b64:\n", 1))
+	add("v1-unknown-header", "parse", "header key outside the v1 set", fixturePath, strings.Replace(v1, "\nct_b64:\n", "\nmeta:x\nct_b64:\n", 1))
+	add("v1-kdf-drift", "parse", "kdf other than hkdf-sha256", fixturePath, strings.Replace(v1, "kdf:hkdf-sha256", "kdf:hkdf-sha512", 1))
+	add("v1-chain-drift", "parse", "chain other than ethereum", fixturePath, strings.Replace(v1, "\nkdf:", "\nchain:bitcoin\nkdf:", 1))
+	add("v1-missing-nonce", "parse", "nonce_b64 line absent", fixturePath, strings.Replace(v1, nonce1+"\n", "", 1))
+	add("v1-padded-ct", "parse", "base64 padding in the ciphertext", fixturePath, strings.Replace(v1, ct1, ct1+"=", 1))
+	add("v1-urlsafe-ct", "parse", "URL-safe alphabet in the ciphertext", fixturePath, strings.Replace(v1, ct1, strings.NewReplacer("/", "_", "+", "-").Replace(ct1), 1))
+	add("v1-path-drift", "open", "path differs from the one sealed (AAD and index mismatch)", "m/44'/60'/0'/0/778", v1)
+	add("v1-salt-drift", "open", "salt_b64 altered", fixturePath, strings.Replace(v1, salt1, "salt_b64:B"+salt1[len("salt_b64:A"):], 1))
+	add("v1-nonce-drift", "open", "nonce_b64 altered", fixturePath, strings.Replace(v1, nonce1, "nonce_b64:B"+nonce1[len("nonce_b64:A"):], 1))
+	add("v1-ct-bitflip", "open", "ciphertext character altered", fixturePath, strings.Replace(v1, ct1, flipFirst(ct1), 1))
+	ctBytes, _ := base64.RawStdEncoding.DecodeString(ct1)
+	add("v1-truncated-tag", "open", "last 3 ciphertext bytes removed (re-encoded canonically)", fixturePath, strings.Replace(v1, ct1, base64.RawStdEncoding.EncodeToString(ctBytes[:len(ctBytes)-3]), 1))
+	add("v3-header-order", "parse", "aead before kdf", fixturePath, strings.Replace(v3, "kdf:hkdf-sha256\naead:aes-256-gcm\n", "aead:aes-256-gcm\nkdf:hkdf-sha256\n", 1))
+	add("v3-missing-commit", "parse", "txlock:v3 without commit_b64", fixturePath, strings.Replace(v3, commit3+"\n", "", 1))
+	add("v3-commit-drift", "open", "commit_b64 altered", fixturePath, strings.Replace(v3, commit3, "commit_b64:"+flipFirst(commit3[len("commit_b64:"):]), 1))
+	salt3 := ""
+	for _, line := range lines3 {
+		if strings.HasPrefix(line, "salt_b64:") {
+			salt3 = line
+		}
+	}
+	add("v1-noncanonical-salt", "encoding", "salt_b64 last character differs only in trailing bits", fixturePath, strings.Replace(v1, salt1, malleate(salt1), 1))
+	add("v1-nonce-cr", "encoding", "carriage return inside nonce_b64 (ignored by lenient decoders)", fixturePath, strings.Replace(v1, nonce1, nonce1+"\r", 1))
+	add("v1-noncanonical-ct", "encoding", "ciphertext last character differs only in trailing bits", fixturePath, strings.Replace(v1, ct1, malleate(ct1), 1))
+	add("v3-noncanonical-salt", "encoding", "salt_b64 last character differs only in trailing bits", fixturePath, strings.Replace(v3, salt3, malleate(salt3), 1))
+	add("v3-noncanonical-commit", "encoding", "commit_b64 last character differs only in trailing bits", fixturePath, strings.Replace(v3, commit3, malleate(commit3), 1))
+	add("v3-wrong-sk", "open", "SK from a different index", fixturePath, v3)
+	wrong, err := derive.DeriveSK(fixtureMnemonic, "778")
+	if err != nil {
+		return nil, err
+	}
+	s.Cases[len(s.Cases)-1].SKHex = hex.EncodeToString(wrong)
+	return s, nil
+}
+
+// Why(中文): 用例声明的失败层必须与实际一致；在错误的层失败同样算不合格，否则解析器放宽会被认证层掩盖。
+// Why(English): The declared failing stage must match reality; failing at the wrong stage also counts, or a loosened parser would be masked by the authentication layer.
+func checkReject(c rejectCase) error {
+	sk, err := hex.DecodeString(c.SKHex)
+	if err != nil {
+		return err
+	}
+	parsed, _, err := open(sk, c.Path, c.Envelope)
+	encodingErr := lockcore.CheckCanonicalB64([]byte(c.Envelope))
+	switch c.Stage {
+	case "parse", "encoding":
+		if parsed {
+			return errors.New("parsed but must be rejected by the parser")
+		}
+		if c.Stage == "encoding" && encodingErr != lockcore.ErrNonCanonical {
+			return errors.New("rejected but not classified as non-canonical encoding")
+		}
+	case "open":
+		if !parsed {
+			return errors.New("rejected by the parser but must reach authentication")
+		}
+		if err == nil {
+			return errors.New("opened but must fail authentication")
+		}
+	default:
+		return errors.New("unknown stage")
+	}
+	return nil
+}
+
+// Why(中文): 夹具封装统一走这里：SK 由夹具助记词派生，随机源只含夹具 salt 与 nonce。
+// Why(English): Fixture sealing goes through one place: SK derives from the fixture mnemonic and the randomness is just the fixture salt and nonce.
+func sealFixture(version, compress, pad string) (string, error) {
+	sk, err := derive.DeriveSK(fixtureMnemonic, fixtureIndex)
+	if err != nil {
+		return "", err
+	}
+	salt, _ := hex.DecodeString(fixtureSaltHex)
+	nonce, _ := hex.DecodeString(fixtureNonceHex)
+	return seal(sk, fixturePath, salt, nonce, version, compress, pad, []byte(fixturePlaintext))
+}
+
+// Why(中文): 随机源恰好是 salt||nonce，若实现多读一个字节就会报错，从而暴露向量不可复现的改动。
+// Why(English): The randomness is exactly salt||nonce; an implementation reading one more byte fails, exposing changes that would make vectors irreproducible.
+func seal(sk []byte, path string, salt, nonce []byte, version, compress, pad string, pt []byte) (string, error) {
+	random := bytes.NewReader(append(append([]byte{}, salt...), nonce...))
+	switch version {
+	case "v1":
+		if compress != "" || pad != "" {
+			return "", errors.New("v1 has no compress or pad")
+		}
+		r, err := lockcore.SealV1(sk, path, pt, random)
+		if err != nil {
+			return "", err
+		}
+		return lockcore.BuildEnvelopeV1(path, r.SaltB64, r.NonceB64, base64.RawStdEncoding.EncodeToString(r.Ciphertext)), nil
+	case "v2", "v3":
+		r, err := lockcore.SealV2(sk, path, pt, lockcore.SealOptionsV2{Commit: version == "v3", Compress: compress, Pad: pad}, random)
+		if err != nil {
+			return "", err
+		}
+		return lockcore.BuildEnvelopeV2(r.Header, base64.RawStdEncoding.EncodeToString(r.Ciphertext)), nil
+	}
+	return "", errors.New("unknown version")
+}
+
+// Why(中文): 与 txlock-dec 相同，先按 magic 行选择严格解析器，再认证解密；返回值区分“解析失败”与“认证失败”。
+// Why(English): As in txlock-dec, pick the strict parser by the magic line and then authenticate; the result separates parse failure from authentication failure.
+func open(sk []byte, path string, raw string) (bool, []byte, error) {
+	switch lockcore.DetectEnvelopeVersion(raw) {
+	case "v1":
+		_, salt, nonce, ct, ok := lockcore.ParseEnvelopeV1(raw)
+		if !ok {
+			return false, nil, nil
+		}
+		pt, err := lockcore.OpenV1(sk, path, salt, nonce, ct)
+		return true, pt, err
+	case "v2", "v3":
+		h, ct, ok := lockcore.ParseEnvelopeV2(raw)
+		if !ok {
+			return false, nil, nil
+		}
+		pt, err := lockcore.OpenV2(sk, path, h, ct, lockcore.OpenOptionsV2{})
+		return true, pt, err
+	}
+	return false, nil, nil
+}
+
+// Why(中文): HKDF 套件的 K 与 CK 直接取自恢复包同款 WorkedExample，两处向量因此不会各算各的。
+// Why(English): The HKDF suite takes K and CK from the same worked examples as the recovery kit, so the two sets of vectors are never computed separately.
+func workedExamples() (*lockcore.WorkedExample, *lockcore.WorkedExample, []byte, error) {
+	sk, err := derive.DeriveSK(fixtureMnemonic, fixtureIndex)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	salt, _ := hex.DecodeString(fixtureSaltHex)
+	nonce, _ := hex.DecodeString(fixtureNonceHex)
+	v1, err := lockcore.WorkedExampleV1(sk, fixturePath, salt, nonce, []byte(fixturePlaintext))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	v3, err := lockcore.WorkedExampleV3(sk, fixturePath, salt, nonce, []byte(fixturePlaintext))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return v1, v3, sk, nil
+}
+
+// Why(中文): 把首字符换成字母表中的相邻字符，保持 base64 合法与长度不变，只改变解码后的字节。
+// Why(English): Swap the first character for a neighbour in the alphabet, keeping the base64 valid and the length unchanged so only the decoded bytes differ.
+func flipFirst(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
+
+// Why(中文): 末字符换成只差最低位的字母表字符；仅用于末字符带尾部比特的字段，得到解码相同但拼写不同的可塑写法。
+// Why(English): Swap the last character for the alphabet neighbour differing in the lowest bit; used only on fields whose last character carries trailing bits, yielding a malleable spelling with identical decoded bytes.
+func malleate(s string) string {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	i := strings.IndexByte(alphabet, s[len(s)-1])
+	return s[:len(s)-1] + string(alphabet[i^1])
+}
+
+// Why(中文): JSON 中头字段用有序数组表示，保留 v2 头的顺序语义。
+// Why(English): Header fields are an ordered array in JSON, preserving the ordering semantics of the v2 header.
+func toEntries(h []lockcore.HeaderField) []headerEntry {
+	out := make([]headerEntry, 0, len(h))
+	for _, f := range h {
+		out = append(out, headerEntry{Key: f.Key, Value: f.Value})
+	}
+	return out
+}
+
+// Why(中文): toEntries 的逆操作，供校验时重建头字段。
+// Why(English): Inverse of toEntries, rebuilding the header for checking.
+func fromEntries(entries []headerEntry) []lockcore.HeaderField {
+	out := make([]lockcore.HeaderField, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, lockcore.HeaderField{Key: e.Key, Value: e.Value})
+	}
+	return out
+}
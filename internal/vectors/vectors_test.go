@@ -0,0 +1,96 @@
+package vectors
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const committedDir = "../../testdata/vectors"
+
+// Why(中文): 提交的套件必须与生成器输出逐字节一致且全部通过校验；实现改动若影响协议字节，这里会先于用户数据暴露出来。
+// Why(English): The committed suite must equal generator output byte for byte and pass every check; an implementation change that alters protocol bytes surfaces here before it reaches user data.
+func TestCommittedSuiteIsCurrentAndPasses(t *testing.T) {
+	files, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	for _, f := range files {
+		got, err := os.ReadFile(filepath.Join(committedDir, f.Name))
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		if !bytes.Equal(got, f.Data) {
+			t.Fatalf("%s is stale; run txlock vectors generate", f.Name)
+		}
+	}
+	n, failures, err := Check(committedDir)
+	if err != nil || len(failures) != 0 || n == 0 {
+		t.Fatalf("check: n=%d failures=%v err=%v", n, failures, err)
+	}
+}
+
+// Why(中文): 锚定外部标准：第一条 HKDF 用例必须等于 RFC 5869 A.1 的公开输出，夹具助记词的种子必须等于 BIP39 公开向量。
+// Why(English): Anchor to external standards: the first HKDF case must equal the published RFC 5869 A.1 output and the fixture mnemonic seed the published BIP39 vector.
+func TestSuiteMatchesPublishedStandards(t *testing.T) {
+	hkdf, _ := os.ReadFile(filepath.Join(committedDir, "hkdf.json"))
+	if !bytes.Contains(hkdf, []byte(`"okm_hex": "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"`)) {
+		t.Fatalf("hkdf.json misses the RFC 5869 A.1 output")
+	}
+	derive, _ := os.ReadFile(filepath.Join(committedDir, "derive.json"))
+	if !bytes.Contains(derive, []byte(`"seed_hex": "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4"`)) {
+		t.Fatalf("derive.json misses the BIP39 published seed")
+	}
+}
+
+// Why(中文): 校验必须能发现篡改：改动一个期望值、把 reject 用例换成合法信封、加入未知字段或未知文件，都不能通过。
+// Why(English): Checking must catch tampering: a changed expected value, a reject case swapped for a valid envelope, an unknown field or an unknown file must all fail.
+func TestCheckDetectsTampering(t *testing.T) {
+	files, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	write := func(edit func(name string, data string) string) string {
+		dir := t.TempDir()
+		for _, f := range files {
+			if err := os.WriteFile(filepath.Join(dir, f.Name), []byte(edit(f.Name, string(f.Data))), 0o644); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+		}
+		return dir
+	}
+	dir := write(func(name, data string) string {
+		if name == "aad.json" {
+			return strings.Replace(data, `chain:ethereum\n`, `chain:bitcoin\n`, 1)
+		}
+		return data
+	})
+	if _, failures, err := Check(dir); err != nil || len(failures) != 1 || !strings.Contains(failures[0], "aad mismatch") {
+		t.Fatalf("expected one aad mismatch, got %v %v", failures, err)
+	}
+	dir = write(func(name, data string) string {
+		if name == "reject.json" {
+			return strings.Replace(data, `"envelope": " <!--`, `"envelope": "<!--`, 1)
+		}
+		return data
+	})
+	if _, failures, err := Check(dir); err != nil || len(failures) != 1 || !strings.Contains(failures[0], "must be rejected") {
+		t.Fatalf("expected one reject failure, got %v %v", failures, err)
+	}
+	dir = write(func(name, data string) string {
+		return strings.Replace(data, `"name"`, `"nmae"`, 1)
+	})
+	if _, _, err := Check(dir); err == nil {
+		t.Fatalf("expected unknown field error")
+	}
+	dir = write(func(_, data string) string { return data })
+	if err := os.WriteFile(filepath.Join(dir, "extra.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, _, err := Check(dir); !errors.Is(err, ErrUnknownSuite) {
+		t.Fatalf("expected ErrUnknownSuite, got %v", err)
+	}
+}